@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"time"
 
 	"github.com/a1yama/git-cz-go/internal/config"
 	"github.com/a1yama/git-cz-go/internal/git"
@@ -10,21 +14,89 @@ import (
 )
 
 func main() {
-	// Load config
-	cfg, err := config.Load()
+	dir := flag.String("C", "", "run as if started in `path` instead of the current directory")
+	profile := flag.String("profile", "", "name of the config profile to use (overrides "+config.ProfileEnv+")")
+	configRef := flag.String("config-ref", "", "read the shared config from a git revision, e.g. origin/main:.git-cz.json")
+	backend := flag.String("backend", "", "how to access git: \""+git.BackendExec+"\" (default) or \""+git.BackendGoGit+"\" (no git binary needed)")
+
+	// Flags passed through to git commit
+	noVerify := flag.Bool("no-verify", false, "bypass the pre-commit and commit-msg hooks")
+	gpgSign := flag.Bool("gpg-sign", false, "GPG-sign the commit")
+	flag.BoolVar(gpgSign, "S", false, "shorthand for --gpg-sign")
+	author := flag.String("author", "", "override the commit author")
+	date := flag.String("date", "", "override the author date")
+	allowEmpty := flag.Bool("allow-empty", false, "allow a commit without changes")
+	signoff := flag.Bool("signoff", false, "add a Signed-off-by trailer")
+	flag.BoolVar(signoff, "s", false, "shorthand for --signoff")
+	flag.Parse()
+
+	// Stop git on Ctrl+C until the TUI takes over the terminal
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	repo, err := git.NewRepository(*backend, *dir)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Check if we're in a git repository
-	if !git.IsGitRepository() {
-		fmt.Fprintln(os.Stderr, "Error: not a git repository (or any of the parent directories)")
+	// Load config. Unlike the repository data it is loaded before the UI
+	// starts: it chooses the backend and the git timeouts, defines the steps
+	// of the wizard, and an invalid config ends the program. Without a shared
+	// config ref or profiles, it runs git only once, to read git config.
+	opts := config.LoadOptions{
+		Profile:    *profile,
+		ConfigRef:  *configRef,
+		Repository: repo,
+		Dir:        *dir,
+	}
+	cfg, err := config.Load(ctx, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Start the TUI
-	if err := ui.Run(cfg); err != nil {
+	// The backend setting from the config applies unless given on the command
+	// line. Git config, the shared config ref and the remotes for profiles are
+	// read through the repository, so the config is loaded again with it.
+	if *backend == "" && cfg.Backend != "" && cfg.Backend != git.BackendExec {
+		repo, err = git.NewRepository(cfg.Backend, *dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Repository = repo
+		if cfg, err = config.Load(ctx, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if execRepo, ok := repo.(*git.ExecRepository); ok {
+		execRepo.Timeout = time.Duration(cfg.GitTimeout)
+		execRepo.CommitTimeout = time.Duration(cfg.CommitTimeout)
+	}
+
+	if *noVerify {
+		cfg.CommitArgs = append(cfg.CommitArgs, "--no-verify")
+	}
+	if *gpgSign {
+		cfg.CommitArgs = append(cfg.CommitArgs, "--gpg-sign")
+	}
+	if *author != "" {
+		cfg.CommitArgs = append(cfg.CommitArgs, "--author="+*author)
+	}
+	if *date != "" {
+		cfg.CommitArgs = append(cfg.CommitArgs, "--date="+*date)
+	}
+	if *allowEmpty {
+		cfg.CommitArgs = append(cfg.CommitArgs, "--allow-empty")
+	}
+	if *signoff {
+		cfg.CommitArgs = append(cfg.CommitArgs, "--signoff")
+	}
+
+	// Start the TUI; it checks the repository and loads its data in the background
+	if err := ui.Run(ctx, cfg, repo); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
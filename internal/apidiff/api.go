@@ -0,0 +1,274 @@
+package apidiff
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"regexp"
+	"sort"
+)
+
+// Kinds of exported declarations, as named in the change messages
+const (
+	kindFunction        = "function"
+	kindMethod          = "method"
+	kindType            = "type"
+	kindField           = "field"
+	kindInterfaceMethod = "interface method"
+	kindEmbedded        = "embedded interface"
+	kindConstant        = "constant"
+	kindVariable        = "variable"
+)
+
+// decl is an exported declaration of a package
+type decl struct {
+	kind string
+	// name is the identifier, qualified with its type for members, like "Model.View"
+	name string
+	// owner is the type of a member, or ""
+	owner string
+	// signature is the part of the declaration that users depend on, like
+	// the parameter and result types of a function; "" if it is not known
+	signature string
+}
+
+// pkgAPI is the exported API declared in some files of a package
+type pkgAPI struct {
+	decls map[string]decl
+	// main is set for commands, which cannot be imported
+	main bool
+}
+
+// newPkgAPI creates an empty API
+func newPkgAPI() *pkgAPI {
+	return &pkgAPI{decls: make(map[string]decl)}
+}
+
+// keys returns the keys of the declarations by name, so that a type comes
+// right before its members
+func (a *pkgAPI) keys() []string {
+	keys := make([]string, 0, len(a.decls))
+	for key := range a.decls {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		di, dj := a.decls[keys[i]], a.decls[keys[j]]
+		if di.name != dj.name {
+			return di.name < dj.name
+		}
+		return di.kind < dj.kind
+	})
+	return keys
+}
+
+// add records a declaration
+func (a *pkgAPI) add(d decl) {
+	a.decls[d.kind+" "+d.name] = d
+}
+
+// addFile records the exported declarations of a Go source file
+func (a *pkgAPI) addFile(name string, content []byte) error {
+	file, err := parser.ParseFile(token.NewFileSet(), name, content, parser.SkipObjectResolution)
+	if err != nil {
+		return err
+	}
+	if file.Name.Name == "main" {
+		a.main = true
+	}
+
+	for _, d := range file.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			a.addFunc(d)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					a.addType(spec)
+				case *ast.ValueSpec:
+					a.addValue(d.Tok, spec)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// addFunc records an exported function, or a method of an exported type
+func (a *pkgAPI) addFunc(d *ast.FuncDecl) {
+	if !d.Name.IsExported() {
+		return
+	}
+	if d.Recv == nil || len(d.Recv.List) == 0 {
+		a.add(decl{kind: kindFunction, name: d.Name.Name, signature: funcSignature(d.Type)})
+		return
+	}
+	owner := receiverType(d.Recv.List[0].Type)
+	if !ast.IsExported(owner) {
+		return
+	}
+	a.add(decl{kind: kindMethod, name: owner + "." + d.Name.Name, owner: owner, signature: funcSignature(d.Type)})
+}
+
+// addType records an exported type with its exported fields or methods
+func (a *pkgAPI) addType(spec *ast.TypeSpec) {
+	if !spec.Name.IsExported() {
+		return
+	}
+	name := spec.Name.Name
+	signature := ""
+	if spec.TypeParams != nil {
+		signature = "[" + fieldTypes(spec.TypeParams, true) + "] "
+	}
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		signature += "struct"
+		for _, field := range t.Fields.List {
+			typ := typeString(field.Type)
+			for _, fieldName := range fieldNames(field) {
+				if ast.IsExported(fieldName) {
+					a.add(decl{kind: kindField, name: name + "." + fieldName, owner: name, signature: typ})
+				}
+			}
+		}
+	case *ast.InterfaceType:
+		signature += "interface"
+		for _, method := range t.Methods.List {
+			if len(method.Names) == 0 {
+				embedded := typeString(method.Type)
+				a.add(decl{kind: kindEmbedded, name: name + "." + embedded, owner: name, signature: embedded})
+				continue
+			}
+			for _, methodName := range method.Names {
+				if methodName.IsExported() {
+					a.add(decl{kind: kindInterfaceMethod, name: name + "." + methodName.Name, owner: name,
+						signature: funcSignature(method.Type.(*ast.FuncType))})
+				}
+			}
+		}
+	default:
+		if spec.Assign.IsValid() {
+			signature += "= "
+		}
+		signature += typeString(spec.Type)
+	}
+	a.add(decl{kind: kindType, name: name, signature: signature})
+}
+
+// addValue records exported constants and variables. Untyped constants can
+// be used like their previous type, so only typed ones are compared.
+func (a *pkgAPI) addValue(tok token.Token, spec *ast.ValueSpec) {
+	kind, signature := kindVariable, ""
+	if tok == token.CONST {
+		kind, signature = kindConstant, "untyped"
+	}
+	if spec.Type != nil {
+		signature = typeString(spec.Type)
+	}
+	for _, name := range spec.Names {
+		if name.IsExported() {
+			a.add(decl{kind: kind, name: name.Name, signature: signature})
+		}
+	}
+}
+
+// receiverType returns the name of the type of a method receiver
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.ParenExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// fieldNames returns the names of a struct field; an embedded field is
+// named after its type
+func fieldNames(field *ast.Field) []string {
+	if len(field.Names) > 0 {
+		names := make([]string, len(field.Names))
+		for i, name := range field.Names {
+			names[i] = name.Name
+		}
+		return names
+	}
+	expr := field.Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch t := expr.(type) {
+	case *ast.SelectorExpr:
+		return []string{t.Sel.Name}
+	case *ast.IndexExpr:
+		return []string{receiverType(t.X)}
+	case *ast.IndexListExpr:
+		return []string{receiverType(t.X)}
+	}
+	return []string{receiverType(expr)}
+}
+
+// funcSignature renders a function type without parameter names, which
+// callers do not depend on
+func funcSignature(fn *ast.FuncType) string {
+	signature := "func"
+	if fn.TypeParams != nil {
+		signature += "[" + fieldTypes(fn.TypeParams, true) + "]"
+	}
+	signature += "(" + fieldTypes(fn.Params, false) + ")"
+	if fn.Results != nil && len(fn.Results.List) > 0 {
+		results := fieldTypes(fn.Results, false)
+		if len(fn.Results.List) == 1 && len(fn.Results.List[0].Names) <= 1 {
+			signature += " " + results
+		} else {
+			signature += " (" + results + ")"
+		}
+	}
+	return signature
+}
+
+// fieldTypes renders the types of a field list, once per name. Type
+// parameters keep their names, which the constraints may refer to.
+func fieldTypes(list *ast.FieldList, named bool) string {
+	s := ""
+	for _, field := range list.List {
+		typ := typeString(field.Type)
+		count := max(len(field.Names), 1)
+		for i := 0; i < count; i++ {
+			if s != "" {
+				s += ", "
+			}
+			if named && i < len(field.Names) {
+				s += field.Names[i].Name + " "
+			}
+			s += typ
+		}
+	}
+	return s
+}
+
+// typeAliases matches the spellings of types that have another one, which
+// typeString replaces
+var typeAliases = regexp.MustCompile(`interface\{\}|\bbyte\b|\brune\b`)
+
+// typeString renders a type expression, spelling the same predeclared type
+// the same way: interface{} as any, byte as uint8 and rune as int32. Switching
+// between these spellings breaks no users.
+func typeString(expr ast.Expr) string {
+	return typeAliases.ReplaceAllStringFunc(types.ExprString(expr), func(alias string) string {
+		switch alias {
+		case "byte":
+			return "uint8"
+		case "rune":
+			return "int32"
+		}
+		return "any"
+	})
+}
@@ -0,0 +1,181 @@
+// Package apidiff finds incompatible changes to the exported API of the Go
+// packages touched by the staged changes.
+package apidiff
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/git"
+)
+
+// Change is an incompatible change to the exported API of a package
+type Change struct {
+	// Package is the directory of the package, relative to the repository root
+	Package string
+	// Message describes the change, like "removed function Parse"
+	Message string
+}
+
+// String returns the change with its package, as listed in a BREAKING CHANGE footer
+func (c Change) String() string {
+	return c.Package + ": " + c.Message
+}
+
+// Detect compares the exported API of the packages with staged Go files at
+// HEAD and in the index. Only the changed files are parsed: the other files
+// of a package are the same in both versions. Test files, commands and
+// internal packages, which cannot be imported by other modules, are left out.
+//
+// The declarations are compared as written, without type checking, so that
+// no dependencies have to be loaded. Types are compared by their spelling,
+// with the aliases of predeclared types, like any for interface{}, treated
+// alike. Changes are therefore missed when they only show through type
+// checking: a changed alias or named type that a declaration refers to, or
+// methods gained or lost through an embedded type. Spelling a type another
+// way, like with an alias instead of the type it names, is reported though.
+func Detect(ctx context.Context, repo git.Repository) ([]Change, error) {
+	changes, err := repo.GetStagedChanges(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	before := make(map[string]*pkgAPI)
+	after := make(map[string]*pkgAPI)
+	add := func(apis map[string]*pkgAPI, file string, content []byte) error {
+		dir := path.Dir(file)
+		if apis[dir] == nil {
+			apis[dir] = newPkgAPI()
+		}
+		return apis[dir].addFile(file, content)
+	}
+
+	for _, c := range changes {
+		if oldPath := previousPath(c); isAPIFile(oldPath) {
+			content, err := repo.ShowFile(ctx, "HEAD", oldPath)
+			if err != nil {
+				return nil, err
+			}
+			if err := add(before, oldPath, content); err != nil {
+				return nil, err
+			}
+		}
+		if c.Status != "D" && isAPIFile(c.Path) {
+			content, err := repo.ShowStagedFile(ctx, c.Path)
+			if err != nil {
+				return nil, err
+			}
+			if err := add(after, c.Path, content); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// New packages break nothing
+	dirs := make([]string, 0, len(before))
+	for dir := range before {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	var found []Change
+	for _, dir := range dirs {
+		old, updated := before[dir], after[dir]
+		if updated == nil {
+			updated = newPkgAPI()
+		}
+		if old.main || updated.main {
+			continue
+		}
+		for _, message := range compare(old, updated) {
+			found = append(found, Change{Package: dir, Message: message})
+		}
+	}
+	return found, nil
+}
+
+// previousPath returns the path of the file at HEAD, or "" for a new file
+func previousPath(c git.FileChange) string {
+	switch c.Status {
+	case "A", "C":
+		return ""
+	case "R":
+		return c.OldPath
+	}
+	return c.Path
+}
+
+// isAPIFile reports whether the file can declare exported API of an
+// importable package
+func isAPIFile(file string) bool {
+	if !strings.HasSuffix(file, ".go") || strings.HasSuffix(file, "_test.go") {
+		return false
+	}
+	for _, elem := range strings.Split(path.Dir(file), "/") {
+		if elem == "internal" || elem == "testdata" || elem == "vendor" {
+			return false
+		}
+	}
+	return true
+}
+
+// Summary describes the changes for a BREAKING CHANGE footer: a single
+// change on its own, several ones as a list
+func Summary(changes []Change) string {
+	switch len(changes) {
+	case 0:
+		return ""
+	case 1:
+		return changes[0].String()
+	}
+	lines := []string{"incompatible changes to the exported API:"}
+	for _, c := range changes {
+		lines = append(lines, "- "+c.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// compare returns the incompatible changes from old to updated. Members of
+// removed types, or of types that became another kind of type, are not
+// listed on their own.
+func compare(old, updated *pkgAPI) []string {
+	var messages []string
+	replaced := make(map[string]bool)
+	for _, key := range old.keys() {
+		decl := old.decls[key]
+		now, ok := updated.decls[key]
+		switch {
+		case decl.owner != "" && replaced[decl.owner]:
+		case ok && decl.kind == kindConstant && now.signature == "untyped":
+			// An untyped constant can still be used as the previous type
+		case !ok:
+			messages = append(messages, fmt.Sprintf("removed %s %s", decl.kind, decl.name))
+			if decl.kind == kindType {
+				replaced[decl.name] = true
+			}
+		case now.signature != decl.signature && decl.signature != "" && now.signature != "":
+			messages = append(messages, fmt.Sprintf("changed %s %s: %s → %s", decl.kind, decl.name, decl.signature, now.signature))
+			if decl.kind == kindType {
+				replaced[decl.name] = true
+			}
+		}
+	}
+
+	// Types outside the package can no longer implement a grown interface
+	for _, key := range updated.keys() {
+		decl := updated.decls[key]
+		if (decl.kind != kindInterfaceMethod && decl.kind != kindEmbedded) || replaced[decl.owner] {
+			continue
+		}
+		if _, ok := old.decls[key]; ok {
+			continue
+		}
+		if owner, ok := old.decls[kindType+" "+decl.owner]; ok && owner.signature == "interface" {
+			messages = append(messages, fmt.Sprintf("added %s %s", decl.kind, decl.name))
+		}
+	}
+	return messages
+}
@@ -0,0 +1,140 @@
+package apidiff
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/a1yama/git-cz-go/internal/git"
+)
+
+const before = `package commitmsg
+
+// Format formats a message
+func Format(typ, scope string, breaking bool) string { return "" }
+
+func Parse(s string) (Message, error) { return Message{}, nil }
+
+func Rename(old string) string { return old }
+
+type Message struct {
+	Type    string
+	Subject string
+	body    string
+}
+
+func (m *Message) Header() string { return "" }
+
+type Formatter interface {
+	Format(m Message) string
+}
+
+type Legacy struct {
+	Field int
+}
+
+const MaxLength = 100
+
+const Prefix string = "cz"
+
+func helper() {}
+`
+
+const after = `package commitmsg
+
+// Format formats a message, parameter names do not matter
+func Format(kind, area string, isBreaking bool) string { return "" }
+
+func Parse(s string, strict bool) (Message, error) { return Message{}, nil }
+
+func Renamed(old string) string { return old }
+
+type Message struct {
+	Type    string
+	Subject []string
+	Body    string
+}
+
+type Formatter interface {
+	Format(m Message) string
+	Name() string
+}
+
+const MaxLength = 72
+
+const Prefix = "cz"
+
+func helper(x int) {}
+`
+
+func TestDetect(t *testing.T) {
+	repo := git.NewMemoryRepository("/repo")
+	repo.CommonDir = t.TempDir()
+	repo.Commits = []git.LogEntry{{Hash: "c1", Message: "init"}}
+	repo.Files["pkg/commitmsg/format.go"] = []byte(before)
+	repo.Staged["pkg/commitmsg/format.go"] = []byte(after)
+	// Internal packages, commands and tests are not importable API
+	repo.Files["internal/x/x.go"] = []byte("package x\n\nfunc Gone() {}\n")
+	repo.Staged["internal/x/x.go"] = []byte("package x\n")
+	repo.Files["cmd/tool/main.go"] = []byte("package main\n\nfunc Gone() {}\n")
+	repo.Staged["cmd/tool/main.go"] = []byte("package main\n")
+	repo.Files["pkg/commitmsg/format_test.go"] = []byte("package commitmsg\n\nfunc TestGone() {}\n")
+	repo.Staged["pkg/commitmsg/format_test.go"] = []byte("package commitmsg\n")
+
+	changes, err := Detect(context.Background(), repo)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"pkg/commitmsg: removed type Legacy",
+		"pkg/commitmsg: removed method Message.Header",
+		"pkg/commitmsg: changed field Message.Subject: string → []string",
+		"pkg/commitmsg: changed function Parse: func(string) (Message, error) → func(string, bool) (Message, error)",
+		"pkg/commitmsg: removed function Rename",
+		"pkg/commitmsg: added interface method Formatter.Name",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Detect() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestDetectMovedDeclaration(t *testing.T) {
+	repo := git.NewMemoryRepository("/repo")
+	repo.CommonDir = t.TempDir()
+	repo.Commits = []git.LogEntry{{Hash: "c1", Message: "init"}}
+	repo.Files["api/a.go"] = []byte("package api\n\nfunc Moved(n int) int { return n }\n")
+	repo.Staged["api/a.go"] = []byte("package api\n")
+	repo.Staged["api/b.go"] = []byte("package api\n\nfunc Moved(m int) int { return m }\n")
+
+	changes, err := Detect(context.Background(), repo)
+	if err != nil || len(changes) != 0 {
+		t.Errorf("Detect() = %v, %v, want no changes for a moved function", changes, err)
+	}
+}
+
+func TestDetectRespelledTypes(t *testing.T) {
+	repo := git.NewMemoryRepository("/repo")
+	repo.CommonDir = t.TempDir()
+	repo.Commits = []git.LogEntry{{Hash: "c1", Message: "init"}}
+	repo.Files["api/a.go"] = []byte("package api\n\nfunc Encode(v interface{}) []byte { return nil }\n\nvar Runes []rune\n")
+	repo.Staged["api/a.go"] = []byte("package api\n\nfunc Encode(v any) []uint8 { return nil }\n\nvar Runes []int32\n")
+
+	changes, err := Detect(context.Background(), repo)
+	if err != nil || len(changes) != 0 {
+		t.Errorf("Detect() = %v, %v, want no changes for the same types spelled differently", changes, err)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	if got := Summary([]Change{{Package: "api", Message: "removed function A"}}); got != "api: removed function A" {
+		t.Errorf("Summary() of one change = %q", got)
+	}
+	got := Summary([]Change{{Package: "api", Message: "removed function A"}, {Package: "api", Message: "removed type B"}})
+	if want := "incompatible changes to the exported API:\n- api: removed function A\n- api: removed type B"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,186 @@
+// Package bump detects staged changes that only bump dependencies, and
+// proposes a commit message for them.
+package bump
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/diff"
+	"github.com/a1yama/git-cz-go/internal/git"
+)
+
+// Type and Scope are the commit type and scope proposed for dependency bumps
+const (
+	Type  = "build"
+	Scope = "deps"
+)
+
+// Bump is a dependency whose version changed
+type Bump struct {
+	// Name is the module, package or submodule path
+	Name string
+	// From and To are the versions before and after the change; From is
+	// empty for an added dependency and To for a removed one
+	From string
+	To   string
+	// Indirect is set for dependencies only needed by other dependencies,
+	// like Go modules marked "// indirect"
+	Indirect bool
+	// Dev is set for development dependencies, like npm devDependencies
+	Dev bool
+	// Major is set when the major version changed
+	Major bool
+}
+
+// Content loads a version of a changed file; nil content means the file
+// does not exist in that version
+type Content func() ([]byte, error)
+
+// Detector finds the bumps in the files of one kind of dependency manifest
+type Detector interface {
+	// Match reports whether the detector handles the changed file. Files
+	// it matches without bumps, like lock files, are still dependency files.
+	Match(f diff.File) bool
+	// Detect returns the bumps in the changed file, given its content at
+	// HEAD and in the index
+	Detect(f diff.File, before, after Content) ([]Bump, error)
+}
+
+// Detectors returns the built-in detectors
+func Detectors() []Detector {
+	return []Detector{GoModules{}, NPM{}, Submodules{}}
+}
+
+// Detect returns the dependency bumps of the staged changes. It returns no
+// bumps unless every staged file is matched by one of the detectors.
+func Detect(ctx context.Context, repo git.Repository, detectors []Detector) ([]Bump, error) {
+	text, err := repo.GetStagedDiff(ctx)
+	if err != nil {
+		return nil, err
+	}
+	files, err := diff.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var bumps []Bump
+	for _, f := range files {
+		detector := match(detectors, f)
+		if detector == nil {
+			return nil, nil
+		}
+
+		f := f
+		before := func() ([]byte, error) {
+			if f.OldPath == "" {
+				return nil, nil
+			}
+			return repo.ShowFile(ctx, "HEAD", f.OldPath)
+		}
+		after := func() ([]byte, error) {
+			if f.NewPath == "" {
+				return nil, nil
+			}
+			return repo.ShowStagedFile(ctx, f.NewPath)
+		}
+		found, err := detector.Detect(f, before, after)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Path(), err)
+		}
+		bumps = append(bumps, found...)
+	}
+	return bumps, nil
+}
+
+// match returns the first detector that handles the file, or nil
+func match(detectors []Detector, f diff.File) Detector {
+	for _, d := range detectors {
+		if d.Match(f) {
+			return d
+		}
+	}
+	return nil
+}
+
+// Message returns the subject and body proposed for the bumps: the bump
+// itself as subject, or a count with a bulleted list of the bumps as body.
+func Message(bumps []Bump) (subject, body string) {
+	switch len(bumps) {
+	case 0:
+		return "", ""
+	case 1:
+		return bumps[0].String(), notes(bumps[0])
+	}
+
+	// Direct dependencies first, the order of the files breaks ties
+	sorted := append([]Bump(nil), bumps...)
+	sort.SliceStable(sorted, func(i, j int) bool { return rank(sorted[i]) < rank(sorted[j]) })
+
+	verb := "bump"
+	lines := make([]string, len(sorted))
+	for i, b := range sorted {
+		if b.From == "" || b.To == "" {
+			verb = "update"
+		}
+		lines[i] = "- " + b.String()
+		if tags := b.tags(); len(tags) > 0 {
+			lines[i] += " (" + strings.Join(tags, ", ") + ")"
+		}
+	}
+	return fmt.Sprintf("%s %d dependencies", verb, len(bumps)), strings.Join(lines, "\n")
+}
+
+// String describes the bump, like "bump example.com/mod from v1.0.0 to v1.1.0"
+func (b Bump) String() string {
+	switch {
+	case b.From == "":
+		return fmt.Sprintf("add %s %s", b.Name, b.To)
+	case b.To == "":
+		return fmt.Sprintf("remove %s %s", b.Name, b.From)
+	}
+	return fmt.Sprintf("bump %s from %s to %s", b.Name, b.From, b.To)
+}
+
+// tags returns the short notes on the bump listed in a multi-bump body
+func (b Bump) tags() []string {
+	var tags []string
+	if b.Major {
+		tags = append(tags, "major")
+	}
+	if b.Indirect {
+		tags = append(tags, "indirect")
+	}
+	if b.Dev {
+		tags = append(tags, "dev")
+	}
+	return tags
+}
+
+// notes returns the body for a single bump, empty for a plain minor bump
+func notes(b Bump) string {
+	var lines []string
+	if b.Major {
+		lines = append(lines, "This is a major version upgrade, which may contain breaking changes.")
+	}
+	if b.Indirect {
+		lines = append(lines, "It is an indirect dependency.")
+	}
+	if b.Dev {
+		lines = append(lines, "It is a development dependency.")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// rank orders direct dependencies before development and indirect ones
+func rank(b Bump) int {
+	switch {
+	case b.Indirect:
+		return 2
+	case b.Dev:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,180 @@
+package bump
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/a1yama/git-cz-go/internal/diff"
+	"github.com/a1yama/git-cz-go/internal/git"
+)
+
+const goModBefore = `module example.com/app
+
+go 1.24
+
+require (
+	github.com/charmbracelet/bubbletea v0.24.2
+	github.com/old/mod v1.4.0
+	github.com/removed/mod v1.0.0
+)
+
+require golang.org/x/sys v0.32.0 // indirect
+`
+
+const goModAfter = `module example.com/app
+
+go 1.24
+
+require (
+	github.com/charmbracelet/bubbletea v0.25.0
+	github.com/old/mod/v2 v2.0.1
+	github.com/added/mod v0.1.0
+)
+
+require golang.org/x/sys v0.33.0 // indirect
+`
+
+// newRepo returns a repository with a commit, so that HEAD exists, and its
+// git directory in a temporary directory
+func newRepo(t *testing.T) *git.MemoryRepository {
+	repo := git.NewMemoryRepository("/repo")
+	repo.CommonDir = t.TempDir()
+	repo.Commits = []git.LogEntry{{Hash: "abc123", Message: "init"}}
+	return repo
+}
+
+func TestDetect(t *testing.T) {
+	ctx := context.Background()
+	repo := newRepo(t)
+	repo.Files["go.mod"] = []byte(goModBefore)
+	repo.Files["go.sum"] = []byte("old sums\n")
+	repo.Staged["go.mod"] = []byte(goModAfter)
+	repo.Staged["go.sum"] = []byte("new sums\n")
+
+	bumps, err := Detect(ctx, repo, Detectors())
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	want := []Bump{
+		{Name: "github.com/charmbracelet/bubbletea", From: "v0.24.2", To: "v0.25.0"},
+		{Name: "github.com/old/mod/v2", From: "v1.4.0", To: "v2.0.1", Major: true},
+		{Name: "github.com/added/mod", To: "v0.1.0"},
+		{Name: "golang.org/x/sys", From: "v0.32.0", To: "v0.33.0", Indirect: true},
+		{Name: "github.com/removed/mod", From: "v1.0.0"},
+	}
+	if !reflect.DeepEqual(bumps, want) {
+		t.Errorf("Detect() = %+v, want %+v", bumps, want)
+	}
+
+	// Any other staged file means the change is not only a bump
+	repo.Staged["main.go"] = []byte("package main\n")
+	if bumps, err := Detect(ctx, repo, Detectors()); err != nil || bumps != nil {
+		t.Errorf("Detect() with a source file = %+v, %v, want no bumps", bumps, err)
+	}
+}
+
+func TestNPMDetect(t *testing.T) {
+	before := `{"name": "app", "dependencies": {"react": "^17.0.2", "lodash": "^4.17.20"},
+		"devDependencies": {"jest": "~29.0.0"}}`
+	after := `{"name": "app", "dependencies": {"react": "^18.2.0", "lodash": "^4.17.21"},
+		"devDependencies": {"jest": "~29.1.0"}}`
+	f := diff.File{OldPath: "web/package.json", NewPath: "web/package.json"}
+	if !(NPM{}).Match(f) || !(NPM{}).Match(diff.File{NewPath: "yarn.lock"}) {
+		t.Fatal("Match() = false for package.json or yarn.lock")
+	}
+
+	bumps, err := NPM{}.Detect(f, content(before), content(after))
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	want := []Bump{
+		{Name: "lodash", From: "^4.17.20", To: "^4.17.21"},
+		{Name: "react", From: "^17.0.2", To: "^18.2.0", Major: true},
+		{Name: "jest", From: "~29.0.0", To: "~29.1.0", Dev: true},
+	}
+	if !reflect.DeepEqual(bumps, want) {
+		t.Errorf("Detect() = %+v, want %+v", bumps, want)
+	}
+}
+
+func TestSubmodulesDetect(t *testing.T) {
+	files, err := diff.Parse(`diff --git a/vendor/lib b/vendor/lib
+index 1111111..2222222 160000
+--- a/vendor/lib
++++ b/vendor/lib
+@@ -1 +1 @@
+-Subproject commit 1111111aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa
++Subproject commit 2222222bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb
+diff --git a/README.md b/README.md
+index 1234567..89abcde 100644
+--- a/README.md
++++ b/README.md
+@@ -1 +1 @@
+-Subproject commit in the text
++something else
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !(Submodules{}).Match(files[0]) || (Submodules{}).Match(files[1]) {
+		t.Fatal("Match() should only accept the submodule")
+	}
+	bumps, err := Submodules{}.Detect(files[0], nil, nil)
+	want := []Bump{{Name: "vendor/lib", From: "1111111", To: "2222222"}}
+	if err != nil || !reflect.DeepEqual(bumps, want) {
+		t.Errorf("Detect() = %+v, %v, want %+v", bumps, err, want)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		bumps       []Bump
+		wantSubject string
+		wantBody    string
+	}{
+		{
+			name:        "single bump",
+			bumps:       []Bump{{Name: "github.com/charmbracelet/bubbletea", From: "v0.24.2", To: "v0.25.0"}},
+			wantSubject: "bump github.com/charmbracelet/bubbletea from v0.24.2 to v0.25.0",
+		},
+		{
+			name:        "major indirect bump",
+			bumps:       []Bump{{Name: "example.com/mod/v2", From: "v1.0.0", To: "v2.0.0", Major: true, Indirect: true}},
+			wantSubject: "bump example.com/mod/v2 from v1.0.0 to v2.0.0",
+			wantBody:    "This is a major version upgrade, which may contain breaking changes.\nIt is an indirect dependency.",
+		},
+		{
+			name: "several bumps",
+			bumps: []Bump{
+				{Name: "golang.org/x/sys", From: "v0.32.0", To: "v0.33.0", Indirect: true},
+				{Name: "jest", From: "29.0.0", To: "30.0.0", Dev: true, Major: true},
+				{Name: "github.com/a/b", From: "v1.0.0", To: "v1.1.0"},
+			},
+			wantSubject: "bump 3 dependencies",
+			wantBody: "- bump github.com/a/b from v1.0.0 to v1.1.0\n" +
+				"- bump jest from 29.0.0 to 30.0.0 (major, dev)\n" +
+				"- bump golang.org/x/sys from v0.32.0 to v0.33.0 (indirect)",
+		},
+		{
+			name:        "added and removed",
+			bumps:       []Bump{{Name: "a", To: "v1.0.0"}, {Name: "b", From: "v1.0.0"}},
+			wantSubject: "update 2 dependencies",
+			wantBody:    "- add a v1.0.0\n- remove b v1.0.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, body := Message(tt.bumps)
+			if subject != tt.wantSubject || body != tt.wantBody {
+				t.Errorf("Message() = %q, %q, want %q, %q", subject, body, tt.wantSubject, tt.wantBody)
+			}
+		})
+	}
+}
+
+// content returns a Content with the given text
+func content(text string) Content {
+	return func() ([]byte, error) { return []byte(text), nil }
+}
@@ -0,0 +1,106 @@
+package bump
+
+import (
+	"path"
+
+	"github.com/a1yama/git-cz-go/internal/diff"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// GoModules detects bumps of the requirements in go.mod files. A module
+// replaced by its next major version, like example.com/mod by
+// example.com/mod/v2, is a major bump.
+type GoModules struct{}
+
+// Match handles go.mod and go.sum
+func (GoModules) Match(f diff.File) bool {
+	name := path.Base(f.Path())
+	return name == "go.mod" || name == "go.sum"
+}
+
+// Detect compares the requirements of a go.mod file; go.sum has no bumps
+func (GoModules) Detect(f diff.File, before, after Content) ([]Bump, error) {
+	if path.Base(f.Path()) != "go.mod" {
+		return nil, nil
+	}
+	old, err := parseGoMod(f.OldPath, before)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := parseGoMod(f.NewPath, after)
+	if err != nil {
+		return nil, err
+	}
+
+	required := make(map[string]*modfile.Require, len(old))
+	for _, r := range old {
+		required[r.Mod.Path] = r
+	}
+	var bumps []Bump
+	for _, r := range updated {
+		prev, ok := required[r.Mod.Path]
+		if !ok {
+			bumps = append(bumps, Bump{Name: r.Mod.Path, To: r.Mod.Version, Indirect: r.Indirect})
+			continue
+		}
+		delete(required, r.Mod.Path)
+		if prev.Mod.Version != r.Mod.Version {
+			bumps = append(bumps, Bump{
+				Name:     r.Mod.Path,
+				From:     prev.Mod.Version,
+				To:       r.Mod.Version,
+				Indirect: r.Indirect,
+				Major:    semver.Major(prev.Mod.Version) != semver.Major(r.Mod.Version),
+			})
+		}
+	}
+
+	// Removed modules are either replaced by another major version or
+	// listed last
+	for _, r := range old {
+		if _, ok := required[r.Mod.Path]; !ok {
+			continue
+		}
+		if i := majorSuccessor(bumps, r.Mod.Path); i >= 0 {
+			bumps[i].From = r.Mod.Version
+			bumps[i].Major = true
+			continue
+		}
+		bumps = append(bumps, Bump{Name: r.Mod.Path, From: r.Mod.Version, Indirect: r.Indirect})
+	}
+	return bumps, nil
+}
+
+// majorSuccessor returns the index of the added module that is another
+// major version of the removed module, or -1
+func majorSuccessor(bumps []Bump, removed string) int {
+	prefix, _, ok := module.SplitPathVersion(removed)
+	if !ok {
+		return -1
+	}
+	for i, b := range bumps {
+		if b.From != "" {
+			continue
+		}
+		if p, _, ok := module.SplitPathVersion(b.Name); ok && p == prefix {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseGoMod returns the requirements of a version of a go.mod file, none
+// if the file does not exist
+func parseGoMod(name string, content Content) ([]*modfile.Require, error) {
+	data, err := content()
+	if err != nil || data == nil {
+		return nil, err
+	}
+	file, err := modfile.ParseLax(name, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	return file.Require, nil
+}
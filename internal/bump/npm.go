@@ -0,0 +1,108 @@
+package bump
+
+import (
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/diff"
+	"golang.org/x/mod/semver"
+)
+
+// npmLockFiles are the lock files that change along with package.json
+var npmLockFiles = map[string]bool{
+	"package-lock.json":   true,
+	"npm-shrinkwrap.json": true,
+	"yarn.lock":           true,
+	"pnpm-lock.yaml":      true,
+}
+
+// NPM detects bumps of the dependencies in package.json files
+type NPM struct{}
+
+// Match handles package.json and its lock files
+func (NPM) Match(f diff.File) bool {
+	name := path.Base(f.Path())
+	return name == "package.json" || npmLockFiles[name]
+}
+
+// packageJSON holds the dependency lists of a package.json file
+type packageJSON struct {
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+}
+
+// Detect compares the dependency lists of a package.json file; lock files
+// have no bumps
+func (NPM) Detect(f diff.File, before, after Content) ([]Bump, error) {
+	if path.Base(f.Path()) != "package.json" {
+		return nil, nil
+	}
+	old, err := parsePackageJSON(before)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := parsePackageJSON(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var bumps []Bump
+	bumps = append(bumps, npmBumps(old.Dependencies, updated.Dependencies, false)...)
+	bumps = append(bumps, npmBumps(old.OptionalDependencies, updated.OptionalDependencies, false)...)
+	bumps = append(bumps, npmBumps(old.PeerDependencies, updated.PeerDependencies, false)...)
+	bumps = append(bumps, npmBumps(old.DevDependencies, updated.DevDependencies, true)...)
+	return bumps, nil
+}
+
+// npmBumps compares a dependency list, in package name order
+func npmBumps(old, updated map[string]string, dev bool) []Bump {
+	names := make(map[string]bool, len(old)+len(updated))
+	for name := range old {
+		names[name] = true
+	}
+	for name := range updated {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var bumps []Bump
+	for _, name := range sorted {
+		from, to := old[name], updated[name]
+		if from == to {
+			continue
+		}
+		b := Bump{Name: name, From: from, To: to, Dev: dev}
+		if from != "" && to != "" {
+			b.Major = npmMajor(from) != npmMajor(to)
+		}
+		bumps = append(bumps, b)
+	}
+	return bumps
+}
+
+// npmMajor returns the major version of a version range like "^1.2.3", or
+// "" if it is not a plain version
+func npmMajor(version string) string {
+	version = strings.TrimLeft(version, "^~=<>v ")
+	return semver.Major("v" + version)
+}
+
+// parsePackageJSON parses a version of a package.json file, empty if the
+// file does not exist
+func parsePackageJSON(content Content) (packageJSON, error) {
+	var pkg packageJSON
+	data, err := content()
+	if err != nil || data == nil {
+		return pkg, err
+	}
+	err = json.Unmarshal(data, &pkg)
+	return pkg, err
+}
@@ -0,0 +1,59 @@
+package bump
+
+import (
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/diff"
+)
+
+// subprojectPrefix starts the lines of a submodule diff
+const subprojectPrefix = "Subproject commit "
+
+// Submodules detects submodules whose commit changed
+type Submodules struct{}
+
+// Match handles the diff of a submodule, which only has subproject lines
+func (Submodules) Match(f diff.File) bool {
+	if len(f.Hunks) == 0 {
+		return false
+	}
+	for _, h := range f.Hunks {
+		for _, line := range h.Lines {
+			if line.Kind != diff.Context && !strings.HasPrefix(line.Text, subprojectPrefix) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Detect returns the change of the submodule commit, with abbreviated ids
+func (Submodules) Detect(f diff.File, before, after Content) ([]Bump, error) {
+	b := Bump{Name: f.Path()}
+	for _, h := range f.Hunks {
+		for _, line := range h.Lines {
+			commit := shortCommit(strings.TrimPrefix(line.Text, subprojectPrefix))
+			switch line.Kind {
+			case diff.Removed:
+				b.From = commit
+			case diff.Added:
+				b.To = commit
+			}
+		}
+	}
+	if b.From == b.To {
+		// Only the dirty marker of the working tree changed
+		return nil, nil
+	}
+	return []Bump{b}, nil
+}
+
+// shortCommit abbreviates a commit id the way git log --oneline does by
+// default, dropping the "-dirty" marker
+func shortCommit(id string) string {
+	id = strings.TrimSuffix(id, "-dirty")
+	if len(id) > 7 {
+		return id[:7]
+	}
+	return id
+}
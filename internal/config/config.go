@@ -1,10 +1,17 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/a1yama/git-cz-go/internal/git"
 	"github.com/mitchellh/go-homedir"
 )
 
@@ -15,11 +22,154 @@ type CommitType struct {
 	Emoji       string `json:"emoji,omitempty"`
 }
 
+// Scope represents a commit scope.
+// In JSON a scope is either an object or just its name as a string.
+type Scope struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Paths are the path patterns of the files that belong to the scope,
+	// see MatchPath and SuggestScope
+	Paths []string `json:"paths,omitempty"`
+}
+
+// UnmarshalJSON accepts both "name" and {"name": ..., "description": ...}
+func (s *Scope) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*s = Scope{Name: name}
+		return nil
+	}
+
+	type scope Scope
+	return json.Unmarshal(data, (*scope)(s))
+}
+
+// Duration is a time.Duration written as a string like "30s" in JSON.
+// Zero means no limit.
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return d.set(s)
+}
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// set parses a duration string like "30s"; a bare "0" is allowed
+func (d *Duration) set(value string) error {
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < 0 {
+		return fmt.Errorf("expected a duration like \"30s\", got %q", value)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Levels of the imperative mood check of the subject
+const (
+	// ImperativeWarning shows a warning, the default
+	ImperativeWarning = "warning"
+	// ImperativeError refuses the subject
+	ImperativeError = "error"
+	// ImperativeOff disables the check
+	ImperativeOff = "off"
+)
+
+// parseImperativeCheck returns the level of the imperative mood check that
+// value names, ignoring case and surrounding spaces; "" means
+// ImperativeWarning. Every layer of the config uses it, so that "Error" means
+// the same in a JSON file and in git config.
+func parseImperativeCheck(value string) (string, error) {
+	level := strings.ToLower(strings.TrimSpace(value))
+	switch level {
+	case "", ImperativeWarning, ImperativeError, ImperativeOff:
+		return level, nil
+	}
+	return "", fmt.Errorf("unknown level %q, expected %q, %q or %q", value, ImperativeWarning, ImperativeError, ImperativeOff)
+}
+
 // Config holds the configuration for git-cz-go
 type Config struct {
 	Types            []CommitType `json:"types"`
+	Scopes           []Scope      `json:"scopes,omitempty"`
 	UseEmoji         bool         `json:"useEmoji"`
 	MaxSubjectLength int          `json:"maxSubjectLength"`
+
+	// ScopeDirs are the directory patterns scopes are detected from when no
+	// scopes are configured, e.g. ["*", "packages/*"]; see git.DetectScopes
+	ScopeDirs []string `json:"scopeDirs,omitempty"`
+
+	// TypeRules suggest a type from the staged files; the first rule that
+	// matches all of them wins, see SuggestType
+	TypeRules []TypeRule `json:"typeRules,omitempty"`
+
+	// ImperativeCheck sets how a subject that does not start with a verb in
+	// the imperative mood is reported, see the Imperative* levels; "" means
+	// ImperativeWarning
+	ImperativeCheck string `json:"imperativeCheck,omitempty"`
+	// ImperativeVerbs are verbs known to the check besides the bundled ones,
+	// like "dockerize" or "undo undid undone" for irregular ones, see mood.NewLexicon
+	ImperativeVerbs []string `json:"imperativeVerbs,omitempty"`
+
+	// GitTimeout limits each git operation other than commit
+	GitTimeout Duration `json:"gitTimeout,omitempty"`
+	// CommitTimeout limits git commit, including its hooks
+	CommitTimeout Duration `json:"commitTimeout,omitempty"`
+	// CommitArgs are extra flags passed to git commit, like "--signoff"
+	CommitArgs []string `json:"commitArgs,omitempty"`
+
+	// Backend selects how git is accessed: "exec" runs the git binary,
+	// "go-git" uses the pure Go implementation
+	Backend string `json:"backend,omitempty"`
+
+	// ConfigRef names a shared config file in a git revision as "<rev>:<path>",
+	// e.g. "origin/main:.git-cz.json"
+	ConfigRef string `json:"configRef,omitempty"`
+
+	// Profiles are named sets of settings, see Profile
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+	// Profile is the name of the active profile, if any
+	Profile string `json:"-"`
+}
+
+// Clone returns a deep copy of the config, so that settings can be applied
+// to the copy without changing the config
+func (c *Config) Clone() *Config {
+	clone := *c
+	clone.Types = slices.Clone(c.Types)
+	clone.Scopes = slices.Clone(c.Scopes)
+	for i := range clone.Scopes {
+		clone.Scopes[i].Paths = slices.Clone(clone.Scopes[i].Paths)
+	}
+	clone.ScopeDirs = slices.Clone(c.ScopeDirs)
+	clone.TypeRules = slices.Clone(c.TypeRules)
+	for i := range clone.TypeRules {
+		clone.TypeRules[i].Paths = slices.Clone(clone.TypeRules[i].Paths)
+	}
+	clone.ImperativeVerbs = slices.Clone(c.ImperativeVerbs)
+	clone.CommitArgs = slices.Clone(c.CommitArgs)
+	clone.Profiles = maps.Clone(c.Profiles)
+	return &clone
+}
+
+// LoadOptions holds per-invocation options for Load
+type LoadOptions struct {
+	// Profile selects a profile by name, overriding GIT_CZ_PROFILE and automatic matching
+	Profile string
+	// ConfigRef overrides GIT_CZ_CONFIG_REF and the configRef setting
+	ConfigRef string
+	// Repository is the repository to read git config and revisions from;
+	// nil means the repository in Dir
+	Repository git.Repository
+	// Dir is the directory to use instead of the current directory, like `git -C`
+	Dir string
 }
 
 // DefaultConfig returns the default configuration
@@ -38,67 +188,189 @@ func DefaultConfig() *Config {
 			{Type: "chore", Description: "Other changes that don't modify src or test files", Emoji: "♻️"},
 			{Type: "revert", Description: "Reverts a previous commit", Emoji: "🗑"},
 		},
+		TypeRules:        defaultTypeRules(),
 		UseEmoji:         true,
 		MaxSubjectLength: 100,
+		GitTimeout:       Duration(git.DefaultTimeout),
+		CommitTimeout:    Duration(git.DefaultCommitTimeout),
 	}
 }
 
-// configFilePaths returns a list of possible config file locations
-func configFilePaths() ([]string, error) {
-	home, err := homedir.Dir()
-	if err != nil {
-		return nil, err
-	}
+// configFilePaths returns a list of possible config file locations.
+// The home directory locations are skipped if there is no home directory.
+func configFilePaths(dir string) []string {
+	// Current directory
+	paths := []string{filepath.Join(dir, ".git-cz.json")}
 
-	// Current directory and user's home directory
-	return []string{
-		"./.git-cz.json",
-		filepath.Join(home, ".git-cz.json"),
-		filepath.Join(home, ".config", "git-cz", "config.json"),
-	}, nil
+	// User's home directory
+	if home, err := homedir.Dir(); err == nil {
+		paths = append(paths,
+			filepath.Join(home, ".git-cz.json"),
+			filepath.Join(home, ".config", "git-cz", "config.json"),
+		)
+	}
+	return paths
 }
 
-// Load loads the configuration from disk
-func Load() (*Config, error) {
+// Load loads the configuration.
+//
+// Settings are applied in layers, each overriding the previous one:
+//
+//  1. the built-in defaults
+//  2. the shared config file read from a git revision, if configured (see
+//     loadConfigRef), or else the JSON config file named by GIT_CZ_CONFIG, or
+//     else the first one found (see configFilePaths), with the profiles of the
+//     others (see loadFiles)
+//  3. cz.* keys from git config, e.g. `git config cz.useEmoji false`
+//  4. the selected profile, if any (see selectProfile)
+//  5. GIT_CZ_* environment variables (see applyEnv)
+//
+// Nested config files for monorepos are applied later, once the staged
+// files are known (see ApplyNested); environment variables still win over them.
+//
+// Because git config is read through git itself, its own precedence
+// (system < global < repository, include and includeIf) applies within layer 3.
+// The revision for layer 2 is taken from opts, GIT_CZ_CONFIG_REF, cz.configRef
+// in git config or the configRef setting of the JSON config file, in that order.
+func Load(ctx context.Context, opts LoadOptions) (*Config, error) {
 	config := DefaultConfig()
 
-	paths, err := configFilePaths()
-	if err != nil {
+	repo := opts.Repository
+	if repo == nil {
+		repo = git.NewExecRepository(opts.Dir)
+	}
+
+	if path := os.Getenv(ConfigEnv); path != "" {
+		// An explicit config file must exist and be valid
+		if err := config.ApplyFile(path); err != nil {
+			return config, err
+		}
+	} else {
+		loadFiles(config, configFilePaths(opts.Dir))
+	}
+
+	gitConfig := readGitConfig(ctx, repo)
+	configRef := opts.ConfigRef
+	if configRef == "" {
+		configRef = os.Getenv(ConfigRefEnv)
+	}
+	if configRef == "" {
+		configRef = gitConfigRef(gitConfig)
+	}
+	if configRef == "" {
+		configRef = config.ConfigRef
+	}
+	if configRef != "" {
+		if err := config.loadConfigRef(ctx, configRef, repo); err != nil {
+			return config, err
+		}
+	}
+
+	if err := applyGitConfig(config, gitConfig); err != nil {
 		return config, err
 	}
+	config.ConfigRef = configRef
 
-	// Try to load from each path
-	for _, path := range paths {
-		if _, err := os.Stat(path); err == nil {
-			file, err := os.Open(path)
-			if err != nil {
-				continue
-			}
-			defer file.Close()
+	if name := config.selectProfile(ctx, opts.Profile, repo); name != "" {
+		if err := config.applyProfile(name); err != nil {
+			return config, err
+		}
+	}
 
-			if err := json.NewDecoder(file).Decode(config); err == nil {
-				return config, nil
-			}
+	if err := config.applyEnv(); err != nil {
+		return config, err
+	}
+
+	if err := git.ValidateCommitArgs(config.CommitArgs); err != nil {
+		return config, fmt.Errorf("invalid commitArgs: %w", err)
+	}
+	if err := git.ValidateBackend(config.Backend); err != nil {
+		return config, fmt.Errorf("invalid backend: %w", err)
+	}
+	level, err := parseImperativeCheck(config.ImperativeCheck)
+	if err != nil {
+		return config, fmt.Errorf("invalid imperativeCheck: %w", err)
+	}
+	config.ImperativeCheck = level
+	if err := validateTypeRules(config.TypeRules); err != nil {
+		return config, fmt.Errorf("invalid typeRules: %w", err)
+	}
+	for _, scope := range config.Scopes {
+		if err := validatePathPatterns(scope.Paths); err != nil {
+			return config, fmt.Errorf("invalid paths of scope %q: %w", scope.Name, err)
 		}
 	}
 
-	// Return default config if no config file found
 	return config, nil
 }
 
-// Save saves the configuration to disk
-func (c *Config) Save() error {
-	home, err := homedir.Dir()
+// loadFiles decodes the first of the JSON config files that is found into
+// config. Profiles are personal rather than per project, so those of the
+// later files, like the user's config, are added too unless a file found
+// before defines a profile with the same name.
+func loadFiles(config *Config, paths []string) {
+	for i, path := range paths {
+		if loadFile(config, path) {
+			for _, path := range paths[i+1:] {
+				loadProfiles(config, path)
+			}
+			return
+		}
+	}
+}
+
+// loadProfiles adds the profiles of the JSON config file at path that config
+// does not define yet
+func loadProfiles(config *Config, path string) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return err
+		return
+	}
+	var file struct {
+		Profiles map[string]Profile `json:"profiles"`
+	}
+	if json.Unmarshal(data, &file) != nil {
+		return
+	}
+	for name, profile := range file.Profiles {
+		if _, ok := config.Profiles[name]; ok {
+			continue
+		}
+		if config.Profiles == nil {
+			config.Profiles = make(map[string]Profile)
+		}
+		config.Profiles[name] = profile
+	}
+}
+
+// loadFile decodes the JSON config file at path into config.
+// It reports whether the file was found and decoded.
+func loadFile(config *Config, path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	return json.NewDecoder(file).Decode(config) == nil
+}
+
+// Save saves the configuration to disk, to the file named by GIT_CZ_CONFIG
+// or else to ~/.config/git-cz/config.json
+func (c *Config) Save() error {
+	configPath := os.Getenv(ConfigEnv)
+	if configPath == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return err
+		}
+		configPath = filepath.Join(home, ".config", "git-cz", "config.json")
 	}
 
-	configDir := filepath.Join(home, ".config", "git-cz")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
 		return err
 	}
 
-	configPath := filepath.Join(configDir, "config.json")
 	file, err := os.Create(configPath)
 	if err != nil {
 		return err
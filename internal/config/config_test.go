@@ -1,10 +1,16 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/a1yama/git-cz-go/internal/git"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -33,6 +39,19 @@ func TestDefaultConfig(t *testing.T) {
 	}
 }
 
+func TestClone(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Scopes = []Scope{{Name: "ui", Paths: []string{"ui/**"}}}
+
+	clone := cfg.Clone()
+	if err := clone.applyJSON([]byte(`{"types": [{"type": "wip"}], "scopes": [{"name": "api", "paths": ["api/**"]}]}`)); err != nil {
+		t.Fatalf("applyJSON() failed: %v", err)
+	}
+	if cfg.Types[0].Type != "feat" || cfg.Scopes[0].Paths[0] != "ui/**" {
+		t.Errorf("Expected the config to be unchanged by its clone, got %+v and %+v", cfg.Types[0], cfg.Scopes)
+	}
+}
+
 func TestLoadFromFile(t *testing.T) {
 	// Create a temporary config file
 	tempDir, err := os.MkdirTemp("", "git-cz-go-test")
@@ -85,3 +104,460 @@ func TestLoadFromFile(t *testing.T) {
 		t.Errorf("Expected MaxSubjectLength to be 50, got %d", cfg.MaxSubjectLength)
 	}
 }
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*.md", "README.md", true},
+		{"*.md", "docs/guide/intro.md", true},
+		{"docs/**", "docs/guide/intro.md", true},
+		{"docs/**", "internal/docs/a.go", false},
+		{"**/testdata/**", "internal/git/testdata/repo/a.txt", true},
+		{"internal/*/ui.go", "internal/ui/ui.go", true},
+		{"internal/*/ui.go", "internal/ui/components/ui.go", false},
+		{".github/workflows/**", ".github/workflows/ci.yml", true},
+		{"go.mod", "tools/go.mod", true},
+	}
+	for _, tt := range tests {
+		if got := MatchPath(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchPath(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestType(t *testing.T) {
+	cfg := DefaultConfig()
+	tests := []struct {
+		files []string
+		want  TypeSuggestion
+	}{
+		{[]string{"internal/ui/ui_test.go"}, TypeSuggestion{Type: "test", Reason: "the staged file matches *_test.go"}},
+		{[]string{"README.md", "docs/usage.txt"}, TypeSuggestion{Type: "docs", Reason: "all staged files match *.md, docs/**"}},
+		{[]string{".github/workflows/release.yml"}, TypeSuggestion{Type: "ci", Reason: "the staged file matches .github/workflows/**"}},
+		{[]string{"go.mod", "go.sum"}, TypeSuggestion{Type: "build", Reason: "all staged files match go.mod, go.sum"}},
+		// Mixed changes have no suggestion
+		{[]string{"go.mod", "internal/ui/ui.go"}, TypeSuggestion{}},
+		{nil, TypeSuggestion{}},
+	}
+	for _, tt := range tests {
+		if got, _ := cfg.SuggestType(tt.files); got != tt.want {
+			t.Errorf("SuggestType(%v) = %+v, want %+v", tt.files, got, tt.want)
+		}
+	}
+
+	// Rules are configurable and only suggest configured types
+	cfg.TypeRules = []TypeRule{{Type: "deps", Paths: []string{"go.*"}}, {Type: "chore", Paths: []string{"go.*"}}}
+	if got, ok := cfg.SuggestType([]string{"go.mod"}); !ok || got.Type != "chore" {
+		t.Errorf("SuggestType() with custom rules = %+v, %v, want chore", got, ok)
+	}
+	if err := validateTypeRules([]TypeRule{{Type: "docs", Paths: []string{"docs/[a"}}}); err == nil {
+		t.Error("validateTypeRules() accepted a bad pattern")
+	}
+}
+
+func TestSuggestScope(t *testing.T) {
+	scopes := []Scope{
+		{Name: "ui", Paths: []string{"internal/ui/**"}},
+		{Name: "components", Paths: []string{"internal/ui/components/**"}},
+		{Name: "git", Paths: []string{"internal/git/**"}},
+		// Without paths, files in a directory named like the scope match
+		{Name: "config"},
+	}
+	tests := []struct {
+		files []string
+		want  ScopeSuggestion
+	}{
+		{
+			[]string{"internal/ui/ui.go", "internal/ui/loader.go"},
+			ScopeSuggestion{Scopes: []string{"ui"}, Reason: "all staged files are in ui"},
+		},
+		// The longest matching pattern wins
+		{
+			[]string{"internal/ui/components/scope.go"},
+			ScopeSuggestion{Scopes: []string{"components"}, Reason: "the staged file is in components"},
+		},
+		{
+			[]string{"internal/config/rules.go", "README.md"},
+			ScopeSuggestion{Scopes: []string{"config"}, Reason: "1 of 2 staged files are in config"},
+		},
+		{
+			[]string{"internal/git/git.go", "internal/ui/ui.go", "internal/ui/plan.go"},
+			ScopeSuggestion{Scopes: []string{"ui", "git"}, Reason: "2 of 3 staged files are in ui", Parent: "internal"},
+		},
+	}
+	for _, tt := range tests {
+		got, ok := SuggestScope(scopes, tt.files)
+		if !ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SuggestScope(%v) = %+v, %v, want %+v", tt.files, got, ok, tt.want)
+		}
+	}
+	if got, ok := SuggestScope(scopes, []string{"go.mod"}); ok {
+		t.Errorf("SuggestScope(go.mod) = %+v, want no suggestion", got)
+	}
+}
+
+func TestApplyGitConfig(t *testing.T) {
+	cfg := DefaultConfig()
+
+	// Keys are reported lowercased by git, except for subsection names
+	entries := []git.ConfigEntry{
+		{Key: "cz.useemoji", Value: "false"},
+		{Key: "cz.maxsubjectlength", Value: "72"},
+		{Key: "cz.gittimeout", Value: "2s"},
+		{Key: "cz.types", Value: "feat, fix"},
+		{Key: "cz.type.feat.emoji", Value: "🎉"},
+		{Key: "cz.type.Security.description", Value: "A security fix"},
+		{Key: "cz.imperativecheck", Value: "Error"},
+		{Key: "cz.imperativeverbs", Value: "dockerize, undo undid undone"},
+	}
+
+	if err := applyGitConfig(cfg, entries); err != nil {
+		t.Fatalf("applyGitConfig() failed: %v", err)
+	}
+
+	if cfg.UseEmoji {
+		t.Error("Expected UseEmoji to be false")
+	}
+
+	if cfg.MaxSubjectLength != 72 {
+		t.Errorf("Expected MaxSubjectLength to be 72, got %d", cfg.MaxSubjectLength)
+	}
+
+	if time.Duration(cfg.GitTimeout) != 2*time.Second {
+		t.Errorf("Expected GitTimeout to be 2s, got %v", time.Duration(cfg.GitTimeout))
+	}
+
+	if len(cfg.Types) != 3 {
+		t.Fatalf("Expected 3 commit types, got %d", len(cfg.Types))
+	}
+
+	if cfg.Types[0].Type != "feat" || cfg.Types[0].Emoji != "🎉" {
+		t.Errorf("Expected feat with overridden emoji, got %+v", cfg.Types[0])
+	}
+
+	if cfg.Types[1].Type != "fix" || cfg.Types[1].Description != "A bug fix" {
+		t.Errorf("Expected fix to keep its default description, got %+v", cfg.Types[1])
+	}
+
+	if cfg.Types[2].Type != "Security" || cfg.Types[2].Description != "A security fix" {
+		t.Errorf("Expected new type Security, got %+v", cfg.Types[2])
+	}
+
+	if cfg.ImperativeCheck != ImperativeError {
+		t.Errorf("Expected ImperativeCheck to be %q, got %q", ImperativeError, cfg.ImperativeCheck)
+	}
+
+	if len(cfg.ImperativeVerbs) != 2 || cfg.ImperativeVerbs[1] != "undo undid undone" {
+		t.Errorf("Expected two imperative verbs, got %q", cfg.ImperativeVerbs)
+	}
+
+	// Invalid values are reported with the offending key
+	err := applyGitConfig(cfg, []git.ConfigEntry{{Key: "cz.maxsubjectlength", Value: "long"}})
+	if err == nil {
+		t.Error("Expected an error for an invalid maxSubjectLength")
+	}
+	err = applyGitConfig(cfg, []git.ConfigEntry{{Key: "cz.imperativecheck", Value: "strict"}})
+	if err == nil {
+		t.Error("Expected an error for an unknown imperativeCheck level")
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	cfg := DefaultConfig()
+
+	configContent := `{
+        "useEmoji": true,
+        "profiles": {
+            "oss": {"match": {"paths": ["~/oss/*"]}},
+            "work": {
+                "useEmoji": false,
+                "maxSubjectLength": 60,
+                "match": {"remotes": ["*github.com?acme/*"]}
+            }
+        }
+    }`
+	if err := json.Unmarshal([]byte(configContent), cfg); err != nil {
+		t.Fatalf("Failed to decode config: %v", err)
+	}
+
+	// Automatic matching by remote URL, in both URL styles
+	for _, remote := range []string{"git@github.com:acme/api.git", "https://github.com/acme/api.git"} {
+		if name := matchProfile(cfg.Profiles, []string{remote}, "/src/api"); name != "work" {
+			t.Errorf("matchProfile(%q) = %q, want %q", remote, name, "work")
+		}
+	}
+
+	if name := matchProfile(cfg.Profiles, []string{"https://example.com/x.git"}, "/src/x"); name != "" {
+		t.Errorf("matchProfile() = %q, want no profile", name)
+	}
+
+	// Applying a profile overrides only the fields it sets
+	if err := cfg.applyProfile("work"); err != nil {
+		t.Fatalf("applyProfile() failed: %v", err)
+	}
+
+	if cfg.UseEmoji {
+		t.Error("Expected UseEmoji to be false")
+	}
+
+	if cfg.MaxSubjectLength != 60 {
+		t.Errorf("Expected MaxSubjectLength to be 60, got %d", cfg.MaxSubjectLength)
+	}
+
+	if len(cfg.Types) != len(DefaultConfig().Types) {
+		t.Error("Expected the profile to keep the default types")
+	}
+
+	if cfg.Profile != "work" || len(cfg.Profiles) != 2 {
+		t.Errorf("Expected active profile work with 2 profiles, got %q with %d", cfg.Profile, len(cfg.Profiles))
+	}
+
+	if err := cfg.applyProfile("missing"); err == nil {
+		t.Error("Expected an error for an unknown profile")
+	}
+}
+
+func TestLoadFilesKeepsUserProfiles(t *testing.T) {
+	dir := t.TempDir()
+	project := filepath.Join(dir, "project.json")
+	user := filepath.Join(dir, "user.json")
+	if err := os.WriteFile(project, []byte(`{"maxSubjectLength": 60, "profiles": {"oss": {"maxSubjectLength": 72}}}`), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if err := os.WriteFile(user, []byte(`{"maxSubjectLength": 40, "profiles": {"oss": {"maxSubjectLength": 50}, "work": {"useEmoji": false}}}`), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	// The settings come from the first file, the profiles from both
+	cfg := DefaultConfig()
+	loadFiles(cfg, []string{filepath.Join(dir, "missing.json"), project, user})
+	if cfg.MaxSubjectLength != 60 {
+		t.Errorf("Expected MaxSubjectLength to be 60, got %d", cfg.MaxSubjectLength)
+	}
+	if len(cfg.Profiles) != 2 {
+		t.Fatalf("Expected the oss and work profiles, got %v", cfg.Profiles)
+	}
+	if err := cfg.applyProfile("oss"); err != nil || cfg.MaxSubjectLength != 72 {
+		t.Errorf("Expected the oss profile of the project to win, got %v", err)
+	}
+	if err := cfg.applyProfile("work"); err != nil || cfg.UseEmoji {
+		t.Errorf("Expected the work profile of the user config, got %v", err)
+	}
+}
+
+func TestApplyNested(t *testing.T) {
+	root, err := os.MkdirTemp("", "git-cz-go-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		"frontend/.git-cz.json": `{"scopes": ["web", "mobile"], "maxSubjectLength": 72}`,
+		"services/.git-cz.json": `{"scopes": [{"name": "api"}], "types": [{"type": "feat", "description": "A new feature"}]}`,
+		"tools/.git-cz.json":    `{"maxSubjectLength": 50}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+	}
+
+	// Files without a nested config leave the config untouched
+	cfg := DefaultConfig()
+	choices, err := cfg.ApplyNested(root, []string{"README.md", "docs/index.md"})
+	if err != nil || choices != nil {
+		t.Fatalf("ApplyNested() = %v, %v, want no choices", choices, err)
+	}
+	if cfg.MaxSubjectLength != 100 || len(cfg.Scopes) != 0 {
+		t.Error("Expected the config to be unchanged")
+	}
+
+	// Compatible configs are merged
+	cfg = DefaultConfig()
+	choices, err = cfg.ApplyNested(root, []string{"frontend/src/app.ts", "services/api/main.go"})
+	if err != nil || choices != nil {
+		t.Fatalf("ApplyNested() = %v, %v, want no choices", choices, err)
+	}
+	if len(cfg.Scopes) != 3 || cfg.Scopes[0].Name != "web" || cfg.Scopes[2].Name != "api" {
+		t.Errorf("Expected merged scopes web, mobile, api, got %+v", cfg.Scopes)
+	}
+	if len(cfg.Types) != 1 || cfg.MaxSubjectLength != 72 {
+		t.Errorf("Expected 1 type and MaxSubjectLength 72, got %d and %d", len(cfg.Types), cfg.MaxSubjectLength)
+	}
+
+	// Conflicting configs are returned as choices and nothing is applied
+	cfg = DefaultConfig()
+	choices, err = cfg.ApplyNested(root, []string{"frontend/app.ts", "tools/lint/main.go"})
+	if err != nil {
+		t.Fatalf("ApplyNested() failed: %v", err)
+	}
+	if len(choices) != 2 {
+		t.Fatalf("Expected 2 choices, got %v", choices)
+	}
+	if cfg.MaxSubjectLength != 100 {
+		t.Error("Expected the config to be unchanged when configs conflict")
+	}
+
+	if err := cfg.ApplyFile(choices[1]); err != nil {
+		t.Fatalf("ApplyFile() failed: %v", err)
+	}
+	if cfg.MaxSubjectLength != 50 {
+		t.Errorf("Expected MaxSubjectLength to be 50, got %d", cfg.MaxSubjectLength)
+	}
+}
+
+func TestApplyEnviron(t *testing.T) {
+	cfg := DefaultConfig()
+
+	environ := []string{
+		"HOME=/root",
+		"GIT_CZ_USE_EMOJI=false",
+		"GIT_CZ_MAX_SUBJECT_LENGTH=50",
+		"GIT_CZ_TYPES=fix,feat",
+		"GIT_CZ_CONFIG=/etc/git-cz.json",
+		"GIT_CZ_PROFILE=work",
+	}
+	if err := cfg.applyEnviron(environ); err != nil {
+		t.Fatalf("applyEnviron() failed: %v", err)
+	}
+
+	if cfg.UseEmoji {
+		t.Error("Expected UseEmoji to be false")
+	}
+
+	if cfg.MaxSubjectLength != 50 {
+		t.Errorf("Expected MaxSubjectLength to be 50, got %d", cfg.MaxSubjectLength)
+	}
+
+	if len(cfg.Types) != 2 || cfg.Types[0].Type != "fix" || cfg.Types[1].Type != "feat" {
+		t.Errorf("Expected types fix, feat, got %+v", cfg.Types)
+	}
+
+	// Unknown variables are ignored, invalid values are not
+	if err := cfg.applyEnviron([]string{"GIT_CZ_DEBUG=1", "GIT_CZ_TYPE_FEAT_EMOJI=x"}); err != nil {
+		t.Errorf("applyEnviron() with unknown variables failed: %v", err)
+	}
+	if err := cfg.applyEnviron([]string{"GIT_CZ_MAX_SUBJECT_LENGTH=long"}); err == nil {
+		t.Error("Expected an error for an invalid value")
+	}
+}
+
+func TestLoadConfigRef(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "git-cz-go-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	defer os.Chdir(originalDir)
+
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change to repository directory: %v", err)
+	}
+
+	// Commit a shared config, then change the working tree copy
+	runGit := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+	runGit("init")
+	runGit("config", "user.name", "Test User")
+	runGit("config", "user.email", "test@example.com")
+	if err := os.WriteFile(".git-cz.json", []byte(`{"maxSubjectLength": 60}`), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	runGit("add", ".git-cz.json")
+	runGit("commit", "-m", "chore: add shared config")
+	if err := os.WriteFile(".git-cz.json", []byte(`{"maxSubjectLength": 40}`), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	// The committed version replaces the local settings and is cached
+	cfg := DefaultConfig()
+	cfg.UseEmoji = false
+	repo := git.NewExecRepository("")
+	if err := cfg.loadConfigRef(context.Background(), "HEAD:.git-cz.json", repo); err != nil {
+		t.Fatalf("loadConfigRef() failed: %v", err)
+	}
+	if cfg.MaxSubjectLength != 60 {
+		t.Errorf("Expected MaxSubjectLength to be 60, got %d", cfg.MaxSubjectLength)
+	}
+	if !cfg.UseEmoji {
+		t.Error("Expected UseEmoji to be reset to the default, as the shared config does not set it")
+	}
+
+	cached, err := filepath.Glob(filepath.Join(".git", configRefCacheDir, "*.json"))
+	if err != nil || len(cached) != 1 {
+		t.Errorf("Expected 1 cached config file, got %v", cached)
+	}
+
+	// An unknown revision falls back to the working tree copy
+	cfg = DefaultConfig()
+	if err := cfg.loadConfigRef(context.Background(), "origin/main", repo); err != nil {
+		t.Fatalf("loadConfigRef() failed: %v", err)
+	}
+	if cfg.MaxSubjectLength != 40 {
+		t.Errorf("Expected MaxSubjectLength to be 40, got %d", cfg.MaxSubjectLength)
+	}
+}
+
+func TestLoadConfigRefPrecedence(t *testing.T) {
+	t.Setenv(ConfigEnv, "")
+	t.Setenv(ConfigRefEnv, "")
+	repo := git.NewMemoryRepository("/repo")
+	repo.Revisions["origin/main"] = map[string][]byte{
+		".git-cz.json": []byte(`{"maxSubjectLength": 60, "useEmoji": false}`),
+	}
+	repo.Config = []git.ConfigEntry{
+		{Key: "cz.configref", Value: "origin/main"},
+		{Key: "cz.maxsubjectlength", Value: "50"},
+	}
+
+	// The shared config is read from the ref named in git config, and the
+	// other keys of git config override it
+	cfg, err := Load(context.Background(), LoadOptions{Dir: t.TempDir(), Repository: repo})
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.MaxSubjectLength != 50 || cfg.UseEmoji {
+		t.Errorf("Expected MaxSubjectLength 50 from git config and no emoji from the ref, got %d and %v", cfg.MaxSubjectLength, cfg.UseEmoji)
+	}
+	if cfg.ConfigRef != "origin/main" {
+		t.Errorf("ConfigRef = %q, want %q", cfg.ConfigRef, "origin/main")
+	}
+}
+
+func TestImperativeCheckIsNormalized(t *testing.T) {
+	t.Setenv(ConfigEnv, "")
+	t.Setenv(ConfigRefEnv, "")
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".git-cz.json"), []byte(`{"imperativeCheck": " Error"}`), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	cfg, err := Load(context.Background(), LoadOptions{Dir: dir, Repository: git.NewMemoryRepository(dir)})
+	if err != nil || cfg.ImperativeCheck != ImperativeError {
+		t.Errorf("Load() = %q, %v, want %q like in git config", cfg.ImperativeCheck, err, ImperativeError)
+	}
+
+	if err := cfg.applyJSON([]byte(`{"imperativeCheck": "OFF"}`)); err != nil || cfg.ImperativeCheck != ImperativeOff {
+		t.Errorf("applyJSON() = %q, %v, want %q", cfg.ImperativeCheck, err, ImperativeOff)
+	}
+	if err := cfg.applyJSON([]byte(`{"imperativeCheck": "strict"}`)); err == nil {
+		t.Error("Expected an error for an unknown imperativeCheck level")
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// envPrefix is the prefix of the environment variables that override settings,
+	// e.g. GIT_CZ_USE_EMOJI or GIT_CZ_MAX_SUBJECT_LENGTH
+	envPrefix = "GIT_CZ_"
+
+	// ConfigEnv is the environment variable holding an explicit config file path
+	ConfigEnv = "GIT_CZ_CONFIG"
+)
+
+// reservedEnv lists the GIT_CZ_* variables that are not settings
+var reservedEnv = map[string]bool{
+	ConfigEnv:    true,
+	ConfigRefEnv: true,
+	ProfileEnv:   true,
+}
+
+// applyEnv applies the GIT_CZ_* environment variables on top of the config.
+// The variable name maps to the setting name without underscores, so
+// GIT_CZ_MAX_SUBJECT_LENGTH sets maxSubjectLength and GIT_CZ_TYPES takes
+// a comma separated list of type names like cz.types in git config.
+func (c *Config) applyEnv() error {
+	return c.applyEnviron(os.Environ())
+}
+
+// applyEnviron applies the GIT_CZ_* variables from a list of KEY=value pairs.
+// Variables that are not settings are ignored, as other tools may use the
+// same prefix; this includes the fields of types, which are only set with
+// type.<name>.<field> in git config.
+func (c *Config) applyEnviron(environ []string) error {
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, envPrefix) || reservedEnv[name] {
+			continue
+		}
+
+		key := strings.ReplaceAll(strings.TrimPrefix(name, envPrefix), "_", "")
+		err := c.setValue(key, value)
+		if errors.Is(err, errUnknownSetting) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("invalid environment variable %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/git"
+)
+
+// gitConfigSection is the git config section that holds git-cz-go settings
+const gitConfigSection = "cz"
+
+// errUnknownSetting is returned by setValue for a key that is not a setting
+var errUnknownSetting = errors.New("unknown setting")
+
+// readGitConfig returns the cz.* keys from git config. git resolves system,
+// global, local and included files (including includeIf) itself, so only the
+// effective values are seen here.
+func readGitConfig(ctx context.Context, repo git.Repository) []git.ConfigEntry {
+	entries, err := repo.GetConfigEntries(ctx, `^`+gitConfigSection+`\.`)
+	if err != nil {
+		// git config is an optional layer; ignore it if git cannot be run
+		return nil
+	}
+	return entries
+}
+
+// gitConfigRef returns the configRef set in the git config entries, if any.
+// It is needed before the entries are applied, see Load.
+func gitConfigRef(entries []git.ConfigEntry) string {
+	ref := ""
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Key, gitConfigSection+".configRef") {
+			ref = strings.TrimSpace(entry.Value)
+		}
+	}
+	return ref
+}
+
+// applyGitConfig applies git config entries in order, so later entries win
+func applyGitConfig(c *Config, entries []git.ConfigEntry) error {
+	for _, entry := range entries {
+		key := strings.TrimPrefix(entry.Key, gitConfigSection+".")
+		if err := c.setValue(key, entry.Value); err != nil {
+			return fmt.Errorf("invalid git config %s: %w", entry.Key, err)
+		}
+	}
+	return nil
+}
+
+// setValue sets a single setting from its string form.
+// Keys are matched case-insensitively because git lowercases them:
+//
+//	useEmoji, maxSubjectLength, types (comma separated list of type names),
+//	type.<name>.description, type.<name>.emoji, scopes and scopeDirs (comma separated),
+//	configRef, profile, gitTimeout, commitTimeout (durations like "30s"),
+//	commitArgs (space separated), backend ("exec" or "go-git"),
+//	imperativeCheck ("warning", "error" or "off"), imperativeVerbs (comma separated)
+func (c *Config) setValue(key, value string) error {
+	lower := strings.ToLower(key)
+
+	if strings.HasPrefix(lower, "type.") {
+		// The type name is a git subsection, so its case is preserved
+		rest := key[len("type."):]
+		dot := strings.LastIndex(rest, ".")
+		if dot <= 0 {
+			return fmt.Errorf("expected type.<name>.<field>")
+		}
+		return c.setTypeValue(rest[:dot], strings.ToLower(rest[dot+1:]), value)
+	}
+
+	switch lower {
+	case "useemoji":
+		b, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		c.UseEmoji = b
+	case "maxsubjectlength":
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n <= 0 {
+			return fmt.Errorf("expected a positive number, got %q", value)
+		}
+		c.MaxSubjectLength = n
+	case "types":
+		c.Types = selectTypes(c.Types, splitList(value))
+	case "scopes":
+		c.Scopes = nil
+		for _, name := range splitList(value) {
+			c.Scopes = append(c.Scopes, Scope{Name: name})
+		}
+	case "scopedirs":
+		c.ScopeDirs = splitList(value)
+	case "gittimeout":
+		return c.GitTimeout.set(value)
+	case "committimeout":
+		return c.CommitTimeout.set(value)
+	case "commitargs":
+		c.CommitArgs = strings.Fields(value)
+	case "backend":
+		backend := strings.ToLower(strings.TrimSpace(value))
+		if err := git.ValidateBackend(backend); err != nil {
+			return err
+		}
+		c.Backend = backend
+	case "imperativecheck":
+		level, err := parseImperativeCheck(value)
+		if err != nil {
+			return err
+		}
+		c.ImperativeCheck = level
+	case "imperativeverbs":
+		c.ImperativeVerbs = splitList(value)
+	case "configref":
+		c.ConfigRef = strings.TrimSpace(value)
+	case "profile":
+		c.Profile = strings.TrimSpace(value)
+	default:
+		return fmt.Errorf("%w %q", errUnknownSetting, key)
+	}
+	return nil
+}
+
+// setTypeValue sets a field of the named commit type, adding the type if needed
+func (c *Config) setTypeValue(name, field, value string) error {
+	index := -1
+	for i, t := range c.Types {
+		if t.Type == name {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		c.Types = append(c.Types, CommitType{Type: name})
+		index = len(c.Types) - 1
+	}
+
+	switch field {
+	case "description":
+		c.Types[index].Description = value
+	case "emoji":
+		c.Types[index].Emoji = value
+	default:
+		return fmt.Errorf("unknown commit type field %q", field)
+	}
+	return nil
+}
+
+// selectTypes returns the named types in the given order.
+// Types that are not yet known are added without a description.
+func selectTypes(types []CommitType, names []string) []CommitType {
+	selected := make([]CommitType, 0, len(names))
+	for _, name := range names {
+		found := CommitType{Type: name}
+		for _, t := range types {
+			if t.Type == name {
+				found = t
+				break
+			}
+		}
+		selected = append(selected, found)
+	}
+	return selected
+}
+
+// splitList splits a comma separated list, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseBool parses a boolean the way git does
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "yes", "on", "1", "":
+		return true, nil
+	case "false", "no", "off", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("expected a boolean, got %q", value)
+}
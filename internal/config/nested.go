@@ -0,0 +1,200 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// NestedConfigFile is the name of the per-directory config file used in monorepos
+const NestedConfigFile = ".git-cz.json"
+
+// ApplyNested applies the nested config files that apply to the given files on top of the config.
+//
+// root is the repository root and files are paths relative to it, as reported by git.
+// Each file uses the nearest .git-cz.json in its directory or a parent directory below root.
+// When the files use several nested configs they are merged: types and scopes are combined,
+// and every other setting must have the same value in all of them.
+//
+// If the configs conflict, nothing is applied and the conflicting config paths are
+// returned so that the user can pick one with ApplyFile.
+func (c *Config) ApplyNested(root string, files []string) ([]string, error) {
+	paths := nestedConfigPaths(root, files)
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	merged, ok, err := mergeConfigFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return paths, nil
+	}
+	if err := c.applyJSON(merged); err != nil {
+		return nil, err
+	}
+	return nil, c.applyEnv()
+}
+
+// ApplyFile applies a single JSON config file on top of the config.
+// GIT_CZ_* environment variables keep precedence over the file.
+func (c *Config) ApplyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := c.applyJSON(data); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return c.applyEnv()
+}
+
+// applyJSON decodes JSON settings on top of the config, keeping the profiles
+func (c *Config) applyJSON(data []byte) error {
+	profiles := c.Profiles
+	err := json.Unmarshal(data, c)
+	c.Profiles = profiles
+	if err != nil {
+		return err
+	}
+	level, err := parseImperativeCheck(c.ImperativeCheck)
+	if err != nil {
+		return fmt.Errorf("invalid imperativeCheck: %w", err)
+	}
+	c.ImperativeCheck = level
+	return nil
+}
+
+// nestedConfigPaths returns the sorted, distinct nested config files used by the given files
+func nestedConfigPaths(root string, files []string) []string {
+	found := make(map[string]bool)
+	// Cache lookups per directory, staged files usually share directories
+	nearest := make(map[string]string)
+
+	for _, file := range files {
+		dir := path.Dir(filepath.ToSlash(file))
+		for dir != "." && dir != "/" {
+			if p, ok := nearest[dir]; ok {
+				if p != "" {
+					found[p] = true
+				}
+				break
+			}
+
+			candidate := filepath.Join(root, filepath.FromSlash(dir), NestedConfigFile)
+			if _, err := os.Stat(candidate); err == nil {
+				nearest[dir] = candidate
+				found[candidate] = true
+				break
+			}
+			nearest[dir] = ""
+			dir = path.Dir(dir)
+		}
+	}
+
+	paths := make([]string, 0, len(found))
+	for p := range found {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// mergeConfigFiles merges JSON config files into a single JSON document.
+// It reports false if the files set different values for the same setting.
+func mergeConfigFiles(paths []string) ([]byte, bool, error) {
+	merged := make(map[string]json.RawMessage)
+	var types []CommitType
+	var scopes []Scope
+
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, false, err
+		}
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, false, fmt.Errorf("%s: %w", p, err)
+		}
+
+		for key, value := range fields {
+			switch key {
+			case "types":
+				var t []CommitType
+				if err := json.Unmarshal(value, &t); err != nil {
+					return nil, false, fmt.Errorf("%s: %w", p, err)
+				}
+				types = mergeTypes(types, t)
+			case "scopes":
+				var s []Scope
+				if err := json.Unmarshal(value, &s); err != nil {
+					return nil, false, fmt.Errorf("%s: %w", p, err)
+				}
+				scopes = mergeScopes(scopes, s)
+			case "profiles":
+				// Profiles are only read from the user config
+			default:
+				var compact bytes.Buffer
+				if err := json.Compact(&compact, value); err != nil {
+					return nil, false, fmt.Errorf("%s: %w", p, err)
+				}
+				if existing, ok := merged[key]; ok && !bytes.Equal(existing, compact.Bytes()) {
+					return nil, false, nil
+				}
+				merged[key] = compact.Bytes()
+			}
+		}
+	}
+
+	if types != nil {
+		data, _ := json.Marshal(types)
+		merged["types"] = data
+	}
+	if scopes != nil {
+		data, _ := json.Marshal(scopes)
+		merged["scopes"] = data
+	}
+
+	data, err := json.Marshal(merged)
+	return data, true, err
+}
+
+// mergeTypes appends the types that are not yet in the list
+func mergeTypes(types, more []CommitType) []CommitType {
+	for _, t := range more {
+		exists := false
+		for _, existing := range types {
+			if existing.Type == t.Type {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// mergeScopes appends the scopes that are not yet in the list
+func mergeScopes(scopes, more []Scope) []Scope {
+	for _, s := range more {
+		exists := false
+		for _, existing := range scopes {
+			if existing.Name == s.Name {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
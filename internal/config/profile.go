@@ -0,0 +1,144 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/git"
+	"github.com/mitchellh/go-homedir"
+)
+
+// ProfileEnv is the environment variable that selects a profile
+const ProfileEnv = "GIT_CZ_PROFILE"
+
+// ProfileMatch describes the repositories a profile is selected for automatically.
+// Patterns are globs where * matches any sequence of characters (including /)
+// and ? matches a single character.
+type ProfileMatch struct {
+	Remotes []string `json:"remotes,omitempty"`
+	Paths   []string `json:"paths,omitempty"`
+}
+
+// Profile is a named set of settings that overrides the rest of the config.
+// Any config field can be used in a profile, next to an optional "match".
+type Profile struct {
+	Match    ProfileMatch
+	settings json.RawMessage
+}
+
+// UnmarshalJSON keeps the raw settings so they can be applied on top of a config
+func (p *Profile) UnmarshalJSON(data []byte) error {
+	var match struct {
+		Match ProfileMatch `json:"match"`
+	}
+	if err := json.Unmarshal(data, &match); err != nil {
+		return err
+	}
+	p.Match = match.Match
+	p.settings = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// MarshalJSON writes the profile back as it was read
+func (p Profile) MarshalJSON() ([]byte, error) {
+	if len(p.settings) == 0 {
+		return json.Marshal(struct {
+			Match ProfileMatch `json:"match"`
+		}{p.Match})
+	}
+	return p.settings, nil
+}
+
+// applyProfile applies the settings of the named profile on top of the config
+func (c *Config) applyProfile(name string) error {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+
+	// Profiles cannot define other profiles
+	if len(profile.settings) > 0 {
+		if err := c.applyJSON(profile.settings); err != nil {
+			return fmt.Errorf("invalid profile %q: %w", name, err)
+		}
+	}
+	c.Profile = name
+	return nil
+}
+
+// selectProfile returns the name of the profile to use, or "" for none.
+// An explicit name wins over GIT_CZ_PROFILE, which wins over cz.profile in
+// git config; otherwise the first profile (by name) matching the repository is used.
+func (c *Config) selectProfile(ctx context.Context, explicit string, repo git.Repository) string {
+	if explicit != "" {
+		return explicit
+	}
+	if name := os.Getenv(ProfileEnv); name != "" {
+		return name
+	}
+	if c.Profile != "" {
+		return c.Profile
+	}
+	if len(c.Profiles) == 0 {
+		return ""
+	}
+
+	remotes, _ := git.GetRemoteURLs(ctx, repo)
+	root, _ := repo.GetGitRootDir(ctx)
+	return matchProfile(c.Profiles, remotes, root)
+}
+
+// matchProfile returns the first profile (by name) whose match rules apply
+func matchProfile(profiles map[string]Profile, remotes []string, root string) string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		match := profiles[name].Match
+		for _, pattern := range match.Remotes {
+			for _, remote := range remotes {
+				if matchGlob(pattern, remote) {
+					return name
+				}
+			}
+		}
+		if root == "" {
+			continue
+		}
+		for _, pattern := range match.Paths {
+			if expanded, err := homedir.Expand(pattern); err == nil && matchGlob(expanded, root) {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// matchGlob reports whether s matches the pattern, where * matches any
+// sequence of characters and ? matches exactly one
+func matchGlob(pattern, s string) bool {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+
+	matched, err := regexp.MatchString(expr.String(), s)
+	return err == nil && matched
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/git"
+)
+
+// ConfigRefEnv is the environment variable that names a config file in a git revision
+const ConfigRefEnv = "GIT_CZ_CONFIG_REF"
+
+// configRefCacheDir is the directory below the git directory where config files
+// read from revisions are cached
+const configRefCacheDir = "git-cz/config-ref"
+
+// configRefCacheSize is the number of config files that are kept in the cache
+const configRefCacheSize = 20
+
+// parseConfigRef splits "<rev>:<path>" into its parts.
+// The path defaults to .git-cz.json at the repository root.
+func parseConfigRef(spec string) (string, string) {
+	rev, path, ok := strings.Cut(spec, ":")
+	if !ok || path == "" {
+		path = NestedConfigFile
+	}
+	return rev, strings.TrimPrefix(path, "/")
+}
+
+// loadConfigRef replaces the settings of the JSON config file with the config
+// file stored at "<rev>:<path>", as read with `git show`, so that settings
+// removed from the shared file are not kept from a local one; only the profiles
+// are kept. The file is cached per commit id in the git directory. If the
+// revision or the file does not exist, the working tree copy of the file is
+// used instead, if any.
+func (c *Config) loadConfigRef(ctx context.Context, spec string, repo git.Repository) error {
+	rev, path := parseConfigRef(spec)
+
+	if data, err := readConfigRef(ctx, repo, rev, path); err == nil {
+		return c.replaceJSON(data)
+	}
+
+	root, err := repo.GetGitRootDir(ctx)
+	if err != nil {
+		return nil
+	}
+	file := filepath.Join(root, filepath.FromSlash(path))
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	if err := c.replaceJSON(data); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
+
+// replaceJSON replaces the config with the defaults and the JSON settings,
+// keeping the profiles
+func (c *Config) replaceJSON(data []byte) error {
+	profiles := c.Profiles
+	*c = *DefaultConfig()
+	c.Profiles = profiles
+	return c.applyJSON(data)
+}
+
+// readConfigRef reads a file from a revision, using the cache if possible
+func readConfigRef(ctx context.Context, repo git.Repository, rev, path string) ([]byte, error) {
+	commit, err := repo.ResolveCommit(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	cachePath := ""
+	if gitDir, err := repo.GetGitCommonDir(ctx); err == nil && gitDir != "" {
+		sum := sha1.Sum([]byte(path))
+		name := commit + "-" + hex.EncodeToString(sum[:8]) + ".json"
+		cachePath = filepath.Join(gitDir, filepath.FromSlash(configRefCacheDir), name)
+		if data, err := os.ReadFile(cachePath); err == nil {
+			return data, nil
+		}
+	}
+
+	data, err := repo.ShowFile(ctx, commit, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if cachePath != "" {
+		git.WriteCache(cachePath, data, configRefCacheSize)
+	}
+	return data, nil
+}
@@ -0,0 +1,244 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// TypeRule suggests a commit type when every staged file matches one of its
+// path patterns, see MatchPath
+type TypeRule struct {
+	Type  string   `json:"type"`
+	Paths []string `json:"paths"`
+}
+
+// TypeSuggestion is a commit type suggested for the staged files
+type TypeSuggestion struct {
+	Type string
+	// Reason explains the suggestion, like "all staged files match *.md"
+	Reason string
+}
+
+// defaultTypeRules returns the built-in type rules
+func defaultTypeRules() []TypeRule {
+	return []TypeRule{
+		{Type: "test", Paths: []string{"*_test.go", "**/testdata/**"}},
+		{Type: "docs", Paths: []string{"*.md", "docs/**", "LICENSE"}},
+		{Type: "ci", Paths: []string{".github/workflows/**", ".gitlab-ci.yml", ".circleci/**"}},
+		{Type: "build", Paths: []string{"go.mod", "go.sum", "Makefile", ".goreleaser.yml", ".goreleaser.yaml", "Dockerfile"}},
+	}
+}
+
+// SuggestType returns the type of the first type rule that matches all the
+// files, which are relative to the repository root. Rules for types that
+// are not configured are skipped.
+func (c *Config) SuggestType(files []string) (TypeSuggestion, bool) {
+	if len(files) == 0 {
+		return TypeSuggestion{}, false
+	}
+
+	for _, rule := range c.TypeRules {
+		if !c.HasType(rule.Type) {
+			continue
+		}
+		if matched, ok := matchAll(rule.Paths, files); ok {
+			reason := "all staged files match " + strings.Join(matched, ", ")
+			if len(files) == 1 {
+				reason = "the staged file matches " + matched[0]
+			}
+			return TypeSuggestion{Type: rule.Type, Reason: reason}, true
+		}
+	}
+	return TypeSuggestion{}, false
+}
+
+// HasType reports whether the type is configured
+func (c *Config) HasType(name string) bool {
+	for _, t := range c.Types {
+		if t.Type == name {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAll reports whether every file matches one of the patterns, and
+// returns the patterns that matched in their original order
+func matchAll(patterns, files []string) ([]string, bool) {
+	used := make([]bool, len(patterns))
+	for _, file := range files {
+		found := false
+		for i, pattern := range patterns {
+			if MatchPath(pattern, file) {
+				used[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+
+	var matched []string
+	for i, pattern := range patterns {
+		if used[i] {
+			matched = append(matched, pattern)
+		}
+	}
+	return matched, true
+}
+
+// MatchPath reports whether a slash-separated path relative to the repository
+// root matches the pattern. Like in .gitignore, a pattern without a slash
+// matches the file name in any directory, and "**" matches any number of
+// directories; other path elements are matched with path.Match.
+func MatchPath(pattern, name string) bool {
+	if !strings.Contains(pattern, "/") {
+		matched, err := path.Match(pattern, path.Base(name))
+		return err == nil && matched
+	}
+	return matchElems(strings.Split(strings.TrimPrefix(pattern, "/"), "/"), strings.Split(name, "/"))
+}
+
+// matchElems matches the path elements against the pattern elements
+func matchElems(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchElems(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if matched, err := path.Match(pattern[0], name[0]); err != nil || !matched {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// validatePathPatterns checks that the patterns can be used with MatchPath
+func validatePathPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if pattern == "" {
+			return errors.New("empty path pattern")
+		}
+		for _, elem := range strings.Split(pattern, "/") {
+			if _, err := path.Match(elem, ""); err != nil {
+				return fmt.Errorf("bad path pattern %q", pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// validateTypeRules checks the path patterns of the type rules
+func validateTypeRules(rules []TypeRule) error {
+	for _, rule := range rules {
+		if rule.Type == "" {
+			return errors.New("a rule has no type")
+		}
+		if err := validatePathPatterns(rule.Paths); err != nil {
+			return fmt.Errorf("%s: %w", rule.Type, err)
+		}
+	}
+	return nil
+}
+
+// ScopeSuggestion is the scope suggested for the staged files
+type ScopeSuggestion struct {
+	// Scopes are the scopes of the staged files, those with the most files first
+	Scopes []string
+	// Reason explains the suggestion of the first scope, like "all staged files are in ui"
+	Reason string
+	// Parent is the name of the closest directory containing all the files,
+	// when they span several scopes and have a common directory
+	Parent string
+}
+
+// SuggestScope returns the scopes of the files, which are relative to the
+// repository root. A file belongs to the scope with the longest path pattern
+// that matches it; scopes without paths match files in a directory of the
+// same name, the deepest one first. Files in no scope are left out.
+func SuggestScope(scopes []Scope, files []string) (ScopeSuggestion, bool) {
+	counts := make(map[string]int)
+	var order []string
+	var matched []string
+	for _, file := range files {
+		name := scopeOf(scopes, file)
+		if name == "" {
+			continue
+		}
+		if counts[name] == 0 {
+			order = append(order, name)
+		}
+		counts[name]++
+		matched = append(matched, file)
+	}
+	if len(order) == 0 {
+		return ScopeSuggestion{}, false
+	}
+
+	// The order of the files breaks ties
+	sort.SliceStable(order, func(i, j int) bool { return counts[order[i]] > counts[order[j]] })
+	suggestion := ScopeSuggestion{Scopes: order}
+	switch top := counts[order[0]]; {
+	case len(files) == 1:
+		suggestion.Reason = "the staged file is in " + order[0]
+	case top == len(files):
+		suggestion.Reason = "all staged files are in " + order[0]
+	default:
+		suggestion.Reason = fmt.Sprintf("%d of %d staged files are in %s", top, len(files), order[0])
+	}
+	if len(order) > 1 {
+		if dir := commonDir(matched); dir != "." {
+			suggestion.Parent = path.Base(dir)
+		}
+	}
+	return suggestion, true
+}
+
+// scopeOf returns the name of the scope of a file, or "" if it is in none
+func scopeOf(scopes []Scope, file string) string {
+	best, longest := "", -1
+	for _, scope := range scopes {
+		for _, pattern := range scope.Paths {
+			if len(pattern) > longest && MatchPath(pattern, file) {
+				best, longest = scope.Name, len(pattern)
+			}
+		}
+	}
+	if best != "" {
+		return best
+	}
+
+	dirs := strings.Split(path.Dir(file), "/")
+	for i := len(dirs) - 1; i >= 0; i-- {
+		for _, scope := range scopes {
+			if len(scope.Paths) == 0 && scope.Name == dirs[i] {
+				return scope.Name
+			}
+		}
+	}
+	return ""
+}
+
+// commonDir returns the closest directory containing all the files, or "."
+func commonDir(files []string) string {
+	dir := path.Dir(files[0])
+	for _, file := range files[1:] {
+		for dir != "." && !strings.HasPrefix(file, dir+"/") {
+			dir = path.Dir(dir)
+		}
+	}
+	return dir
+}
@@ -0,0 +1,226 @@
+// Package diff parses unified diffs as printed by git diff.
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LineKind is the kind of a line in a hunk
+type LineKind int
+
+const (
+	// Context is an unchanged line
+	Context LineKind = iota
+	// Added is a line only in the new version
+	Added
+	// Removed is a line only in the old version
+	Removed
+	// NoNewline is the "\ No newline at end of file" marker of the previous line
+	NoNewline
+)
+
+// Line is a line of a hunk, without its prefix character
+type Line struct {
+	Kind LineKind
+	Text string
+}
+
+// Hunk is a range of changed lines with their context
+type Hunk struct {
+	// Header is the full "@@ -1,2 +1,3 @@ section" line
+	Header   string
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []Line
+}
+
+// File is the diff of a single file
+type File struct {
+	// OldPath and NewPath are the paths without the a/ and b/ prefixes;
+	// OldPath is empty for new files and NewPath for deleted files
+	OldPath string
+	NewPath string
+	// Header holds the lines between "diff --git" and the first hunk,
+	// like "new file mode 100644" or "index 1234567..89abcde"
+	Header []string
+	Binary bool
+	Hunks  []Hunk
+}
+
+// Path returns the path of the file, the old one if it was deleted
+func (f File) Path() string {
+	if f.NewPath != "" {
+		return f.NewPath
+	}
+	return f.OldPath
+}
+
+// Parse parses the output of git diff. Text before the first "diff --git"
+// line is ignored.
+func Parse(text string) ([]File, error) {
+	var files []File
+	var file *File
+	var hunk *Hunk
+
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, parseGitHeader(line))
+			file = &files[len(files)-1]
+			hunk = nil
+
+		case file == nil:
+			continue
+
+		case strings.HasPrefix(line, "@@ "):
+			h, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			file.Hunks = append(file.Hunks, h)
+			hunk = &file.Hunks[len(file.Hunks)-1]
+
+		case hunk != nil && line != "" && strings.ContainsRune(" +-\\", rune(line[0])):
+			hunk.Lines = append(hunk.Lines, Line{Kind: lineKind(line[0]), Text: line[1:]})
+
+		case hunk != nil && line == "":
+			// Some tools strip the trailing space of empty context lines
+			hunk.Lines = append(hunk.Lines, Line{Kind: Context})
+
+		default:
+			parseHeaderLine(file, line)
+		}
+	}
+	return files, nil
+}
+
+// lineKind returns the kind of a hunk line from its prefix character
+func lineKind(prefix byte) LineKind {
+	switch prefix {
+	case '+':
+		return Added
+	case '-':
+		return Removed
+	case '\\':
+		return NoNewline
+	}
+	return Context
+}
+
+// parseGitHeader reads the paths from "diff --git a/old b/new". The paths are
+// ambiguous if they contain spaces, so later header lines take precedence.
+func parseGitHeader(line string) File {
+	paths := strings.TrimPrefix(line, "diff --git ")
+	if strings.HasPrefix(paths, `"`) {
+		if old, rest, ok := cutQuoted(paths); ok {
+			return File{OldPath: stripPrefix(old), NewPath: stripPrefix(unquote(strings.TrimSpace(rest)))}
+		}
+	}
+	// Without a rename both paths are the same
+	if half := (len(paths) - 1) / 2; len(paths)%2 == 1 && paths[half] == ' ' {
+		return File{OldPath: stripPrefix(paths[:half]), NewPath: stripPrefix(paths[half+1:])}
+	}
+	oldPath, newPath, _ := strings.Cut(paths, " ")
+	return File{OldPath: stripPrefix(oldPath), NewPath: stripPrefix(newPath)}
+}
+
+// parseHeaderLine applies an extended header line to the file
+func parseHeaderLine(file *File, line string) {
+	file.Header = append(file.Header, line)
+	switch {
+	case strings.HasPrefix(line, "--- "):
+		file.OldPath = diffPath(strings.TrimPrefix(line, "--- "))
+	case strings.HasPrefix(line, "+++ "):
+		file.NewPath = diffPath(strings.TrimPrefix(line, "+++ "))
+	case strings.HasPrefix(line, "rename from "):
+		file.OldPath = unquote(strings.TrimPrefix(line, "rename from "))
+	case strings.HasPrefix(line, "rename to "):
+		file.NewPath = unquote(strings.TrimPrefix(line, "rename to "))
+	case strings.HasPrefix(line, "new file mode "):
+		file.OldPath = ""
+	case strings.HasPrefix(line, "deleted file mode "):
+		file.NewPath = ""
+	case strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch":
+		file.Binary = true
+	}
+}
+
+// diffPath returns the path of a ---/+++ line, or "" for /dev/null
+func diffPath(path string) string {
+	path = unquote(strings.TrimSuffix(path, "\t"))
+	if path == "/dev/null" {
+		return ""
+	}
+	return stripPrefix(path)
+}
+
+// stripPrefix removes the a/ or b/ prefix of a path
+func stripPrefix(path string) string {
+	if len(path) > 2 && (path[:2] == "a/" || path[:2] == "b/") {
+		return path[2:]
+	}
+	return path
+}
+
+// unquote decodes a path that git quoted because of special characters
+func unquote(path string) string {
+	if strings.HasPrefix(path, `"`) {
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			return unquoted
+		}
+	}
+	return path
+}
+
+// cutQuoted splits a leading quoted string from the rest
+func cutQuoted(s string) (string, string, bool) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return unquote(s[:i+1]), s[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// parseHunkHeader parses "@@ -oldStart,oldLines +newStart,newLines @@ section"
+func parseHunkHeader(line string) (Hunk, error) {
+	h := Hunk{Header: line}
+	fields := strings.Fields(line)
+	if len(fields) < 4 || fields[3] != "@@" {
+		return h, fmt.Errorf("invalid hunk header %q", line)
+	}
+
+	var err error
+	if h.OldStart, h.OldLines, err = parseRange(fields[1], "-"); err != nil {
+		return h, fmt.Errorf("invalid hunk header %q: %w", line, err)
+	}
+	if h.NewStart, h.NewLines, err = parseRange(fields[2], "+"); err != nil {
+		return h, fmt.Errorf("invalid hunk header %q: %w", line, err)
+	}
+	return h, nil
+}
+
+// parseRange parses "-start,lines" or "-start", where lines defaults to 1
+func parseRange(s, prefix string) (int, int, error) {
+	if !strings.HasPrefix(s, prefix) {
+		return 0, 0, fmt.Errorf("expected %q", prefix)
+	}
+	start, lines, ok := strings.Cut(s[1:], ",")
+	n, err := strconv.Atoi(start)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !ok {
+		return n, 1, nil
+	}
+	count, err := strconv.Atoi(lines)
+	return n, count, err
+}
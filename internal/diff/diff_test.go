@@ -0,0 +1,91 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+const sample = `diff --git a/internal/ui/ui.go b/internal/ui/ui.go
+index 1234567..89abcde 100644
+--- a/internal/ui/ui.go
++++ b/internal/ui/ui.go
+@@ -1,3 +1,4 @@ package ui
+ package ui
+-
++// changed
++
+ import "fmt"
+@@ -10 +11 @@ func f() {
+-	return 1
++	return 2
+\ No newline at end of file
+diff --git a/new file.txt b/new file.txt
+new file mode 100644
+index 0000000..e69de29
+--- /dev/null
++++ b/new file.txt
+@@ -0,0 +1 @@
++hello
+diff --git a/old.md b/docs/new.md
+similarity index 100%
+rename from old.md
+rename to docs/new.md
+diff --git a/logo.png b/logo.png
+deleted file mode 100644
+index 1234567..0000000
+Binary files a/logo.png and /dev/null differ
+`
+
+func TestParse(t *testing.T) {
+	files, err := Parse(sample)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(files) != 4 {
+		t.Fatalf("Parse() returned %d files, want 4", len(files))
+	}
+
+	paths := [][2]string{}
+	for _, f := range files {
+		paths = append(paths, [2]string{f.OldPath, f.NewPath})
+	}
+	wantPaths := [][2]string{
+		{"internal/ui/ui.go", "internal/ui/ui.go"},
+		{"", "new file.txt"},
+		{"old.md", "docs/new.md"},
+		{"logo.png", ""},
+	}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Errorf("paths = %v, want %v", paths, wantPaths)
+	}
+	if files[3].Path() != "logo.png" || !files[3].Binary {
+		t.Errorf("deleted binary file = %+v", files[3])
+	}
+
+	hunks := files[0].Hunks
+	if len(hunks) != 2 {
+		t.Fatalf("got %d hunks, want 2", len(hunks))
+	}
+	if h := hunks[0]; h.OldStart != 1 || h.OldLines != 3 || h.NewStart != 1 || h.NewLines != 4 || h.Header != "@@ -1,3 +1,4 @@ package ui" {
+		t.Errorf("first hunk = %+v", h)
+	}
+	wantLines := []Line{
+		{Kind: Context, Text: "package ui"},
+		{Kind: Removed, Text: ""},
+		{Kind: Added, Text: "// changed"},
+		{Kind: Added, Text: ""},
+		{Kind: Context, Text: `import "fmt"`},
+	}
+	if !reflect.DeepEqual(hunks[0].Lines, wantLines) {
+		t.Errorf("first hunk lines = %+v, want %+v", hunks[0].Lines, wantLines)
+	}
+	if h := hunks[1]; h.OldLines != 1 || h.NewStart != 11 || len(h.Lines) != 3 || h.Lines[2].Kind != NoNewline {
+		t.Errorf("second hunk = %+v", h)
+	}
+}
+
+func TestParseInvalidHunk(t *testing.T) {
+	if _, err := Parse("diff --git a/x b/x\n@@ -a +1 @@\n"); err == nil {
+		t.Error("Parse() succeeded on an invalid hunk header")
+	}
+}
@@ -0,0 +1,265 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Groups returns the number of change groups in the hunk: runs of added and
+// removed lines separated by context lines
+func (h Hunk) Groups() int {
+	groups := 0
+	for _, g := range h.LineGroups() {
+		groups = max(groups, g+1)
+	}
+	return groups
+}
+
+// LineGroups returns the change group of each line of the hunk, or -1 for
+// context lines. A "\ No newline" marker belongs to the group of its line.
+func (h Hunk) LineGroups() []int {
+	groups := make([]int, len(h.Lines))
+	group, last := -1, -1
+	for i, l := range h.Lines {
+		switch {
+		case l.Kind == NoNewline:
+			groups[i] = last
+			continue
+		case l.changed() && last < 0:
+			group++
+			last = group
+		case !l.changed():
+			last = -1
+		}
+		groups[i] = last
+	}
+	return groups
+}
+
+// changed reports whether the line is added or removed
+func (l Line) changed() bool {
+	return l.Kind == Added || l.Kind == Removed
+}
+
+// Select returns the hunk with only the change groups for which keep returns
+// true. Removed lines of the other groups become context and their added
+// lines are dropped, so the hunk still applies to the old version.
+func (h Hunk) Select(keep func(group int) bool) Hunk {
+	selected := Hunk{Header: h.Header, OldStart: h.OldStart, NewStart: h.NewStart}
+	groups := h.LineGroups()
+	dropped := false
+	for i, l := range h.Lines {
+		switch {
+		case l.Kind == NoNewline:
+			// The marker belongs to the previous line
+			if dropped {
+				continue
+			}
+		case l.changed() && !keep(groups[i]):
+			if l.Kind == Added {
+				dropped = true
+				continue
+			}
+			l.Kind = Context
+		}
+		dropped = false
+		selected.Lines = append(selected.Lines, l)
+	}
+	selected.count()
+	return selected
+}
+
+// count sets the line counts of the hunk from its lines
+func (h *Hunk) count() {
+	h.OldLines, h.NewLines = 0, 0
+	for _, l := range h.Lines {
+		switch l.Kind {
+		case Context:
+			h.OldLines++
+			h.NewLines++
+		case Removed:
+			h.OldLines++
+		case Added:
+			h.NewLines++
+		}
+	}
+}
+
+// Changed reports whether the hunk adds or removes any line
+func (h Hunk) Changed() bool {
+	for _, l := range h.Lines {
+		if l.changed() {
+			return true
+		}
+	}
+	return false
+}
+
+// Format renders the files as a patch that git apply accepts. Hunks without
+// changes are left out, as are files that only had such hunks, and the new
+// line numbers are recomputed for the hunks that are kept.
+func Format(files []File) string {
+	var sb strings.Builder
+	for _, f := range files {
+		var hunks []Hunk
+		for _, h := range f.Hunks {
+			if h.Changed() {
+				hunks = append(hunks, h)
+			}
+		}
+		if len(f.Hunks) > 0 && len(hunks) == 0 {
+			continue
+		}
+
+		oldPath, newPath := f.OldPath, f.NewPath
+		if oldPath == "" {
+			oldPath = newPath
+		}
+		if newPath == "" {
+			newPath = oldPath
+		}
+		fmt.Fprintf(&sb, "diff --git %s %s\n", quotePath("a/"+oldPath), quotePath("b/"+newPath))
+		for _, line := range f.Header {
+			sb.WriteString(line + "\n")
+		}
+
+		offset := 0
+		for _, h := range hunks {
+			h.NewStart = h.OldStart + offset
+			// Empty ranges start at the line before them
+			if h.OldLines == 0 {
+				h.NewStart++
+			}
+			if h.NewLines == 0 {
+				h.NewStart--
+			}
+			offset += h.NewLines - h.OldLines
+
+			sb.WriteString(h.formatHeader() + "\n")
+			for _, l := range h.Lines {
+				sb.WriteString(l.prefix() + l.Text + "\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// formatHeader renders the "@@ -1,2 +1,3 @@ section" line from the line
+// numbers, keeping the section of the original header
+func (h Hunk) formatHeader() string {
+	header := fmt.Sprintf("@@ -%s +%s @@", formatRange(h.OldStart, h.OldLines), formatRange(h.NewStart, h.NewLines))
+	if rest, ok := strings.CutPrefix(h.Header, "@@ "); ok {
+		if i := strings.Index(rest, " @@"); i >= 0 {
+			header += rest[i+3:]
+		}
+	}
+	return header
+}
+
+// formatRange renders a hunk range like git, leaving out a count of one
+func formatRange(start, lines int) string {
+	if lines == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+// prefix returns the prefix character of the line
+func (l Line) prefix() string {
+	switch l.Kind {
+	case Added:
+		return "+"
+	case Removed:
+		return "-"
+	case NoNewline:
+		return "\\"
+	}
+	return " "
+}
+
+// quotePath quotes a path like git does when it contains special characters
+func quotePath(path string) string {
+	var sb strings.Builder
+	quoted := false
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case c == '"' || c == '\\':
+			sb.WriteString(`\` + string(c))
+		case c == '\t':
+			sb.WriteString(`\t`)
+		case c == '\n':
+			sb.WriteString(`\n`)
+		case c < 0x20 || c >= 0x7f:
+			fmt.Fprintf(&sb, `\%03o`, c)
+		default:
+			sb.WriteByte(c)
+			continue
+		}
+		quoted = true
+	}
+	if !quoted {
+		return path
+	}
+	return `"` + sb.String() + `"`
+}
+
+// Apply applies the hunks of the file to its old content and returns the new
+// content. Unlike git apply, the hunks must match exactly at their line numbers.
+func Apply(content string, f File) (string, error) {
+	var lines []string
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+	newline := content == "" || strings.HasSuffix(content, "\n")
+
+	var out []string
+	pos := 0
+	for _, h := range f.Hunks {
+		// An empty old range starts after the given line
+		start := h.OldStart - 1
+		if h.OldLines == 0 {
+			start = h.OldStart
+		}
+		if start < pos || start > len(lines) {
+			return "", fmt.Errorf("%s: hunk %q does not apply", f.Path(), h.Header)
+		}
+		out = append(out, lines[pos:start]...)
+		pos = start
+
+		missingNewline := false
+		var last LineKind
+		for _, l := range h.Lines {
+			switch l.Kind {
+			case Context, Removed:
+				if pos >= len(lines) || lines[pos] != l.Text {
+					return "", fmt.Errorf("%s: hunk %q does not apply", f.Path(), h.Header)
+				}
+				pos++
+				if l.Kind == Context {
+					out = append(out, l.Text)
+				}
+			case Added:
+				out = append(out, l.Text)
+			case NoNewline:
+				if last != Removed {
+					missingNewline = true
+				}
+			}
+			last = l.Kind
+		}
+		if pos == len(lines) {
+			newline = !missingNewline
+		}
+	}
+	out = append(out, lines[pos:]...)
+
+	if len(out) == 0 {
+		return "", nil
+	}
+	result := strings.Join(out, "\n")
+	if newline {
+		result += "\n"
+	}
+	return result, nil
+}
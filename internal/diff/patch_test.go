@@ -0,0 +1,116 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+const before = "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten"
+
+// patch changes "two" and "nine" in a single hunk and adds a newline at the end
+const patch = `diff --git a/numbers.txt b/numbers.txt
+index 1234567..89abcde 100644
+--- a/numbers.txt
++++ b/numbers.txt
+@@ -1,10 +1,11 @@ header
+ one
+-two
++2
++2.5
+ three
+ four
+ five
+ six
+ seven
+ eight
+-nine
+-ten
+\ No newline at end of file
++9
++ten
+`
+
+func TestSelectAndApply(t *testing.T) {
+	files, err := Parse(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hunk := files[0].Hunks[0]
+	if got := hunk.Groups(); got != 2 {
+		t.Fatalf("Groups() = %d, want 2", got)
+	}
+
+	tests := []struct {
+		name string
+		keep func(int) bool
+		want string
+	}{
+		{"all", func(int) bool { return true }, "one\n2\n2.5\nthree\nfour\nfive\nsix\nseven\neight\n9\nten\n"},
+		{"first", func(g int) bool { return g == 0 }, "one\n2\n2.5\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten"},
+		{"second", func(g int) bool { return g == 1 }, before[:4] + "two\nthree\nfour\nfive\nsix\nseven\neight\n9\nten\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := files[0]
+			f.Hunks = []Hunk{hunk.Select(tt.keep)}
+
+			// The formatted patch parses back to the same hunk
+			text := Format([]File{f})
+			reparsed, err := Parse(text)
+			if err != nil {
+				t.Fatalf("Parse(Format()) error = %v\n%s", err, text)
+			}
+			if !strings.Contains(text, " @@ header\n") {
+				t.Errorf("Format() lost the hunk section:\n%s", text)
+			}
+
+			got, err := Apply(before, reparsed[0])
+			if err != nil {
+				t.Fatalf("Apply() error = %v\n%s", err, text)
+			}
+			if got != tt.want {
+				t.Errorf("Apply() = %q, want %q\n%s", got, tt.want, text)
+			}
+		})
+	}
+
+	if got := hunk.Select(func(int) bool { return false }); got.Changed() {
+		t.Errorf("Select() without groups still has changes: %+v", got)
+	}
+	if _, err := Apply("something else\n", files[0]); err == nil {
+		t.Error("Apply() to other content succeeded")
+	}
+}
+
+func TestFormatRenumbers(t *testing.T) {
+	files, err := Parse(`diff --git "a/t\303\244st.txt" "b/t\303\244st.txt"
+--- "a/t\303\244st.txt"
++++ "b/t\303\244st.txt"
+@@ -1,2 +1,3 @@
+ a
++b
+ c
+@@ -10,2 +11,2 @@
+ x
+-y
++z
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without the first hunk the second one starts at the same line again
+	f := files[0]
+	f.Hunks[0] = f.Hunks[0].Select(func(int) bool { return false })
+	want := `diff --git "a/t\303\244st.txt" "b/t\303\244st.txt"
+--- "a/t\303\244st.txt"
++++ "b/t\303\244st.txt"
+@@ -10,2 +10,2 @@
+ x
+-y
++z
+`
+	if got := Format([]File{f}); got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,264 @@
+// Package draft derives draft commit subjects from a staged diff, without
+// any network service.
+package draft
+
+import (
+	"fmt"
+	"go/token"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/diff"
+)
+
+// MaxSubjects is the number of drafts that Subjects returns at most
+const MaxSubjects = 3
+
+var (
+	// funcLine matches a Go function or method declaration, with the
+	// receiver type in the first group and the name in the second
+	funcLine = regexp.MustCompile(`^func\s+(?:\(\s*(?:\w+\s+)?\*?(\w+)(?:\[[^\]]*\])?\s*\)\s*)?(\w+)`)
+	// typeLine matches a Go type declaration
+	typeLine = regexp.MustCompile(`^type\s+(\w+)\s`)
+	// testName matches the name of a Go test, benchmark, fuzz test or example
+	testName = regexp.MustCompile(`^(?:Test|Benchmark|Fuzz|Example)_?(\w*)$`)
+
+	// keyLines match the keys of configuration files by extension
+	keyLines = map[string]*regexp.Regexp{
+		".json": regexp.MustCompile(`^\s*"([\w.-]+)"\s*:`),
+		".yaml": regexp.MustCompile(`^\s*-?\s*([\w.-]+)\s*:`),
+		".yml":  regexp.MustCompile(`^\s*-?\s*([\w.-]+)\s*:`),
+		".toml": regexp.MustCompile(`^\s*([\w.-]+)\s*=`),
+	}
+)
+
+// lockFiles are generated files whose keys are not worth a subject
+var lockFiles = map[string]bool{
+	"package-lock.json":   true,
+	"npm-shrinkwrap.json": true,
+	"pnpm-lock.yaml":      true,
+	"composer.lock":       true,
+}
+
+// changes collects the names added, removed or changed by a diff, in the
+// order they were first seen
+type changes struct {
+	added   map[string]bool
+	removed map[string]bool
+	order   []string
+}
+
+// newChanges creates an empty collection
+func newChanges() *changes {
+	return &changes{added: make(map[string]bool), removed: make(map[string]bool)}
+}
+
+// record notes that a line declaring the name was added or removed
+func (c *changes) record(name string, kind diff.LineKind) {
+	if !c.added[name] && !c.removed[name] {
+		c.order = append(c.order, name)
+	}
+	switch kind {
+	case diff.Added:
+		c.added[name] = true
+	case diff.Removed:
+		c.removed[name] = true
+	}
+}
+
+// touch notes that the body of a declaration was changed
+func (c *changes) touch(name string) {
+	c.record(name, diff.Added)
+	c.record(name, diff.Removed)
+}
+
+// split returns the names that were only added, only removed, or changed
+func (c *changes) split() (added, removed, changed []string) {
+	for _, name := range c.order {
+		switch {
+		case c.added[name] && c.removed[name]:
+			changed = append(changed, name)
+		case c.added[name]:
+			added = append(added, name)
+		default:
+			removed = append(removed, name)
+		}
+	}
+	return added, removed, changed
+}
+
+// Subjects returns up to MaxSubjects draft subjects for the files of a
+// staged diff, in imperative mood: renamed files, added, removed and
+// changed Go declarations, new tests, changed configuration keys, and added
+// or removed files, in that order.
+func Subjects(files []diff.File) []string {
+	decls, tests, keys := newChanges(), newChanges(), newChanges()
+	helpers := make(map[string]bool)
+	var renamed, created, deleted, bodies []string
+
+	for _, f := range files {
+		switch {
+		case f.OldPath == "":
+			created = append(created, path.Base(f.NewPath))
+		case f.NewPath == "":
+			deleted = append(deleted, path.Base(f.OldPath))
+		case f.OldPath != f.NewPath:
+			renamed = append(renamed, renaming(f.OldPath, f.NewPath))
+		}
+
+		name := f.Path()
+		keyLine := keyLines[path.Ext(name)]
+		if lockFiles[path.Base(name)] {
+			keyLine = nil
+		}
+		for _, h := range f.Hunks {
+			// git names the function before the hunk after its range, which
+			// a change before the next declaration is part of
+			enclosing, _ := goDecl(section(h.Header))
+			if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+				enclosing = ""
+			}
+			for _, line := range h.Lines {
+				if decl, _ := goDecl(line.Text); decl != "" {
+					enclosing = ""
+				}
+				if line.Kind != diff.Added && line.Kind != diff.Removed {
+					continue
+				}
+				if enclosing != "" {
+					bodies = append(bodies, enclosing)
+					enclosing = ""
+				}
+				switch {
+				case strings.HasSuffix(name, ".go"):
+					decl, isFunc := goDecl(line.Text)
+					if decl == "" {
+						continue
+					}
+					if m := testName.FindStringSubmatch(decl); m != nil && isFunc && strings.HasSuffix(name, "_test.go") {
+						// TestWalk_Symlinks tests Walk
+						if tested, _, _ := strings.Cut(m[1], "_"); tested != "" {
+							tests.record(tested, line.Kind)
+						}
+						continue
+					}
+					if isFunc && !strings.Contains(decl, ".") && !token.IsExported(decl) {
+						helpers[decl] = true
+					}
+					decls.record(decl, line.Kind)
+				case keyLine != nil:
+					if m := keyLine.FindStringSubmatch(line.Text); m != nil {
+						keys.record(m[1], line.Kind)
+					}
+				}
+			}
+		}
+	}
+
+	// Declarations added or removed as a whole are not updated
+	for _, decl := range bodies {
+		if !decls.added[decl] && !decls.removed[decl] {
+			decls.touch(decl)
+		}
+	}
+
+	var subjects []string
+	if len(renamed) > 0 {
+		subjects = append(subjects, "rename "+list(renamed))
+	}
+	added, removed, changed := decls.split()
+	if len(added) > 0 {
+		subjects = append(subjects, "add "+list(added))
+	}
+	if len(removed) > 0 {
+		subject := "remove " + list(removed)
+		if len(removed) == 1 && helpers[removed[0]] {
+			subject += " helper"
+		}
+		subjects = append(subjects, subject)
+	}
+	if len(changed) > 0 {
+		subjects = append(subjects, "update "+list(changed))
+	}
+	if addedTests, _, _ := tests.split(); len(addedTests) > 0 {
+		subjects = append(subjects, "add tests for "+list(addedTests))
+	}
+	addedKeys, removedKeys, changedKeys := keys.split()
+	if len(addedKeys) > 0 {
+		subjects = append(subjects, "add "+list(addedKeys)+" "+plural("setting", len(addedKeys)))
+	}
+	if len(removedKeys) > 0 {
+		subjects = append(subjects, "remove "+list(removedKeys)+" "+plural("setting", len(removedKeys)))
+	}
+	if len(changedKeys) > 0 {
+		subjects = append(subjects, "change "+list(changedKeys)+" "+plural("setting", len(changedKeys)))
+	}
+	if len(created) > 0 {
+		subjects = append(subjects, "add "+list(created))
+	}
+	if len(deleted) > 0 {
+		subjects = append(subjects, "remove "+list(deleted))
+	}
+	if len(subjects) == 0 && len(files) == 1 {
+		subjects = append(subjects, "update "+path.Base(files[0].Path()))
+	}
+
+	if len(subjects) > MaxSubjects {
+		subjects = subjects[:MaxSubjects]
+	}
+	return subjects
+}
+
+// goDecl returns the name of the Go function, method or type declared by a
+// line, with the receiver type for methods, and whether it is a function
+func goDecl(line string) (string, bool) {
+	if m := funcLine.FindStringSubmatch(line); m != nil {
+		if m[1] != "" {
+			return m[1] + "." + m[2], true
+		}
+		return m[2], true
+	}
+	if m := typeLine.FindStringSubmatch(line); m != nil {
+		return m[1], false
+	}
+	return "", false
+}
+
+// section returns the text after the range of a hunk header, like
+// "func f() {" in "@@ -1,2 +1,3 @@ func f() {"
+func section(header string) string {
+	parts := strings.SplitN(header, "@@", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	return strings.TrimSpace(parts[2])
+}
+
+// renaming describes a renamed file, with only the file names if it stayed
+// in its directory
+func renaming(oldPath, newPath string) string {
+	if path.Dir(oldPath) == path.Dir(newPath) {
+		return path.Base(oldPath) + " to " + path.Base(newPath)
+	}
+	return oldPath + " to " + newPath
+}
+
+// list joins names like "a", "a and b" or "a, b and 2 more"
+func list(names []string) string {
+	switch len(names) {
+	case 1:
+		return names[0]
+	case 2:
+		return names[0] + " and " + names[1]
+	}
+	return fmt.Sprintf("%s, %s and %d more", names[0], names[1], len(names)-2)
+}
+
+// plural returns the noun for the count, with a trailing "s" for several
+func plural(noun string, count int) string {
+	if count == 1 {
+		return noun
+	}
+	return noun + "s"
+}
@@ -0,0 +1,96 @@
+package draft
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/a1yama/git-cz-go/internal/diff"
+)
+
+func TestSubjects(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+		want []string
+	}{
+		{
+			name: "go declarations",
+			diff: `diff --git a/internal/git/scopes.go b/internal/git/scopes.go
+index 1234567..89abcde 100644
+--- a/internal/git/scopes.go
++++ b/internal/git/scopes.go
+@@ -10,6 +10,10 @@ import (
++// DetectScopes returns the scopes
++func (c *cache) DetectScopes(dirs []string) []string {
++	return nil
++}
+@@ -40,5 +44,1 @@ func other() {
+-func contains(s []string, v string) bool {
+-	return false
+-}
+@@ -60,3 +60,3 @@ func Walk(dir string) error {
+-	return nil
++	return walk(dir)
+`,
+			want: []string{"add cache.DetectScopes", "remove contains helper", "update Walk"},
+		},
+		{
+			name: "tests and renames",
+			diff: `diff --git a/old_test.go b/scopes_test.go
+similarity index 90%
+rename from old_test.go
+rename to scopes_test.go
+--- a/old_test.go
++++ b/scopes_test.go
+@@ -1,3 +1,6 @@ package git
++func TestDetectScopes(t *testing.T) {
++}
++func TestWalk_Symlinks(t *testing.T) {
++}
+`,
+			want: []string{"rename old_test.go to scopes_test.go", "add tests for DetectScopes and Walk"},
+		},
+		{
+			name: "config keys and files",
+			diff: `diff --git a/config.json b/config.json
+index 1234567..89abcde 100644
+--- a/config.json
++++ b/config.json
+@@ -1,3 +1,4 @@
+-  "maxSubjectLength": 100,
++  "maxSubjectLength": 72,
++  "typeRules": [],
+diff --git a/docs/guide.md b/docs/guide.md
+new file mode 100644
+--- /dev/null
++++ b/docs/guide.md
+@@ -0,0 +1 @@
++# Guide
+`,
+			want: []string{"add typeRules setting", "change maxSubjectLength setting", "add guide.md"},
+		},
+		{
+			name: "single file without anything else",
+			diff: `diff --git a/README.md b/README.md
+index 1234567..89abcde 100644
+--- a/README.md
++++ b/README.md
+@@ -1 +1 @@
+-old
++new
+`,
+			want: []string{"update README.md"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := diff.Parse(tt.diff)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Subjects(files); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Subjects() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// WriteCache writes a cache file and removes the oldest files in its
+// directory so that at most keep remain. Caches are only an optimization,
+// so failures are ignored.
+func WriteCache(file string, data []byte, keep int) {
+	dir := filepath.Dir(file)
+	if os.MkdirAll(dir, 0755) != nil || os.WriteFile(file, data, 0644) != nil {
+		return
+	}
+	pruneCache(file, keep)
+}
+
+// pruneCache removes the least recently written files in the directory of
+// the given file, which is always kept, beyond the newest keep
+func pruneCache(file string, keep int) {
+	dir := filepath.Dir(file)
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) <= keep {
+		return
+	}
+
+	type cached struct {
+		path string
+		info os.FileInfo
+	}
+	var files []cached
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if info, err := entry.Info(); err == nil && info.Mode().IsRegular() && path != file {
+			files = append(files, cached{path, info})
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].info.ModTime().After(files[j].info.ModTime())
+	})
+	for i := max(keep-1, 0); i < len(files); i++ {
+		_ = os.Remove(files[i].path)
+	}
+}
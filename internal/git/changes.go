@@ -0,0 +1,77 @@
+package git
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// FileChange is a staged change to a file, as returned by GetStagedChanges
+type FileChange struct {
+	// Status is git's status letter: A (added), M (modified), D (deleted),
+	// R (renamed), C (copied) or T (type changed)
+	Status string
+	// Path is the path relative to the root directory, after a rename
+	Path string
+	// OldPath is the path before a rename or copy, or ""
+	OldPath string
+	// Added and Removed are the number of added and removed lines
+	Added   int
+	Removed int
+	// Binary is set for binary files, which have no line counts
+	Binary bool
+}
+
+// StatusEntry is a file with staged, unstaged or untracked changes, as
+// returned by GetStatus
+type StatusEntry struct {
+	// Path is the path relative to the root directory
+	Path string
+	// OldPath is the path before a staged rename, or ""
+	OldPath string
+	// Staged and Unstaged are git's status letters for the index and the
+	// working tree, like in `git status --short`, or "" when unchanged.
+	// Untracked files have "?" as Unstaged.
+	Staged   string
+	Unstaged string
+}
+
+// Untracked reports whether the file is not tracked by git
+func (e StatusEntry) Untracked() bool {
+	return e.Unstaged == "?"
+}
+
+// binaryCheckSize is how much of a file is checked for NUL bytes, like git does
+const binaryCheckSize = 8000
+
+// isBinary reports whether content looks binary to git
+func isBinary(content []byte) bool {
+	if len(content) > binaryCheckSize {
+		content = content[:binaryCheckSize]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+// countLines returns the number of lines added and removed between two versions of a file
+func countLines(before, after []byte) FileChange {
+	if isBinary(before) || isBinary(after) {
+		return FileChange{Binary: true}
+	}
+
+	var change FileChange
+	for _, d := range diff.Do(string(before), string(after)) {
+		lines := strings.Count(d.Text, "\n")
+		if !strings.HasSuffix(d.Text, "\n") && d.Text != "" {
+			lines++
+		}
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			change.Added += lines
+		case diffmatchpatch.DiffDelete:
+			change.Removed += lines
+		}
+	}
+	return change
+}
@@ -0,0 +1,156 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Cleanup modes of git commit --cleanup
+const (
+	CleanupStrip      = "strip"
+	CleanupWhitespace = "whitespace"
+	CleanupVerbatim   = "verbatim"
+	CleanupScissors   = "scissors"
+)
+
+// autoCommentChars are the candidates git tries for core.commentChar=auto
+const autoCommentChars = "#;@!$%^&|:"
+
+// CommitOptions holds the options for Repository.Commit
+type CommitOptions struct {
+	// Cleanup is the --cleanup mode; empty means CleanupWhitespace
+	Cleanup string
+	// Args are extra flags passed through to git commit, see ValidateCommitArgs
+	Args []string
+}
+
+// CleanupSettings describes how git will clean up a commit message
+type CleanupSettings struct {
+	Mode        string
+	CommentChar string
+}
+
+// GetCleanupSettings reads commit.cleanup and core.commentChar from git config.
+// Because the message is not edited, the "default" mode means whitespace, as in git.
+func GetCleanupSettings(ctx context.Context, repo Repository) (CleanupSettings, error) {
+	settings := CleanupSettings{Mode: CleanupWhitespace, CommentChar: "#"}
+
+	entries, err := repo.GetConfigEntries(ctx, `^(commit\.cleanup|core\.commentchar)$`)
+	if err != nil {
+		return settings, err
+	}
+
+	for _, entry := range entries {
+		switch entry.Key {
+		case "commit.cleanup":
+			switch entry.Value {
+			case CleanupStrip, CleanupWhitespace, CleanupVerbatim, CleanupScissors:
+				settings.Mode = entry.Value
+			case "default":
+				settings.Mode = CleanupWhitespace
+			default:
+				return settings, fmt.Errorf("invalid commit.cleanup mode %q", entry.Value)
+			}
+		case "core.commentchar":
+			settings.CommentChar = entry.Value
+		}
+	}
+	return settings, nil
+}
+
+// commentChar returns the comment character to use for the message,
+// choosing one that no line starts with for core.commentChar=auto
+func (s CleanupSettings) commentChar(message string) string {
+	if s.CommentChar != "auto" {
+		return s.CommentChar
+	}
+
+	used := make(map[byte]bool)
+	for _, line := range strings.Split(message, "\n") {
+		if line = strings.TrimLeft(line, " \t"); line != "" {
+			used[line[0]] = true
+		}
+	}
+	for i := 0; i < len(autoCommentChars); i++ {
+		if !used[autoCommentChars[i]] {
+			return autoCommentChars[i : i+1]
+		}
+	}
+	return "#"
+}
+
+// Clean returns the message as git will record it, without the final newline.
+// Like git, trailing whitespace, leading and trailing blank lines and repeated
+// blank lines are removed, and in strip mode so are comment lines.
+func (s CleanupSettings) Clean(message string) string {
+	if s.Mode == CleanupVerbatim {
+		return strings.TrimSuffix(message, "\n")
+	}
+
+	commentChar := s.commentChar(message)
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(message, "\n") {
+		if s.Mode == CleanupStrip && commentChar != "" && strings.HasPrefix(line, commentChar) {
+			continue
+		}
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RemovedComments returns the lines that strip mode removes as comments
+func (s CleanupSettings) RemovedComments(message string) []string {
+	if s.Mode != CleanupStrip {
+		return nil
+	}
+
+	commentChar := s.commentChar(message)
+	var removed []string
+	for _, line := range strings.Split(message, "\n") {
+		if commentChar != "" && strings.HasPrefix(line, commentChar) {
+			removed = append(removed, line)
+		}
+	}
+	return removed
+}
+
+// commitArgFlags lists the git commit flags that may be passed through.
+// Flags ending in "=" take a value.
+var commitArgFlags = []string{
+	"--no-verify", "-n",
+	"--gpg-sign", "--gpg-sign=", "-S", "--no-gpg-sign",
+	"--author=", "--date=",
+	"--allow-empty",
+	"--signoff", "-s", "--no-signoff",
+}
+
+// ValidateCommitArgs checks that only supported flags are passed through to git commit,
+// so that they cannot interfere with how the message is passed
+func ValidateCommitArgs(args []string) error {
+	for _, arg := range args {
+		valid := false
+		for _, flag := range commitArgFlags {
+			if arg == flag ||
+				(strings.HasSuffix(flag, "=") && strings.HasPrefix(arg, flag) && len(arg) > len(flag)) ||
+				(flag == "-S" && strings.HasPrefix(arg, "-S")) {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("unsupported git commit flag %q", arg)
+		}
+	}
+	return nil
+}
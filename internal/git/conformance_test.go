@@ -0,0 +1,547 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/a1yama/git-cz-go/internal/diff"
+)
+
+// backends creates each Repository implementation backed by a real repository
+var backends = map[string]func(dir string) Repository{
+	BackendExec:  func(dir string) Repository { return NewExecRepository(dir) },
+	BackendGoGit: func(dir string) Repository { return NewGoGitRepository(dir) },
+}
+
+// gitRun runs git in dir and returns its trimmed output
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// writeFile writes a file relative to dir, creating its directories
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newConformanceRepo creates a repository on branch main with the given files staged,
+// isolated from the user's global git config
+func newConformanceRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "init", "-q")
+	gitRun(t, dir, "symbolic-ref", "HEAD", "refs/heads/main")
+	gitRun(t, dir, "config", "user.name", "Test User")
+	gitRun(t, dir, "config", "user.email", "test@example.com")
+	for name, content := range files {
+		writeFile(t, dir, name, content)
+		gitRun(t, dir, "add", name)
+	}
+	return dir
+}
+
+// TestConformance runs the same checks against every backend on identical repositories
+func TestConformance(t *testing.T) {
+	ctx := context.Background()
+
+	for name, newRepo := range backends {
+		t.Run(name, func(t *testing.T) {
+			t.Run("unborn branch", func(t *testing.T) {
+				dir := newConformanceRepo(t, map[string]string{
+					"README.md":      "hello\n",
+					"cmd/app/app.go": "package main\n",
+				})
+				writeFile(t, dir, "untracked.txt", "ignored\n")
+				repo := newRepo(filepath.Join(dir, "cmd"))
+
+				if !repo.IsGitRepository(ctx) {
+					t.Fatal("IsGitRepository() = false, want true")
+				}
+				if root, err := repo.GetGitRootDir(ctx); err != nil || root != dir {
+					t.Errorf("GetGitRootDir() = %q, %v, want %q", root, err, dir)
+				}
+				if common, err := repo.GetGitCommonDir(ctx); err != nil || common != filepath.Join(dir, ".git") {
+					t.Errorf("GetGitCommonDir() = %q, %v, want %q", common, err, filepath.Join(dir, ".git"))
+				}
+				if branch, err := repo.GetCurrentBranch(ctx); err != nil || branch != "main" {
+					t.Errorf("GetCurrentBranch() = %q, %v, want main", branch, err)
+				}
+				if superproject, err := repo.GetSuperproject(ctx); err != nil || superproject != "" {
+					t.Errorf("GetSuperproject() = %q, %v, want none", superproject, err)
+				}
+
+				want := []string{"README.md", "cmd/app/app.go"}
+				if files, err := repo.GetStagedFiles(ctx); err != nil || !reflect.DeepEqual(files, want) {
+					t.Errorf("GetStagedFiles() = %v, %v, want %v", files, err, want)
+				}
+				if files, err := repo.ListFiles(ctx); err != nil || !reflect.DeepEqual(files, want) {
+					t.Errorf("ListFiles() = %v, %v, want %v", files, err, want)
+				}
+			})
+
+			t.Run("not a repository", func(t *testing.T) {
+				dir, err := filepath.EvalSymlinks(t.TempDir())
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(dir))
+				if newRepo(dir).IsGitRepository(ctx) {
+					t.Error("IsGitRepository() = true, want false")
+				}
+			})
+
+			t.Run("commit", func(t *testing.T) {
+				dir := newConformanceRepo(t, map[string]string{"a.txt": "a\n"})
+				repo := newRepo(dir)
+
+				err := repo.Commit(ctx, "feat: add a\n\n# a comment\nBody.\n\n", CommitOptions{
+					Cleanup: CleanupStrip,
+					Args:    []string{"--signoff", "--author=Other Author <other@example.com>"},
+				})
+				if err != nil {
+					t.Fatalf("Commit() error = %v", err)
+				}
+
+				want := "feat: add a\n\nBody.\n\nSigned-off-by: Test User <test@example.com>"
+				if got := gitRun(t, dir, "log", "-1", "--format=%B"); got != want {
+					t.Errorf("message = %q, want %q", got, want)
+				}
+				if got := gitRun(t, dir, "log", "-1", "--format=%an <%ae>|%cn <%ce>"); got != "Other Author <other@example.com>|Test User <test@example.com>" {
+					t.Errorf("author|committer = %q", got)
+				}
+				if status := gitRun(t, dir, "status", "--porcelain"); status != "" {
+					t.Errorf("status after commit = %q, want clean", status)
+				}
+
+				if err := repo.Commit(ctx, "chore: nothing", CommitOptions{}); err == nil {
+					t.Error("Commit() without changes succeeded, want an error")
+				}
+				if err := repo.Commit(ctx, "chore: empty", CommitOptions{Args: []string{"--allow-empty"}}); err != nil {
+					t.Errorf("Commit(--allow-empty) error = %v", err)
+				}
+				if err := repo.Commit(ctx, "chore: bad", CommitOptions{Args: []string{"--amend"}}); err == nil {
+					t.Error("Commit(--amend) succeeded, want an error")
+				}
+			})
+
+			t.Run("history", func(t *testing.T) {
+				dir := newConformanceRepo(t, map[string]string{"docs/index.md": "v1\n"})
+				gitRun(t, dir, "commit", "-q", "-m", "docs: add index")
+				writeFile(t, dir, "docs/index.md", "v2\n")
+				gitRun(t, dir, "commit", "-q", "-a", "-m", "docs: update index\n\nWith a body.")
+				gitRun(t, dir, "branch", "feature")
+				gitRun(t, dir, "rm", "-q", "docs/index.md")
+				repo := newRepo(dir)
+
+				if files, err := repo.GetStagedFiles(ctx); err != nil || !reflect.DeepEqual(files, []string{"docs/index.md"}) {
+					t.Errorf("GetStagedFiles() = %v, %v, want the deleted file", files, err)
+				}
+				if branches, err := repo.GetBranches(ctx); err != nil || !reflect.DeepEqual(branches, []string{"feature", "main"}) {
+					t.Errorf("GetBranches() = %v, %v", branches, err)
+				}
+
+				log, err := repo.GetLog(ctx, 5)
+				if err != nil || len(log) != 2 {
+					t.Fatalf("GetLog() = %v, %v, want 2 entries", log, err)
+				}
+				if log[0].Message != "docs: update index\n\nWith a body." || log[1].Subject() != "docs: add index" {
+					t.Errorf("GetLog() = %q", log)
+				}
+				if log, err := repo.GetLog(ctx, 1); err != nil || len(log) != 1 {
+					t.Errorf("GetLog(1) = %v, %v, want 1 entry", log, err)
+				}
+
+				head := gitRun(t, dir, "rev-parse", "HEAD")
+				if hash, err := repo.ResolveCommit(ctx, "main"); err != nil || hash != head {
+					t.Errorf("ResolveCommit(main) = %q, %v, want %q", hash, err, head)
+				}
+				if _, err := repo.ResolveCommit(ctx, "missing"); err == nil {
+					t.Error("ResolveCommit(missing) succeeded, want an error")
+				}
+				if content, err := repo.ShowFile(ctx, "HEAD~1", "docs/index.md"); err != nil || string(content) != "v1\n" {
+					t.Errorf("ShowFile(HEAD~1) = %q, %v, want v1", content, err)
+				}
+				if _, err := repo.ShowFile(ctx, "HEAD", "missing.md"); err == nil {
+					t.Error("ShowFile(missing.md) succeeded, want an error")
+				}
+
+				writeFile(t, dir, "docs/new.md", "staged\n")
+				gitRun(t, dir, "add", "docs/new.md")
+				writeFile(t, dir, "docs/new.md", "unstaged\n")
+				if content, err := repo.ShowStagedFile(ctx, "docs/new.md"); err != nil || string(content) != "staged\n" {
+					t.Errorf("ShowStagedFile(docs/new.md) = %q, %v, want the staged content", content, err)
+				}
+				if _, err := repo.ShowStagedFile(ctx, "docs/index.md"); err == nil {
+					t.Error("ShowStagedFile(docs/index.md) succeeded for a removed file, want an error")
+				}
+
+				tree := gitRun(t, dir, "rev-parse", "HEAD^{tree}")
+				if got, err := repo.ResolveTree(ctx, "HEAD"); err != nil || got != tree {
+					t.Errorf("ResolveTree(HEAD) = %q, %v, want %q", got, err, tree)
+				}
+			})
+
+			t.Run("directories", func(t *testing.T) {
+				dir := newConformanceRepo(t, map[string]string{
+					"a/b/c/file.txt":    "c\n",
+					"a/file.txt":        "a\n",
+					"d/file.txt":        "d\n",
+					"packages/x/x.go":   "x\n",
+					"packages/x.y/y.go": "y\n",
+					"top.txt":           "top\n",
+				})
+				gitRun(t, dir, "commit", "-q", "-m", "init")
+				repo := newRepo(filepath.Join(dir, "a"))
+
+				for _, tt := range []struct {
+					prefix string
+					depth  int
+					want   []string
+				}{
+					{"", 1, []string{"a", "d", "packages"}},
+					{"", 2, []string{"a", "a/b", "d", "packages", "packages/x.y", "packages/x"}},
+					{"", 0, []string{"a", "a/b", "a/b/c", "d", "packages", "packages/x.y", "packages/x"}},
+					{"packages", 2, []string{"packages/x.y", "packages/x"}},
+					{"a", 0, []string{"a/b", "a/b/c"}},
+					{"a/b", 3, []string{"a/b/c"}},
+					{"a", 2, []string{"a/b"}},
+					{"missing", 2, []string{}},
+					{"top.txt", 2, []string{}},
+				} {
+					if dirs, err := repo.ListDirectories(ctx, "HEAD", tt.prefix, tt.depth); err != nil || !reflect.DeepEqual(dirs, tt.want) {
+						t.Errorf("ListDirectories(%q, %d) = %v, %v, want %v", tt.prefix, tt.depth, dirs, err, tt.want)
+					}
+				}
+				if _, err := repo.ListDirectories(ctx, "missing", "", 0); err == nil {
+					t.Error("ListDirectories(missing) succeeded, want an error")
+				}
+			})
+
+			t.Run("renames of the same content", func(t *testing.T) {
+				dir := newConformanceRepo(t, map[string]string{"a.txt": "same\n", "b.txt": "same\n"})
+				gitRun(t, dir, "commit", "-q", "-m", "init")
+				gitRun(t, dir, "mv", "a.txt", "d.txt")
+				gitRun(t, dir, "mv", "b.txt", "z.txt")
+
+				// Paired in path order, every time
+				want := []FileChange{
+					{Status: "R", Path: "d.txt", OldPath: "a.txt"},
+					{Status: "R", Path: "z.txt", OldPath: "b.txt"},
+				}
+				for i := 0; i < 10; i++ {
+					if changes, err := newRepo(dir).GetStagedChanges(ctx); err != nil || !reflect.DeepEqual(changes, want) {
+						t.Fatalf("GetStagedChanges() = %+v, %v, want %+v", changes, err, want)
+					}
+				}
+			})
+
+			t.Run("mode change", func(t *testing.T) {
+				dir := newConformanceRepo(t, map[string]string{"run.sh": "echo hi\n"})
+				gitRun(t, dir, "commit", "-q", "-m", "init")
+				gitRun(t, dir, "update-index", "--chmod=+x", "run.sh")
+
+				repo := newRepo(dir)
+				if files, err := repo.GetStagedFiles(ctx); err != nil || !reflect.DeepEqual(files, []string{"run.sh"}) {
+					t.Errorf("GetStagedFiles() = %q, %v, want [run.sh]", files, err)
+				}
+				want := []FileChange{{Status: "M", Path: "run.sh"}}
+				if changes, err := repo.GetStagedChanges(ctx); err != nil || !reflect.DeepEqual(changes, want) {
+					t.Errorf("GetStagedChanges() = %+v, %v, want %+v", changes, err, want)
+				}
+				if text, err := repo.GetStagedDiff(ctx); err != nil || !strings.Contains(text, "old mode 100644\nnew mode 100755\n") {
+					t.Errorf("GetStagedDiff() = %q, %v, want the mode change", text, err)
+				}
+			})
+
+			t.Run("staged changes", func(t *testing.T) {
+				dir := newConformanceRepo(t, map[string]string{
+					"keep.txt":   "one\ntwo\nthree\n",
+					"old.txt":    "moved without changes\n",
+					"remove.txt": "a\nb\n",
+				})
+				gitRun(t, dir, "commit", "-q", "-m", "init")
+				writeFile(t, dir, "keep.txt", "one\n2\nthree\nfour\n")
+				writeFile(t, dir, "new.bin", "\x00\x01")
+				writeFile(t, dir, "new.txt", "x\ny")
+				gitRun(t, dir, "add", "keep.txt", "new.bin", "new.txt")
+				gitRun(t, dir, "mv", "old.txt", "moved.txt")
+				gitRun(t, dir, "rm", "-q", "remove.txt")
+
+				want := []FileChange{
+					{Status: "M", Path: "keep.txt", Added: 2, Removed: 1},
+					{Status: "R", Path: "moved.txt", OldPath: "old.txt"},
+					{Status: "A", Path: "new.bin", Binary: true},
+					{Status: "A", Path: "new.txt", Added: 2},
+					{Status: "D", Path: "remove.txt", Removed: 2},
+				}
+				if changes, err := newRepo(dir).GetStagedChanges(ctx); err != nil || !reflect.DeepEqual(changes, want) {
+					t.Errorf("GetStagedChanges() = %+v, %v, want %+v", changes, err, want)
+				}
+
+				// Hashes are abbreviated differently, so the parsed diffs are compared
+				text, err := newRepo(dir).GetStagedDiff(ctx)
+				if err != nil {
+					t.Fatalf("GetStagedDiff() error = %v", err)
+				}
+				files, err := diff.Parse(text)
+				if err != nil {
+					t.Fatalf("diff.Parse() error = %v\n%s", err, text)
+				}
+				var got []string
+				for _, f := range files {
+					got = append(got, fmt.Sprintf("%s -> %s binary=%v", f.OldPath, f.NewPath, f.Binary))
+					for _, h := range f.Hunks {
+						got = append(got, fmt.Sprintf("-%d,%d +%d,%d", h.OldStart, h.OldLines, h.NewStart, h.NewLines))
+						for _, l := range h.Lines {
+							got = append(got, fmt.Sprintf("%d %s", l.Kind, l.Text))
+						}
+					}
+				}
+				wantDiff := []string{
+					"keep.txt -> keep.txt binary=false",
+					"-1,3 +1,4",
+					"0 one", "2 two", "1 2", "0 three", "1 four",
+					"old.txt -> moved.txt binary=false",
+					" -> new.bin binary=true",
+					" -> new.txt binary=false",
+					"-0,0 +1,2",
+					"1 x", "1 y", "3  No newline at end of file",
+					"remove.txt ->  binary=false",
+					"-1,2 +0,0",
+					"2 a", "2 b",
+				}
+				if !reflect.DeepEqual(got, wantDiff) {
+					t.Errorf("GetStagedDiff() parsed = %q, want %q\n%s", got, wantDiff, text)
+				}
+			})
+
+			t.Run("status and staging", func(t *testing.T) {
+				dir := newConformanceRepo(t, map[string]string{
+					".gitignore":  "*.log\n",
+					"both.txt":    "one\n",
+					"change.txt":  "one\n",
+					"delete.txt":  "one\n",
+					"staged.txt":  "one\n",
+					"src/keep.go": "package src\n",
+				})
+				gitRun(t, dir, "commit", "-q", "-m", "init")
+				writeFile(t, dir, "both.txt", "two\n")
+				writeFile(t, dir, "staged.txt", "two\n")
+				gitRun(t, dir, "add", "both.txt", "staged.txt")
+				writeFile(t, dir, "both.txt", "three\n")
+				writeFile(t, dir, "change.txt", "two\n")
+				writeFile(t, dir, "debug.log", "ignored\n")
+				writeFile(t, dir, "src/new/file.go", "package new\n")
+				if err := os.Remove(filepath.Join(dir, "delete.txt")); err != nil {
+					t.Fatal(err)
+				}
+				repo := newRepo(dir)
+
+				want := []StatusEntry{
+					{Path: "both.txt", Staged: "M", Unstaged: "M"},
+					{Path: "change.txt", Unstaged: "M"},
+					{Path: "delete.txt", Unstaged: "D"},
+					{Path: "src/new/file.go", Unstaged: "?"},
+					{Path: "staged.txt", Staged: "M"},
+				}
+				if entries, err := repo.GetStatus(ctx); err != nil || !reflect.DeepEqual(entries, want) {
+					t.Errorf("GetStatus() = %+v, %v, want %+v", entries, err, want)
+				}
+
+				if err := repo.Stage(ctx, []string{"change.txt", "delete.txt", "src/new/file.go"}); err != nil {
+					t.Fatalf("Stage() error = %v", err)
+				}
+				if err := repo.Unstage(ctx, []string{"staged.txt"}); err != nil {
+					t.Fatalf("Unstage() error = %v", err)
+				}
+				if got, want := gitRun(t, dir, "diff", "--cached", "--name-status"),
+					"M\tboth.txt\nM\tchange.txt\nD\tdelete.txt\nA\tsrc/new/file.go"; got != want {
+					t.Errorf("Staged after Stage() and Unstage() = %q, want %q", got, want)
+				}
+				if got, want := gitRun(t, dir, "diff", "--name-only"), "both.txt\nstaged.txt"; got != want {
+					t.Errorf("Unstaged after Stage() and Unstage() = %q, want %q", got, want)
+				}
+			})
+
+			t.Run("unstage before the first commit", func(t *testing.T) {
+				dir := newConformanceRepo(t, map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+				if err := newRepo(dir).Unstage(ctx, []string{"a.txt"}); err != nil {
+					t.Fatalf("Unstage() error = %v", err)
+				}
+				if got, want := gitRun(t, dir, "ls-files"), "b.txt"; got != want {
+					t.Errorf("Index after Unstage() = %q, want %q", got, want)
+				}
+			})
+
+			t.Run("partial staging", func(t *testing.T) {
+				numbers := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n"
+				dir := newConformanceRepo(t, map[string]string{
+					"numbers.txt": numbers,
+					"other.txt":   "other\n",
+					"sub/x.txt":   "x\n",
+				})
+				gitRun(t, dir, "commit", "-q", "-m", "init")
+				writeFile(t, dir, "numbers.txt", strings.Replace(strings.Replace(numbers, "two", "2", 1), "nine", "9", 1))
+				writeFile(t, dir, "other.txt", "changed\n")
+				// Started in a subdirectory, files elsewhere are patched too
+				repo := newRepo(filepath.Join(dir, "sub"))
+
+				text, err := repo.GetUnstagedDiff(ctx)
+				if err != nil {
+					t.Fatalf("GetUnstagedDiff() error = %v", err)
+				}
+				files, err := diff.Parse(text)
+				if err != nil || len(files) != 2 || files[0].Path() != "numbers.txt" {
+					t.Fatalf("GetUnstagedDiff() parsed = %+v, %v\n%s", files, err, text)
+				}
+
+				// Stage only the first change
+				f := files[0]
+				for i := range f.Hunks {
+					first := i == 0
+					f.Hunks[i] = f.Hunks[i].Select(func(group int) bool { return first && group == 0 })
+				}
+				if err := repo.ApplyToIndex(ctx, diff.Format([]diff.File{f})); err != nil {
+					t.Fatalf("ApplyToIndex() error = %v", err)
+				}
+				if got, want := gitRun(t, dir, "show", ":numbers.txt")+"\n", strings.Replace(numbers, "two", "2", 1); got != want {
+					t.Errorf("Index after ApplyToIndex() = %q, want %q", got, want)
+				}
+				if got, want := gitRun(t, dir, "diff", "--name-only"), "numbers.txt\nother.txt"; got != want {
+					t.Errorf("Unstaged after ApplyToIndex() = %q, want %q", got, want)
+				}
+
+				if err := repo.ApplyToIndex(ctx, diff.Format([]diff.File{f})); err == nil {
+					t.Error("ApplyToIndex() applied the same patch twice")
+				}
+			})
+
+			t.Run("commit plan", func(t *testing.T) {
+				dir := newConformanceRepo(t, map[string]string{"a.txt": "a\n", "old.txt": "old\n"})
+				gitRun(t, dir, "commit", "-q", "-m", "init")
+				writeFile(t, dir, "a.txt", "changed\n")
+				writeFile(t, dir, "b.txt", "b\n")
+				writeFile(t, dir, "sub/c.txt", "c\n")
+				gitRun(t, dir, "add", ".")
+				gitRun(t, dir, "mv", "old.txt", "new.txt")
+				repo := newRepo(dir)
+
+				created, err := CommitPlan(ctx, repo, []PlannedCommit{
+					{Message: "feat: b and rename", Paths: []string{"b.txt", "new.txt", "old.txt"}},
+					{Message: "fix: a", Paths: []string{"a.txt"}},
+				}, CommitOptions{})
+				if err != nil || created != 2 {
+					t.Fatalf("CommitPlan() = %d, %v, want 2 commits", created, err)
+				}
+				if got, want := gitRun(t, dir, "log", "--format=%s", "-2"), "fix: a\nfeat: b and rename"; got != want {
+					t.Errorf("Log after CommitPlan() = %q, want %q", got, want)
+				}
+				if got, want := gitRun(t, dir, "show", "--name-only", "--no-renames", "--format=", "HEAD~1"), "b.txt\nnew.txt\nold.txt"; got != want {
+					t.Errorf("First planned commit = %q, want %q", got, want)
+				}
+				// Files in no commit stay staged
+				if got, want := gitRun(t, dir, "diff", "--cached", "--name-only"), "sub/c.txt"; got != want {
+					t.Errorf("Staged after CommitPlan() = %q, want %q", got, want)
+				}
+
+				// The second commit has nothing to commit; the rest stays staged
+				writeFile(t, dir, "a.txt", "again\n")
+				gitRun(t, dir, "add", "a.txt")
+				created, err = CommitPlan(ctx, repo, []PlannedCommit{
+					{Message: "fix: c", Paths: []string{"sub/c.txt"}},
+					{Message: "fix: nothing", Paths: []string{"missing.txt"}},
+				}, CommitOptions{})
+				if err == nil || created != 1 {
+					t.Fatalf("CommitPlan() = %d, %v, want 1 commit and an error", created, err)
+				}
+				if got, want := gitRun(t, dir, "diff", "--cached", "--name-only"), "a.txt"; got != want {
+					t.Errorf("Staged after failed CommitPlan() = %q, want %q", got, want)
+				}
+				if got, want := gitRun(t, dir, "status", "--porcelain"), "M  a.txt"; got != want {
+					t.Errorf("Status after failed CommitPlan() = %q, want %q", got, want)
+				}
+			})
+
+			t.Run("config", func(t *testing.T) {
+				dir := newConformanceRepo(t, nil)
+				gitRun(t, dir, "config", "cz.useEmoji", "false")
+				gitRun(t, dir, "config", "cz.type.Feat.emoji", "x")
+				gitRun(t, dir, "config", "--global", "cz.maxSubjectLength", "72")
+				gitRun(t, dir, "config", "cz.maxSubjectLength", "50")
+				repo := newRepo(dir)
+
+				entries, err := repo.GetConfigEntries(ctx, `^cz\.`)
+				if err != nil {
+					t.Fatalf("GetConfigEntries() error = %v", err)
+				}
+				// Global config comes before the repository config, keys are
+				// lowercased except for subsections and sections come in file order
+				want := []ConfigEntry{
+					{Key: "cz.maxsubjectlength", Value: "72"},
+					{Key: "cz.useemoji", Value: "false"},
+					{Key: "cz.maxsubjectlength", Value: "50"},
+					{Key: "cz.type.Feat.emoji", Value: "x"},
+				}
+				if !reflect.DeepEqual(entries, want) {
+					t.Errorf("GetConfigEntries() = %v, want %v", entries, want)
+				}
+				if entries, err := repo.GetConfigEntries(ctx, `^nothing\.`); err != nil || len(entries) != 0 {
+					t.Errorf("GetConfigEntries(nothing) = %v, %v, want none", entries, err)
+				}
+			})
+
+			t.Run("submodule", func(t *testing.T) {
+				sub := newConformanceRepo(t, map[string]string{"sub.txt": "sub\n"})
+				gitRun(t, sub, "commit", "-q", "-m", "init")
+				super := newConformanceRepo(t, nil)
+				gitRun(t, super, "-c", "protocol.file.allow=always", "submodule", "add", "-q", sub, "sub")
+
+				if superproject, err := newRepo(filepath.Join(super, "sub")).GetSuperproject(ctx); err != nil || superproject != super {
+					t.Errorf("GetSuperproject() = %q, %v, want %q", superproject, err, super)
+				}
+			})
+
+			t.Run("cancelled", func(t *testing.T) {
+				dir := newConformanceRepo(t, map[string]string{"a.txt": "a\n"})
+				cancelled, cancel := context.WithCancel(ctx)
+				cancel()
+
+				if err := newRepo(dir).Commit(cancelled, "feat: a", CommitOptions{}); err == nil {
+					t.Error("Commit() with a cancelled context succeeded, want an error")
+				}
+				if gitRun(t, dir, "status", "--porcelain") != "A  a.txt" {
+					t.Error("Commit() with a cancelled context committed")
+				}
+			})
+		})
+	}
+}
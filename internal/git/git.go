@@ -1,45 +1,201 @@
 package git
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
-// IsGitRepository checks if the current directory is a git repository
-func IsGitRepository() bool {
-	cmd := exec.Command("git", "rev-parse", "--is-inside-work-tree")
-	err := cmd.Run()
+const (
+	// DefaultTimeout is the default timeout for git operations other than commit
+	DefaultTimeout = 10 * time.Second
+	// DefaultCommitTimeout is the default timeout for git commit, which runs hooks
+	DefaultCommitTimeout = 5 * time.Minute
+
+	// waitDelay is how long to wait for output after git is killed,
+	// in case hooks started by git still hold its output open
+	waitDelay = time.Second
+)
+
+// ExecRepository is a Repository that runs the git binary.
+// git inherits the environment, so GIT_DIR and GIT_WORK_TREE are honoured;
+// relative paths in them are resolved from Dir, like with `git -C`.
+type ExecRepository struct {
+	// Dir is the directory git runs in, like `git -C`; empty means the current directory
+	Dir string
+	// Timeout limits each git operation other than Commit; zero means no limit
+	Timeout time.Duration
+	// CommitTimeout limits Commit; zero means no limit
+	CommitTimeout time.Duration
+}
+
+// NewExecRepository creates a repository that runs git in the given directory
+// with the default timeouts. An empty dir means the current directory.
+func NewExecRepository(dir string) *ExecRepository {
+	return &ExecRepository{
+		Dir:           dir,
+		Timeout:       DefaultTimeout,
+		CommitTimeout: DefaultCommitTimeout,
+	}
+}
+
+// withTimeout returns a context limited by the given timeout, if any
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// command creates a git command that runs in the repository directory and is
+// killed when the context is done
+func (r *ExecRepository) command(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.Dir
+	cmd.WaitDelay = waitDelay
+	return cmd
+}
+
+// output runs git with the repository timeout and returns its standard output
+func (r *ExecRepository) output(ctx context.Context, args ...string) ([]byte, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	output, err := r.command(ctx, args...).Output()
+	return output, wrapError(ctx, args, err)
+}
+
+// stream runs git with the repository timeout and calls fn for each
+// NUL-terminated record of its standard output as it is read
+func (r *ExecRepository) stream(ctx context.Context, fn func(record string), args ...string) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	cmd := r.command(ctx, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return wrapError(ctx, args, err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Split(scanNull)
+	for scanner.Scan() {
+		fn(scanner.Text())
+	}
+	scanErr := scanner.Err()
+	if err := cmd.Wait(); err != nil {
+		return wrapError(ctx, args, err)
+	}
+	return scanErr
+}
+
+// scanNull is a bufio.SplitFunc for NUL-terminated records
+func scanNull(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// wrapError reports a git failure caused by the context as ErrTimeout or as
+// the cancellation, so that callers can tell them apart from git errors
+func wrapError(ctx context.Context, args []string, err error) error {
+	if err == nil {
+		return nil
+	}
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return fmt.Errorf("git %s: %w", args[0], ErrTimeout)
+	case context.Canceled:
+		return fmt.Errorf("git %s: %w", args[0], context.Canceled)
+	}
+	return err
+}
+
+// IsGitRepository checks if the directory is inside a git working tree
+func (r *ExecRepository) IsGitRepository(ctx context.Context) bool {
+	_, err := r.output(ctx, "rev-parse", "--is-inside-work-tree")
 	return err == nil
 }
 
 // GetGitRootDir returns the git repository root directory
-func GetGitRootDir() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	output, err := cmd.Output()
+func (r *ExecRepository) GetGitRootDir(ctx context.Context) (string, error) {
+	output, err := r.output(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetGitCommonDir returns the absolute path of the git directory shared by all worktrees
+func (r *ExecRepository) GetGitCommonDir(ctx context.Context) (string, error) {
+	output, err := r.output(ctx, "rev-parse", "--path-format=absolute", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetSuperproject returns the root of the superproject if the repository is a submodule
+func (r *ExecRepository) GetSuperproject(ctx context.Context) (string, error) {
+	output, err := r.output(ctx, "rev-parse", "--show-superproject-working-tree")
 	if err != nil {
 		return "", err
 	}
 	return strings.TrimSpace(string(output)), nil
 }
 
+// ResolveCommit returns the commit id the given revision points to
+func (r *ExecRepository) ResolveCommit(ctx context.Context, rev string) (string, error) {
+	output, err := r.output(ctx, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		if errors.Is(err, ErrTimeout) || errors.Is(err, context.Canceled) {
+			return "", err
+		}
+		return "", fmt.Errorf("unknown revision %q", rev)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ShowFile returns the content of a file at the given revision.
+// The path is relative to the repository root.
+func (r *ExecRepository) ShowFile(ctx context.Context, rev, path string) ([]byte, error) {
+	return r.output(ctx, "show", rev+":"+path)
+}
+
+// ShowStagedFile returns the content of a file in the index.
+// The path is relative to the repository root.
+func (r *ExecRepository) ShowStagedFile(ctx context.Context, path string) ([]byte, error) {
+	return r.output(ctx, "show", ":"+path)
+}
+
 // GetBranches returns a list of git branches
-func GetBranches() ([]string, error) {
-	cmd := exec.Command("git", "branch", "--format", "%(refname:short)")
-	output, err := cmd.Output()
+func (r *ExecRepository) GetBranches(ctx context.Context) ([]string, error) {
+	output, err := r.output(ctx, "branch", "--format", "%(refname:short)")
 	if err != nil {
 		return nil, err
 	}
 
-	branches := strings.Split(strings.TrimSpace(string(output)), "\n")
-	return branches, nil
+	return splitLines(string(output)), nil
 }
 
 // GetCurrentBranch returns the current git branch
-func GetCurrentBranch() (string, error) {
-	cmd := exec.Command("git", "branch", "--show-current")
-	output, err := cmd.Output()
+func (r *ExecRepository) GetCurrentBranch(ctx context.Context) (string, error) {
+	output, err := r.output(ctx, "branch", "--show-current")
 	if err != nil {
 		return "", err
 	}
@@ -47,82 +203,349 @@ func GetCurrentBranch() (string, error) {
 }
 
 // GetStagedFiles returns a list of staged files
-func GetStagedFiles() ([]string, error) {
-	cmd := exec.Command("git", "diff", "--name-only", "--cached")
-	output, err := cmd.Output()
+func (r *ExecRepository) GetStagedFiles(ctx context.Context) ([]string, error) {
+	output, err := r.output(ctx, "diff", "--name-only", "--cached")
 	if err != nil {
 		return nil, err
 	}
 
-	files := strings.Split(strings.TrimSpace(string(output)), "\n")
-	if files[0] == "" && len(files) == 1 {
-		return []string{}, nil
+	return splitLines(string(output)), nil
+}
+
+// GetStagedChanges returns the staged changes, detecting renames like git status
+func (r *ExecRepository) GetStagedChanges(ctx context.Context) ([]FileChange, error) {
+	output, err := r.output(ctx, "diff", "--cached", "--name-status", "-z", "-M")
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []FileChange{}
+	index := make(map[string]int)
+	for fields := splitNull(string(output)); len(fields) >= 2; {
+		change := FileChange{Status: fields[0][:1], Path: fields[1]}
+		fields = fields[2:]
+		if (change.Status == "R" || change.Status == "C") && len(fields) > 0 {
+			change.OldPath, change.Path = change.Path, fields[0]
+			fields = fields[1:]
+		}
+		index[change.Path] = len(changes)
+		changes = append(changes, change)
+	}
+
+	output, err = r.output(ctx, "diff", "--cached", "--numstat", "-z", "-M")
+	if err != nil {
+		return nil, err
 	}
-	return files, nil
+	// Each record is "added\tremoved\tpath", or "added\tremoved\t" followed
+	// by the old and new path for renames; binary files have "-" as counts
+	for fields := splitNull(string(output)); len(fields) > 0; {
+		parts := strings.SplitN(fields[0], "\t", 3)
+		fields = fields[1:]
+		if len(parts) != 3 {
+			continue
+		}
+		path := parts[2]
+		if path == "" && len(fields) >= 2 {
+			path = fields[1]
+			fields = fields[2:]
+		}
+		i, ok := index[path]
+		if !ok {
+			continue
+		}
+		if parts[0] == "-" {
+			changes[i].Binary = true
+			continue
+		}
+		changes[i].Added, _ = strconv.Atoi(parts[0])
+		changes[i].Removed, _ = strconv.Atoi(parts[1])
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
 }
 
-// Commit commits changes with the given message
-func Commit(message string) error {
-	cmd := exec.Command("git", "commit", "-m", message)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+// GetStagedDiff returns the staged changes as a unified diff, ignoring
+// color and external diff settings
+func (r *ExecRepository) GetStagedDiff(ctx context.Context) (string, error) {
+	output, err := r.output(ctx, "diff", "--cached", "--no-color", "--no-ext-diff", "-M")
+	return string(output), err
 }
 
-// DetectScopes tries to detect scopes from the repository structure
-func DetectScopes() ([]string, error) {
-	rootDir, err := GetGitRootDir()
+// GetStatus returns the changed and untracked files from `git status`,
+// without taking the index lock that refreshing it would need
+func (r *ExecRepository) GetStatus(ctx context.Context) ([]StatusEntry, error) {
+	output, err := r.output(ctx, "--no-optional-locks", "status", "--porcelain=v2", "-z", "--untracked-files=all")
 	if err != nil {
 		return nil, err
 	}
 
-	var scopes []string
+	// Changed entries are "1 XY sub mH mI mW hH hI path", renames and copies
+	// "2 XY sub mH mI mW hH hI Xscore path" followed by the old path, and
+	// unmerged entries "u XY sub m1 m2 m3 mW h1 h2 h3 path"
+	entries := []StatusEntry{}
+	for records := splitNull(string(output)); len(records) > 0; {
+		record := records[0]
+		records = records[1:]
 
-	// Add common directories as scopes
-	for _, dir := range []string{"cmd", "pkg", "internal", "api", "ui", "docs"} {
-		path := fmt.Sprintf("%s/%s", rootDir, dir)
-		if _, err := os.Stat(path); err == nil {
-			// Check if the directory has files tracked by git
-			cmd := exec.Command("git", "ls-files", path)
-			output, err := cmd.Output()
-			if err == nil && len(output) > 0 {
-				scopes = append(scopes, dir)
+		var entry StatusEntry
+		switch {
+		case strings.HasPrefix(record, "? "):
+			entry = StatusEntry{Path: record[2:], Unstaged: "?"}
+		case strings.HasPrefix(record, "1 "), strings.HasPrefix(record, "2 "), strings.HasPrefix(record, "u "):
+			fields := 9
+			switch record[0] {
+			case '2':
+				fields = 10
+			case 'u':
+				fields = 11
+			}
+			parts := strings.SplitN(record, " ", fields)
+			if len(parts) != fields || len(parts[1]) != 2 {
+				continue
+			}
+			entry = StatusEntry{
+				Path:     parts[fields-1],
+				Staged:   statusLetter(parts[1][0]),
+				Unstaged: statusLetter(parts[1][1]),
+			}
+			if record[0] == '2' && len(records) > 0 {
+				entry.OldPath = records[0]
+				records = records[1:]
 			}
+		default:
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+// statusLetter converts a porcelain v2 status letter, where "." means unchanged
+func statusLetter(letter byte) string {
+	if letter == '.' {
+		return ""
+	}
+	return string(letter)
+}
+
+// Stage adds the files to the index with `git add`
+func (r *ExecRepository) Stage(ctx context.Context, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	_, err := r.output(ctx, append([]string{"add", "--"}, rootPathspecs(paths)...)...)
+	return err
+}
+
+// Unstage resets the files in the index with `git reset`, which also works
+// before the first commit
+func (r *ExecRepository) Unstage(ctx context.Context, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	_, err := r.output(ctx, append([]string{"reset", "-q", "--"}, rootPathspecs(paths)...)...)
+	return err
+}
+
+// rootPathspecs turns paths relative to the root directory into pathspecs
+// that match exactly those paths, wherever git runs
+func rootPathspecs(paths []string) []string {
+	specs := make([]string, len(paths))
+	for i, path := range paths {
+		specs[i] = ":(top,literal)" + path
+	}
+	return specs
+}
+
+// GetUnstagedDiff returns the unstaged changes as a unified diff, ignoring
+// color, external diff and prefix settings
+func (r *ExecRepository) GetUnstagedDiff(ctx context.Context) (string, error) {
+	output, err := r.output(ctx, "diff", "--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/")
+	return string(output), err
+}
+
+// ApplyToIndex applies the patch with `git apply --cached`, reporting its
+// error message if the patch does not apply
+func (r *ExecRepository) ApplyToIndex(ctx context.Context, patch string) error {
+	// git apply ignores the files outside of the directory it runs in
+	output, err := r.output(ctx, "rev-parse", "--show-toplevel", "--absolute-git-dir")
+	if err != nil {
+		return err
+	}
+	dirs := splitLines(string(output))
+	if len(dirs) != 2 {
+		return fmt.Errorf("unexpected output of git rev-parse: %q", output)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	args := []string{"apply", "--cached", "-"}
+	cmd := r.command(ctx, args...)
+	cmd.Dir = dirs[0]
+	cmd.Env = append(os.Environ(), "GIT_WORK_TREE="+dirs[0], "GIT_DIR="+dirs[1])
+	cmd.Stdin = strings.NewReader(patch)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return wrapError(ctx, args, err)
+		}
+		return fmt.Errorf("git apply: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// WriteIndexTree writes the index as a tree with `git write-tree`
+func (r *ExecRepository) WriteIndexTree(ctx context.Context) (string, error) {
+	output, err := r.output(ctx, "write-tree")
+	return strings.TrimSpace(string(output)), err
+}
+
+// ReadIndexTree reads the tree into the index with `git read-tree --reset`,
+// which keeps the file stats of unchanged entries so that git does not rehash
+// them. Unlike -m, it does not require the other entries to match the working tree.
+func (r *ExecRepository) ReadIndexTree(ctx context.Context, tree string) error {
+	_, err := r.output(ctx, "read-tree", "--reset", tree)
+	return err
+}
+
+// ListFiles returns all tracked files, relative to the repository root
+func (r *ExecRepository) ListFiles(ctx context.Context) ([]string, error) {
+	output, err := r.output(ctx, "ls-files", "--full-name", "-z", ":/")
+	if err != nil {
+		return nil, err
+	}
+
+	return splitNull(string(output)), nil
+}
+
+// ResolveTree returns the id of the tree the given revision points to
+func (r *ExecRepository) ResolveTree(ctx context.Context, rev string) (string, error) {
+	output, err := r.output(ctx, "rev-parse", "--verify", "--quiet", rev+"^{tree}")
+	if err != nil {
+		if errors.Is(err, ErrTimeout) || errors.Is(err, context.Canceled) {
+			return "", err
 		}
+		return "", fmt.Errorf("unknown revision %q", rev)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ListDirectories returns the directories in the tree of the given revision.
+// The output of ls-tree is streamed so that deeper directories are never held in
+// memory, and only the trees below prefix are read.
+func (r *ExecRepository) ListDirectories(ctx context.Context, rev, prefix string, maxDepth int) ([]string, error) {
+	args := []string{"ls-tree", "-d", "-z", "--name-only", "--full-tree"}
+	if maxDepth != pathDepth(prefix)+1 {
+		args = append(args, "-r")
+	}
+	args = append(args, rev)
+	if prefix != "" {
+		args = append(args, "--", prefix+"/")
 	}
 
-	// Get all git-tracked directories in the root
-	cmd := exec.Command("git", "ls-files", "--directory", "--full-name", rootDir)
-	output, err := cmd.Output()
+	dirs := []string{}
+	err := r.stream(ctx, func(dir string) {
+		// With -r, ls-tree lists the directories leading to the prefix too
+		if prefix != "" && !strings.HasPrefix(dir, prefix+"/") {
+			return
+		}
+		if maxDepth <= 0 || strings.Count(dir, "/") < maxDepth {
+			dirs = append(dirs, dir)
+		}
+	}, args...)
 	if err != nil {
-		return scopes, nil // Return what we have so far
+		return nil, err
+	}
+	return dirs, nil
+}
+
+// Commit commits changes with the given message, limited by CommitTimeout.
+// The message is passed on standard input so that it is cleaned up exactly
+// like a message from a file, using the cleanup mode from opts.
+func (r *ExecRepository) Commit(ctx context.Context, message string, opts CommitOptions) error {
+	if err := ValidateCommitArgs(opts.Args); err != nil {
+		return err
 	}
 
-	files := strings.Split(strings.TrimSpace(string(output)), "\n")
-	scopeMap := make(map[string]bool)
+	ctx, cancel := withTimeout(ctx, r.CommitTimeout)
+	defer cancel()
+
+	cleanup := opts.Cleanup
+	if cleanup == "" {
+		cleanup = CleanupWhitespace
+	}
+	args := append([]string{"commit", "-F", "-", "--cleanup=" + cleanup}, opts.Args...)
+	cmd := r.command(ctx, args...)
+	cmd.Stdin = strings.NewReader(message)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return wrapError(ctx, args, cmd.Run())
+}
+
+// logRecordSeparator separates the commits in the output of GetLog
+const logRecordSeparator = "\x1e"
+
+// GetLog returns up to max commits reachable from HEAD, newest first
+func (r *ExecRepository) GetLog(ctx context.Context, max int) ([]LogEntry, error) {
+	output, err := r.output(ctx, "log", fmt.Sprintf("--max-count=%d", max), "--format=%H%n%B%x1e")
+	if err != nil {
+		return nil, err
+	}
 
-	for _, file := range files {
-		parts := strings.Split(file, "/")
-		if len(parts) > 0 {
-			scopeMap[parts[0]] = true
+	var entries []LogEntry
+	for _, record := range strings.Split(string(output), logRecordSeparator) {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
 		}
+		hash, message, _ := strings.Cut(record, "\n")
+		entries = append(entries, LogEntry{Hash: hash, Message: strings.TrimSpace(message)})
 	}
+	return entries, nil
+}
 
-	for scope := range scopeMap {
-		if !contains(scopes, scope) {
-			scopes = append(scopes, scope)
+// GetConfigEntries returns the git config entries whose key matches the given regular expression.
+// Entries are returned in the order git reports them, so later entries take precedence.
+func (r *ExecRepository) GetConfigEntries(ctx context.Context, pattern string) ([]ConfigEntry, error) {
+	output, err := r.output(ctx, "config", "-z", "--get-regexp", pattern)
+	if err != nil {
+		// Exit code 1 means that no matching key was found
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return []ConfigEntry{}, nil
 		}
+		return nil, err
 	}
 
-	return scopes, nil
+	var entries []ConfigEntry
+	for _, record := range splitNull(string(output)) {
+		// Each record is "key\nvalue"; keys without a value have no newline
+		key, value, _ := strings.Cut(record, "\n")
+		entries = append(entries, ConfigEntry{Key: key, Value: value})
+	}
+	return entries, nil
+}
+
+// splitLines splits command output into lines, returning an empty slice for no output
+func splitLines(output string) []string {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return []string{}
+	}
+	return strings.Split(output, "\n")
 }
 
-// Helper function to check if a slice contains a string
-func contains(slice []string, item string) bool {
-	for _, s := range slice {
-		if s == item {
-			return true
+// splitNull splits NUL-terminated command output, returning an empty slice for no output
+func splitNull(output string) []string {
+	items := []string{}
+	for _, item := range strings.Split(output, "\x00") {
+		if item != "" {
+			items = append(items, item)
 		}
 	}
-	return false
+	return items
 }
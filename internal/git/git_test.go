@@ -1,10 +1,15 @@
 package git
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 )
 
 // setupGitRepo creates a temporary Git repository for testing
@@ -59,8 +64,10 @@ func TestIsGitRepository(t *testing.T) {
 		t.Fatalf("Failed to change to repository directory: %v", err)
 	}
 
+	repo := NewExecRepository("")
+
 	// Test IsGitRepository
-	if !IsGitRepository() {
+	if !repo.IsGitRepository(context.Background()) {
 		t.Error("IsGitRepository() = false, want true")
 	}
 
@@ -75,7 +82,7 @@ func TestIsGitRepository(t *testing.T) {
 		t.Fatalf("Failed to change to non-git directory: %v", err)
 	}
 
-	if IsGitRepository() {
+	if repo.IsGitRepository(context.Background()) {
 		t.Error("IsGitRepository() = true, want false")
 	}
 }
@@ -108,7 +115,7 @@ func TestGetGitRootDir(t *testing.T) {
 	}
 
 	// Test GetGitRootDir
-	rootDir, err := GetGitRootDir()
+	rootDir, err := NewExecRepository("").GetGitRootDir(context.Background())
 	if err != nil {
 		t.Fatalf("GetGitRootDir() failed: %v", err)
 	}
@@ -164,7 +171,7 @@ func TestCommit(t *testing.T) {
 
 	// Commit the file
 	message := "test: add test file"
-	if err := Commit(message); err != nil {
+	if err := NewExecRepository("").Commit(context.Background(), message, CommitOptions{}); err != nil {
 		t.Fatalf("Commit() failed: %v", err)
 	}
 
@@ -181,3 +188,292 @@ func TestCommit(t *testing.T) {
 		t.Errorf("Commit message = %q, want %q", commitMessage, message)
 	}
 }
+
+func TestGetStagedFilesAndLog(t *testing.T) {
+	// Create a temporary Git repository
+	tempDir := setupGitRepo(t)
+	defer os.RemoveAll(tempDir)
+
+	repo := NewExecRepository(tempDir)
+
+	// Nothing is staged in a new repository
+	files, err := repo.GetStagedFiles(context.Background())
+	if err != nil {
+		t.Fatalf("GetStagedFiles() failed: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("GetStagedFiles() = %v, want no files", files)
+	}
+
+	// Stage a file in a subdirectory
+	if err := os.MkdirAll(filepath.Join(tempDir, "docs"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "docs", "index.md"), []byte("# Docs\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	cmd := exec.Command("git", "add", "docs/index.md")
+	cmd.Dir = tempDir
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Failed to stage file: %v", err)
+	}
+
+	files, err = repo.GetStagedFiles(context.Background())
+	if err != nil {
+		t.Fatalf("GetStagedFiles() failed: %v", err)
+	}
+	if len(files) != 1 || files[0] != "docs/index.md" {
+		t.Errorf("GetStagedFiles() = %v, want [docs/index.md]", files)
+	}
+
+	cmd = exec.Command("git", "commit", "-q", "-m", "docs: add index\n\nWith a body.")
+	cmd.Dir = tempDir
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	entries, err := repo.GetLog(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("GetLog() failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("GetLog() returned %d entries, want 1", len(entries))
+	}
+	if entries[0].Subject() != "docs: add index" || entries[0].Message != "docs: add index\n\nWith a body." {
+		t.Errorf("GetLog() message = %q", entries[0].Message)
+	}
+	if len(entries[0].Hash) != 40 {
+		t.Errorf("GetLog() hash = %q, want a full commit id", entries[0].Hash)
+	}
+}
+
+func TestDetectScopes(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository("/repo")
+	repo.CommonDir = t.TempDir()
+	for _, path := range []string{"README.md", "web/app.ts", "cmd/main.go", "internal/git/git.go", "internal/ui/ui.go", "packages/b/b.go", "packages/a/x/a.go"} {
+		repo.Files[path] = nil
+	}
+
+	// Before the first commit the tracked files are used
+	scopes, err := DetectScopes(ctx, repo, nil)
+	if err != nil {
+		t.Fatalf("DetectScopes() failed: %v", err)
+	}
+	// Common directories first, then the others sorted; files are not scopes
+	want := []string{"cmd", "internal", "packages", "web"}
+	if !reflect.DeepEqual(scopes, want) {
+		t.Errorf("DetectScopes() = %v, want %v", scopes, want)
+	}
+
+	repo.Commits = []LogEntry{{Hash: "c1", Message: "init"}}
+	patterns := []string{"packages/*", "internal/*"}
+	want = []string{"internal/git", "internal/ui", "packages/a", "packages/b"}
+	if scopes, err := DetectScopes(ctx, repo, patterns); err != nil || !reflect.DeepEqual(scopes, want) {
+		t.Errorf("DetectScopes(%v) = %v, %v, want %v", patterns, scopes, err, want)
+	}
+
+	// The result is cached by tree, so a changed tree is listed again
+	cached, _ := filepath.Glob(filepath.Join(repo.CommonDir, "git-cz", "scopes", "*.json"))
+	if len(cached) != 1 {
+		t.Errorf("cache files = %v, want one", cached)
+	}
+	repo.Files["packages/c/c.go"] = nil
+	want = append(want, "packages/c")
+	if scopes, err := DetectScopes(ctx, repo, patterns); err != nil || !reflect.DeepEqual(scopes, want) {
+		t.Errorf("DetectScopes(%v) after a commit = %v, %v, want %v", patterns, scopes, err, want)
+	}
+
+	// Only the last trees are kept in the cache
+	for i := 0; i < scopeCacheSize+5; i++ {
+		repo.Files[fmt.Sprintf("packages/p%d/p.go", i)] = nil
+		if _, err := DetectScopes(ctx, repo, patterns); err != nil {
+			t.Fatalf("DetectScopes() failed: %v", err)
+		}
+	}
+	if cached, _ := filepath.Glob(filepath.Join(repo.CommonDir, "git-cz", "scopes", "*.json")); len(cached) != scopeCacheSize {
+		t.Errorf("cache files = %d, want %d", len(cached), scopeCacheSize)
+	}
+}
+
+func TestPatternPrefix(t *testing.T) {
+	for pattern, want := range map[string]string{
+		"*":             "",
+		"packages/*":    "packages",
+		"apps/web":      "apps",
+		"src/*/modules": "src",
+		"a/b/*":         "a/b",
+	} {
+		if got := patternPrefix(pattern); got != want {
+			t.Errorf("patternPrefix(%q) = %q, want %q", pattern, got, want)
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	// Create a temporary Git repository
+	tempDir := setupGitRepo(t)
+	defer os.RemoveAll(tempDir)
+
+	repo := NewExecRepository(tempDir)
+	repo.Timeout = time.Nanosecond
+
+	_, err := repo.GetGitRootDir(context.Background())
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("GetGitRootDir() error = %v, want ErrTimeout", err)
+	}
+
+	// Cancellation is reported as such, not as a timeout
+	repo.Timeout = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = repo.GetGitRootDir(ctx)
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ErrTimeout) {
+		t.Errorf("GetGitRootDir() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestCleanupSettingsClean(t *testing.T) {
+	message := "feat: add thing  \n\n\n#123 is fixed\n; not a comment\n\n"
+
+	testCases := []struct {
+		name     string
+		settings CleanupSettings
+		expected string
+	}{
+		{
+			name:     "whitespace",
+			settings: CleanupSettings{Mode: CleanupWhitespace, CommentChar: "#"},
+			expected: "feat: add thing\n\n#123 is fixed\n; not a comment",
+		},
+		{
+			name:     "strip",
+			settings: CleanupSettings{Mode: CleanupStrip, CommentChar: "#"},
+			expected: "feat: add thing\n\n; not a comment",
+		},
+		{
+			name:     "strip with auto comment char",
+			settings: CleanupSettings{Mode: CleanupStrip, CommentChar: "auto"},
+			expected: "feat: add thing\n\n#123 is fixed\n; not a comment",
+		},
+		{
+			name:     "verbatim",
+			settings: CleanupSettings{Mode: CleanupVerbatim, CommentChar: "#"},
+			expected: "feat: add thing  \n\n\n#123 is fixed\n; not a comment\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := tc.settings.Clean(message); result != tc.expected {
+				t.Errorf("Clean() = %q, want %q", result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCommitCleanupAndArgs(t *testing.T) {
+	// Create a temporary Git repository
+	tempDir := setupGitRepo(t)
+	defer os.RemoveAll(tempDir)
+
+	ctx := context.Background()
+	repo := NewExecRepository(tempDir)
+
+	cmd := exec.Command("git", "config", "commit.cleanup", "strip")
+	cmd.Dir = tempDir
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Failed to configure commit.cleanup: %v", err)
+	}
+
+	settings, err := GetCleanupSettings(ctx, repo)
+	if err != nil {
+		t.Fatalf("GetCleanupSettings() failed: %v", err)
+	}
+	if settings.Mode != CleanupStrip || settings.CommentChar != "#" {
+		t.Errorf("GetCleanupSettings() = %+v, want strip with #", settings)
+	}
+
+	message := "chore: empty commit\n\n# removed by strip\nkept"
+	opts := CommitOptions{Cleanup: settings.Mode, Args: []string{"--allow-empty", "--signoff"}}
+	if err := repo.Commit(ctx, message, opts); err != nil {
+		t.Fatalf("Commit() failed: %v", err)
+	}
+
+	entries, err := repo.GetLog(ctx, 1)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("GetLog() = %v, %v", entries, err)
+	}
+	want := "chore: empty commit\n\nkept\n\nSigned-off-by: Test User <test@example.com>"
+	if entries[0].Message != want {
+		t.Errorf("Commit message = %q, want %q", entries[0].Message, want)
+	}
+
+	// Flags that would interfere with the message are rejected
+	if err := repo.Commit(ctx, message, CommitOptions{Args: []string{"-m", "other"}}); err == nil {
+		t.Error("Commit() with -m should fail")
+	}
+}
+
+func TestRepositoryLocation(t *testing.T) {
+	ctx := context.Background()
+
+	// Create a repository with a commit to use as a submodule
+	subDir := setupGitRepo(t)
+	defer os.RemoveAll(subDir)
+	if err := NewExecRepository(subDir).Commit(ctx, "chore: initial commit", CommitOptions{Args: []string{"--allow-empty"}}); err != nil {
+		t.Fatalf("Commit() failed: %v", err)
+	}
+
+	superDir := setupGitRepo(t)
+	defer os.RemoveAll(superDir)
+
+	// A repository outside the current directory is used like with git -C
+	root, err := NewExecRepository(superDir).GetGitRootDir(ctx)
+	if err != nil {
+		t.Fatalf("GetGitRootDir() failed: %v", err)
+	}
+	expectedRoot, _ := filepath.EvalSymlinks(superDir)
+	if actualRoot, _ := filepath.EvalSymlinks(root); actualRoot != expectedRoot {
+		t.Errorf("GetGitRootDir() = %q, want %q", actualRoot, expectedRoot)
+	}
+
+	superproject, err := NewExecRepository(superDir).GetSuperproject(ctx)
+	if err != nil || superproject != "" {
+		t.Errorf("GetSuperproject() = %q, %v, want no superproject", superproject, err)
+	}
+
+	cmd := exec.Command("git", "-c", "protocol.file.allow=always", "submodule", "add", "-q", subDir, "sub")
+	cmd.Dir = superDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to add submodule: %v\n%s", err, output)
+	}
+
+	superproject, err = NewExecRepository(filepath.Join(superDir, "sub")).GetSuperproject(ctx)
+	if err != nil {
+		t.Fatalf("GetSuperproject() failed: %v", err)
+	}
+	if actual, _ := filepath.EvalSymlinks(superproject); actual != expectedRoot {
+		t.Errorf("GetSuperproject() = %q, want %q", actual, expectedRoot)
+	}
+
+	// GIT_DIR and GIT_WORK_TREE are honoured
+	workTree, err := os.MkdirTemp("", "git-cz-go-worktree")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(workTree)
+
+	t.Setenv("GIT_DIR", filepath.Join(superDir, ".git"))
+	t.Setenv("GIT_WORK_TREE", workTree)
+
+	root, err = NewExecRepository(workTree).GetGitRootDir(ctx)
+	if err != nil {
+		t.Fatalf("GetGitRootDir() with GIT_DIR failed: %v", err)
+	}
+	expectedWorkTree, _ := filepath.EvalSymlinks(workTree)
+	if actual, _ := filepath.EvalSymlinks(root); actual != expectedWorkTree {
+		t.Errorf("GetGitRootDir() with GIT_WORK_TREE = %q, want %q", actual, expectedWorkTree)
+	}
+}
@@ -0,0 +1,1055 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/a1yama/git-cz-go/internal/diff"
+	gogit "github.com/go-git/go-git/v5"
+	gogitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	formatconfig "github.com/go-git/go-git/v5/plumbing/format/config"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// GoGitRepository is a Repository implemented in pure Go with go-git, for
+// environments without a git binary.
+//
+// Compared to ExecRepository it does not run hooks, cannot sign commits,
+// ignores GIT_DIR/GIT_WORK_TREE and does not support include/includeIf in git config.
+type GoGitRepository struct {
+	// Dir is the directory to open the repository from; empty means the current directory
+	Dir string
+}
+
+// NewGoGitRepository creates a pure Go repository for the given directory.
+// An empty dir means the current directory.
+func NewGoGitRepository(dir string) *GoGitRepository {
+	return &GoGitRepository{Dir: dir}
+}
+
+// open opens the repository containing Dir
+func (r *GoGitRepository) open(ctx context.Context) (*gogit.Repository, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	dir := r.Dir
+	if dir == "" {
+		dir = "."
+	}
+	return gogit.PlainOpenWithOptions(dir, &gogit.PlainOpenOptions{
+		DetectDotGit:          true,
+		EnableDotGitCommonDir: true,
+	})
+}
+
+// IsGitRepository checks if the directory is inside a git working tree
+func (r *GoGitRepository) IsGitRepository(ctx context.Context) bool {
+	repo, err := r.open(ctx)
+	if err != nil {
+		return false
+	}
+	_, err = repo.Worktree()
+	return err == nil
+}
+
+// GetGitRootDir returns the root directory of the working tree
+func (r *GoGitRepository) GetGitRootDir(ctx context.Context) (string, error) {
+	repo, err := r.open(ctx)
+	if err != nil {
+		return "", err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(worktree.Filesystem.Root())
+}
+
+// GetGitCommonDir returns the absolute path of the git directory
+func (r *GoGitRepository) GetGitCommonDir(ctx context.Context) (string, error) {
+	repo, err := r.open(ctx)
+	if err != nil {
+		return "", err
+	}
+	storage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return "", errors.New("repository is not stored on disk")
+	}
+	return filepath.Abs(storage.Filesystem().Root())
+}
+
+// GetSuperproject returns the root of the superproject if the repository is a submodule.
+// A submodule's .git file points into the superproject's .git/modules directory.
+func (r *GoGitRepository) GetSuperproject(ctx context.Context) (string, error) {
+	root, err := r.GetGitRootDir(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, ".git"))
+	if err != nil {
+		// A .git directory, not a submodule
+		return "", nil
+	}
+	gitDir := strings.TrimSpace(strings.TrimPrefix(string(data), "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(root, gitDir)
+	}
+
+	marker := string(filepath.Separator) + filepath.Join(".git", "modules") + string(filepath.Separator)
+	if i := strings.Index(filepath.Clean(gitDir)+string(filepath.Separator), marker); i >= 0 {
+		return gitDir[:i], nil
+	}
+	return "", nil
+}
+
+// GetBranches returns a list of local branches
+func (r *GoGitRepository) GetBranches(ctx context.Context) ([]string, error) {
+	repo, err := r.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := repo.Branches()
+	if err != nil {
+		return nil, err
+	}
+
+	branches := []string{}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		branches = append(branches, ref.Name().Short())
+		return nil
+	})
+	sort.Strings(branches)
+	return branches, err
+}
+
+// GetCurrentBranch returns the current branch, also before the first commit
+func (r *GoGitRepository) GetCurrentBranch(ctx context.Context) (string, error) {
+	repo, err := r.open(ctx)
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return "", err
+	}
+	if head.Type() != plumbing.SymbolicReference {
+		// Detached HEAD
+		return "", nil
+	}
+	return head.Target().Short(), nil
+}
+
+// GetStagedFiles returns the files whose index entry differs from HEAD
+func (r *GoGitRepository) GetStagedFiles(ctx context.Context) ([]string, error) {
+	repo, err := r.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := headEntries(repo)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+	inIndex := make(map[string]bool, len(idx.Entries))
+	for _, entry := range idx.Entries {
+		inIndex[entry.Name] = true
+		if e, ok := head[entry.Name]; !ok || e.hash != entry.Hash || e.mode != entry.Mode {
+			files = append(files, entry.Name)
+		}
+	}
+	for name := range head {
+		if !inIndex[name] {
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// GetStagedChanges returns the staged changes with line counts.
+// Only renames without changes to the content are detected.
+func (r *GoGitRepository) GetStagedChanges(ctx context.Context) ([]FileChange, error) {
+	files, err := r.stagedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]FileChange, len(files))
+	for i, f := range files {
+		changes[i] = f.change
+	}
+	return changes, nil
+}
+
+// GetStagedDiff returns the staged changes as a unified diff
+func (r *GoGitRepository) GetStagedDiff(ctx context.Context) (string, error) {
+	files, err := r.stagedVersions(ctx)
+	if err != nil {
+		return "", err
+	}
+	return unifiedDiff(files)
+}
+
+// stagedVersions compares the index with HEAD and returns both versions of
+// every changed file, sorted by path
+func (r *GoGitRepository) stagedVersions(ctx context.Context) ([]fileVersions, error) {
+	repo, err := r.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	head, err := headEntries(repo)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+
+	type entries struct{ before, after headEntry }
+	var changes []FileChange
+	var versions []entries
+	inIndex := make(map[string]bool, len(idx.Entries))
+	// The added files by content, in path order like the index
+	added := make(map[plumbing.Hash][]int)
+	for _, entry := range idx.Entries {
+		inIndex[entry.Name] = true
+		staged := headEntry{hash: entry.Hash, mode: entry.Mode}
+		e, ok := head[entry.Name]
+		switch {
+		case !ok:
+			added[entry.Hash] = append(added[entry.Hash], len(changes))
+			changes = append(changes, FileChange{Status: "A", Path: entry.Name})
+			versions = append(versions, entries{after: staged})
+		case e != staged:
+			// Also when only the mode changed, like git
+			changes = append(changes, FileChange{Status: "M", Path: entry.Name})
+			versions = append(versions, entries{before: e, after: staged})
+		}
+	}
+	// Removed files are paired with added files of the same content in path
+	// order, so that the renames do not depend on the order of the map
+	var removed []string
+	for name := range head {
+		if !inIndex[name] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		e := head[name]
+		if candidates := added[e.hash]; len(candidates) > 0 {
+			i := candidates[0]
+			added[e.hash] = candidates[1:]
+			changes[i].Status, changes[i].OldPath = "R", name
+			versions[i].before = e
+			continue
+		}
+		changes = append(changes, FileChange{Status: "D", Path: name})
+		versions = append(versions, entries{before: e})
+	}
+
+	files := make([]fileVersions, len(changes))
+	for i, change := range changes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		before, err := blobContent(repo, versions[i].before.hash)
+		if err != nil {
+			return nil, err
+		}
+		after, err := blobContent(repo, versions[i].after.hash)
+		if err != nil {
+			return nil, err
+		}
+		if change.Status != "R" {
+			counts := countLines(before, after)
+			change.Added, change.Removed, change.Binary = counts.Added, counts.Removed, counts.Binary
+		}
+		files[i] = fileVersions{
+			change: change,
+			before: before, after: after,
+			beforeMode: versions[i].before.mode, afterMode: versions[i].after.mode,
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].change.Path < files[j].change.Path })
+	return files, nil
+}
+
+// blobContent returns the content of a blob; the zero hash is an empty file.
+// Submodules have no blob and are treated as empty as well.
+func blobContent(repo *gogit.Repository, hash plumbing.Hash) ([]byte, error) {
+	if hash.IsZero() {
+		return nil, nil
+	}
+	blob, err := repo.BlobObject(hash)
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// headEntry is the blob, or commit of a submodule, and the mode of a path
+type headEntry struct {
+	hash plumbing.Hash
+	mode filemode.FileMode
+}
+
+// headEntries returns the entries of all files and submodules at HEAD by path.
+// It returns an empty map before the first commit.
+func headEntries(repo *gogit.Repository) (map[string]headEntry, error) {
+	entries := make(map[string]headEntry)
+
+	ref, err := repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if entry.Mode != filemode.Dir {
+			entries[name] = headEntry{hash: entry.Hash, mode: entry.Mode}
+		}
+	}
+}
+
+// GetStatus returns the changed and untracked files. Files are ignored
+// according to the .gitignore files, but not core.excludesFile.
+func (r *GoGitRepository) GetStatus(ctx context.Context) ([]StatusEntry, error) {
+	repo, err := r.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []StatusEntry{}
+	for path, file := range status {
+		entry := StatusEntry{Path: path, Staged: statusCode(file.Staging), Unstaged: statusCode(file.Worktree)}
+		if file.Worktree == gogit.Untracked {
+			entry.Staged = ""
+		}
+		if file.Staging == gogit.Renamed {
+			entry.OldPath = file.Extra
+		}
+		if entry.Staged != "" || entry.Unstaged != "" {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+// statusCode converts a go-git status code to a status letter
+func statusCode(code gogit.StatusCode) string {
+	if code == gogit.Unmodified {
+		return ""
+	}
+	return string(code)
+}
+
+// Stage adds the files to the index, removing the deleted ones
+func (r *GoGitRepository) Stage(ctx context.Context, paths []string) error {
+	repo, err := r.open(ctx)
+	if err != nil {
+		return err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := worktree.Add(path); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Unstage replaces the index entries of the files with those at HEAD, or
+// removes them if they are not in HEAD
+func (r *GoGitRepository) Unstage(ctx context.Context, paths []string) error {
+	repo, err := r.open(ctx)
+	if err != nil {
+		return err
+	}
+	var tree *object.Tree
+	if _, err := repo.Head(); err == nil {
+		if tree, err = r.tree(ctx, "HEAD"); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return err
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if _, err := idx.Remove(path); err != nil && !errors.Is(err, index.ErrEntryNotFound) {
+			return err
+		}
+		if tree == nil {
+			continue
+		}
+		entry, err := tree.FindEntry(path)
+		if errors.Is(err, object.ErrEntryNotFound) || errors.Is(err, object.ErrDirectoryNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		idx.Entries = append(idx.Entries, &index.Entry{Name: path, Hash: entry.Hash, Mode: entry.Mode})
+	}
+	// The entries are sorted when the index is written
+	return repo.Storer.SetIndex(idx)
+}
+
+// GetUnstagedDiff returns the working tree changes of the files in the
+// index. Submodules are left out.
+func (r *GoGitRepository) GetUnstagedDiff(ctx context.Context) (string, error) {
+	repo, err := r.open(ctx)
+	if err != nil {
+		return "", err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return "", err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return "", err
+	}
+
+	var files []fileVersions
+	for path, file := range status {
+		if file.Worktree != gogit.Modified && file.Worktree != gogit.Deleted {
+			continue
+		}
+		entry, err := idx.Entry(path)
+		if err != nil || entry.Mode == filemode.Submodule {
+			continue
+		}
+		before, err := blobContent(repo, entry.Hash)
+		if err != nil {
+			return "", err
+		}
+
+		var after []byte
+		if file.Worktree == gogit.Modified {
+			name := filepath.Join(worktree.Filesystem.Root(), filepath.FromSlash(path))
+			if entry.Mode == filemode.Symlink {
+				var target string
+				target, err = os.Readlink(name)
+				after = []byte(target)
+			} else {
+				after, err = os.ReadFile(name)
+			}
+			if err != nil {
+				return "", err
+			}
+		}
+
+		change := countLines(before, after)
+		change.Path = path
+		change.Status = "M"
+		if file.Worktree == gogit.Deleted {
+			change.Status = "D"
+		}
+		files = append(files, fileVersions{change: change, before: before, after: after})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].change.Path < files[j].change.Path })
+	return unifiedDiff(files)
+}
+
+// ApplyToIndex applies the patch to the index entries of the files
+func (r *GoGitRepository) ApplyToIndex(ctx context.Context, patch string) error {
+	files, err := diff.Parse(patch)
+	if err != nil {
+		return err
+	}
+	repo, err := r.open(ctx)
+	if err != nil {
+		return err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		entry, err := idx.Entry(f.Path())
+		if err != nil || f.OldPath != f.NewPath {
+			return fmt.Errorf("%s: only changes to files in the index can be applied", f.Path())
+		}
+		content, err := blobContent(repo, entry.Hash)
+		if err != nil {
+			return err
+		}
+		applied, err := diff.Apply(string(content), f)
+		if err != nil {
+			return err
+		}
+
+		obj := repo.Storer.NewEncodedObject()
+		obj.SetType(plumbing.BlobObject)
+		writer, err := obj.Writer()
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(writer, applied); err != nil {
+			return err
+		}
+		if err := writer.Close(); err != nil {
+			return err
+		}
+		if entry.Hash, err = repo.Storer.SetEncodedObject(obj); err != nil {
+			return err
+		}
+		// Clear the file times so that git compares the content with the working tree
+		entry.Size = uint32(len(applied))
+		entry.CreatedAt, entry.ModifiedAt = time.Time{}, time.Time{}
+	}
+	return repo.Storer.SetIndex(idx)
+}
+
+// WriteIndexTree writes the tree objects of the index entries
+func (r *GoGitRepository) WriteIndexTree(ctx context.Context) (string, error) {
+	repo, err := r.open(ctx)
+	if err != nil {
+		return "", err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return "", err
+	}
+	hash, err := writeTree(repo, idx.Entries)
+	return hash.String(), err
+}
+
+// writeTree writes a tree of the entries, with the subdirectories as trees
+// of their own, and returns its id
+func writeTree(repo *gogit.Repository, entries []*index.Entry) (plumbing.Hash, error) {
+	tree := &object.Tree{}
+	var dirs []string
+	subdirs := make(map[string][]*index.Entry)
+	for _, e := range entries {
+		dir, rest, ok := strings.Cut(e.Name, "/")
+		if !ok {
+			tree.Entries = append(tree.Entries, object.TreeEntry{Name: e.Name, Mode: e.Mode, Hash: e.Hash})
+			continue
+		}
+		if _, seen := subdirs[dir]; !seen {
+			dirs = append(dirs, dir)
+		}
+		subdirs[dir] = append(subdirs[dir], &index.Entry{Name: rest, Mode: e.Mode, Hash: e.Hash})
+	}
+	for _, dir := range dirs {
+		hash, err := writeTree(repo, subdirs[dir])
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		tree.Entries = append(tree.Entries, object.TreeEntry{Name: dir, Mode: filemode.Dir, Hash: hash})
+	}
+	// Git sorts directories as if their names ended with a slash
+	sortName := func(e object.TreeEntry) string {
+		if e.Mode == filemode.Dir {
+			return e.Name + "/"
+		}
+		return e.Name
+	}
+	sort.Slice(tree.Entries, func(i, j int) bool {
+		return sortName(tree.Entries[i]) < sortName(tree.Entries[j])
+	})
+
+	obj := repo.Storer.NewEncodedObject()
+	if err := tree.Encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return repo.Storer.SetEncodedObject(obj)
+}
+
+// ReadIndexTree replaces the index entries with the files of the tree,
+// keeping the file stats of the entries that did not change
+func (r *GoGitRepository) ReadIndexTree(ctx context.Context, tree string) error {
+	repo, err := r.open(ctx)
+	if err != nil {
+		return err
+	}
+	t, err := repo.TreeObject(plumbing.NewHash(tree))
+	if err != nil {
+		return err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]*index.Entry, len(idx.Entries))
+	for _, e := range idx.Entries {
+		current[e.Name] = e
+	}
+	var entries []*index.Entry
+	walker := object.NewTreeWalker(t, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if entry.Mode == filemode.Dir {
+			continue
+		}
+		if e, ok := current[name]; ok && e.Hash == entry.Hash && e.Mode == entry.Mode {
+			entries = append(entries, e)
+			continue
+		}
+		entries = append(entries, &index.Entry{Name: name, Hash: entry.Hash, Mode: entry.Mode})
+	}
+	idx.Entries = entries
+	return repo.Storer.SetIndex(idx)
+}
+
+// ListFiles returns all files in the index
+func (r *GoGitRepository) ListFiles(ctx context.Context) ([]string, error) {
+	repo, err := r.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(idx.Entries))
+	for _, entry := range idx.Entries {
+		files = append(files, entry.Name)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// ResolveTree returns the id of the tree the given revision points to
+func (r *GoGitRepository) ResolveTree(ctx context.Context, rev string) (string, error) {
+	tree, err := r.tree(ctx, rev)
+	if err != nil {
+		return "", err
+	}
+	return tree.Hash.String(), nil
+}
+
+// ListDirectories returns the directories in the tree of the given revision,
+// reading only the trees below prefix up to maxDepth
+func (r *GoGitRepository) ListDirectories(ctx context.Context, rev, prefix string, maxDepth int) ([]string, error) {
+	tree, err := r.tree(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := []string{}
+	start := ""
+	if prefix != "" {
+		entry, err := tree.FindEntry(prefix)
+		if errors.Is(err, object.ErrEntryNotFound) || errors.Is(err, object.ErrDirectoryNotFound) ||
+			(err == nil && entry.Mode != filemode.Dir) {
+			return dirs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if tree, err = tree.Tree(prefix); err != nil {
+			return nil, err
+		}
+		start = prefix + "/"
+	}
+	var walk func(tree *object.Tree, prefix string, depth int) error
+	walk = func(tree *object.Tree, prefix string, depth int) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		for _, entry := range tree.Entries {
+			if entry.Mode != filemode.Dir {
+				continue
+			}
+			dir := prefix + entry.Name
+			dirs = append(dirs, dir)
+			if maxDepth > 0 && depth >= maxDepth {
+				continue
+			}
+			subtree, err := tree.Tree(entry.Name)
+			if err != nil {
+				return err
+			}
+			if err := walk(subtree, dir+"/", depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(tree, start, pathDepth(prefix)+1); err != nil {
+		return nil, err
+	}
+	return dirs, nil
+}
+
+// tree returns the tree the given revision points to
+func (r *GoGitRepository) tree(ctx context.Context, rev string) (*object.Tree, error) {
+	repo, err := r.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %q", rev)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+	return commit.Tree()
+}
+
+// Commit commits the index with the author and committer from git config or
+// the GIT_AUTHOR_* and GIT_COMMITTER_* environment variables.
+// Of the pass-through flags, --gpg-sign is not supported and --no-verify has
+// no effect since hooks are not run.
+func (r *GoGitRepository) Commit(ctx context.Context, message string, opts CommitOptions) error {
+	if err := ValidateCommitArgs(opts.Args); err != nil {
+		return err
+	}
+	repo, err := r.open(ctx)
+	if err != nil {
+		return err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	settings, err := GetCleanupSettings(ctx, r)
+	if err != nil {
+		return err
+	}
+	settings.Mode = opts.Cleanup
+	if settings.Mode == "" {
+		settings.Mode = CleanupWhitespace
+	}
+
+	author, err := r.signature(ctx, "author")
+	if err != nil {
+		return err
+	}
+	committer, err := r.signature(ctx, "committer")
+	if err != nil {
+		return err
+	}
+
+	commitOpts := &gogit.CommitOptions{Author: author, Committer: committer}
+	signoff := false
+	for _, arg := range opts.Args {
+		switch {
+		case arg == "--allow-empty":
+			commitOpts.AllowEmptyCommits = true
+		case arg == "--signoff" || arg == "-s":
+			signoff = true
+		case arg == "--no-signoff":
+			signoff = false
+		case strings.HasPrefix(arg, "--author="):
+			name, email, err := parseIdent(strings.TrimPrefix(arg, "--author="))
+			if err != nil {
+				return err
+			}
+			author.Name, author.Email = name, email
+		case strings.HasPrefix(arg, "--date="):
+			when, err := parseDate(strings.TrimPrefix(arg, "--date="))
+			if err != nil {
+				return err
+			}
+			author.When = when
+		case arg == "--gpg-sign" || strings.HasPrefix(arg, "--gpg-sign=") || strings.HasPrefix(arg, "-S"):
+			return errors.New("signing commits requires the exec backend")
+		}
+	}
+
+	message = settings.Clean(message)
+	if signoff {
+		message = addTrailer(message, fmt.Sprintf("Signed-off-by: %s <%s>", committer.Name, committer.Email))
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err = worktree.Commit(message+"\n", commitOpts)
+	return err
+}
+
+// signature returns the author or committer identity from the environment or git config
+func (r *GoGitRepository) signature(ctx context.Context, role string) (*object.Signature, error) {
+	upper := strings.ToUpper(role)
+	name := os.Getenv("GIT_" + upper + "_NAME")
+	email := os.Getenv("GIT_" + upper + "_EMAIL")
+
+	entries, err := r.GetConfigEntries(ctx, `^user\.(name|email)$`)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		switch {
+		case entry.Key == "user.name" && os.Getenv("GIT_"+upper+"_NAME") == "":
+			name = entry.Value
+		case entry.Key == "user.email" && os.Getenv("GIT_"+upper+"_EMAIL") == "":
+			email = entry.Value
+		}
+	}
+	if name == "" || email == "" {
+		return nil, errors.New("user.name and user.email must be set in git config")
+	}
+
+	when := time.Now()
+	if date := os.Getenv("GIT_" + upper + "_DATE"); date != "" {
+		if when, err = parseDate(date); err != nil {
+			return nil, err
+		}
+	}
+	return &object.Signature{Name: name, Email: email, When: when}, nil
+}
+
+// parseIdent parses "Name <email>"
+func parseIdent(ident string) (string, string, error) {
+	open := strings.Index(ident, "<")
+	end := strings.LastIndex(ident, ">")
+	if open < 0 || end < open {
+		return "", "", fmt.Errorf("expected \"Name <email>\", got %q", ident)
+	}
+	return strings.TrimSpace(ident[:open]), ident[open+1 : end], nil
+}
+
+// parseDate parses the date formats supported by this backend
+func parseDate(date string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, time.RFC1123Z, "2006-01-02 15:04:05 -0700"} {
+		if when, err := time.Parse(layout, date); err == nil {
+			return when, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported date %q, use RFC 3339", date)
+}
+
+// trailerPattern matches a trailer line like "Signed-off-by: Name <email>"
+var trailerPattern = regexp.MustCompile(`^[A-Za-z0-9-]+: `)
+
+// addTrailer appends a trailer, joining the last paragraph if it already holds trailers
+func addTrailer(message, trailer string) string {
+	paragraphs := strings.Split(message, "\n\n")
+	last := paragraphs[len(paragraphs)-1]
+	if len(paragraphs) > 1 {
+		allTrailers := true
+		for _, line := range strings.Split(last, "\n") {
+			if !trailerPattern.MatchString(line) {
+				allTrailers = false
+				break
+			}
+		}
+		if allTrailers {
+			return message + "\n" + trailer
+		}
+	}
+	return message + "\n\n" + trailer
+}
+
+// GetLog returns up to max commits reachable from HEAD, newest first
+func (r *GoGitRepository) GetLog(ctx context.Context, max int) ([]LogEntry, error) {
+	repo, err := r.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	commits, err := repo.Log(&gogit.LogOptions{From: head.Hash()})
+	if err != nil {
+		return nil, err
+	}
+	defer commits.Close()
+
+	var entries []LogEntry
+	for len(entries) < max {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		commit, err := commits.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, LogEntry{Hash: commit.Hash.String(), Message: strings.TrimSpace(commit.Message)})
+	}
+	return entries, nil
+}
+
+// ResolveCommit returns the commit id the given revision points to
+func (r *GoGitRepository) ResolveCommit(ctx context.Context, rev string) (string, error) {
+	repo, err := r.open(ctx)
+	if err != nil {
+		return "", err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return "", fmt.Errorf("unknown revision %q", rev)
+	}
+	return hash.String(), nil
+}
+
+// ShowFile returns the content of a file at the given revision
+func (r *GoGitRepository) ShowFile(ctx context.Context, rev, path string) ([]byte, error) {
+	repo, err := r.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %q", rev)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+	file, err := commit.File(path)
+	if err != nil {
+		return nil, fmt.Errorf("path %q does not exist in %q", path, rev)
+	}
+	content, err := file.Contents()
+	return []byte(content), err
+}
+
+// ShowStagedFile returns the content of a file in the index.
+// The path is relative to the repository root.
+func (r *GoGitRepository) ShowStagedFile(ctx context.Context, path string) ([]byte, error) {
+	repo, err := r.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+	entry, err := idx.Entry(path)
+	if err != nil {
+		return nil, fmt.Errorf("path %q is not in the index", path)
+	}
+	return blobContent(repo, entry.Hash)
+}
+
+// GetConfigEntries returns the config entries whose key matches the given regular expression,
+// from the system, global and repository config in that order
+func (r *GoGitRepository) GetConfigEntries(ctx context.Context, pattern string) ([]ConfigEntry, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := r.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var raws []*formatconfig.Config
+	for _, scope := range []gogitconfig.Scope{gogitconfig.SystemScope, gogitconfig.GlobalScope} {
+		cfg, err := gogitconfig.LoadConfig(scope)
+		if err != nil {
+			return nil, err
+		}
+		raws = append(raws, cfg.Raw)
+	}
+	local, err := repo.Storer.Config()
+	if err != nil {
+		return nil, err
+	}
+	raws = append(raws, local.Raw)
+
+	entries := []ConfigEntry{}
+	add := func(prefix string, options formatconfig.Options) {
+		for _, option := range options {
+			key := prefix + strings.ToLower(option.Key)
+			if re.MatchString(key) {
+				entries = append(entries, ConfigEntry{Key: key, Value: option.Value})
+			}
+		}
+	}
+	for _, raw := range raws {
+		for _, section := range raw.Sections {
+			name := strings.ToLower(section.Name)
+			add(name+".", section.Options)
+			for _, subsection := range section.Subsections {
+				add(name+"."+subsection.Name+".", subsection.Options)
+			}
+		}
+	}
+	return entries, nil
+}
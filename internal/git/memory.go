@@ -0,0 +1,469 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/diff"
+)
+
+// MemoryRepository is an in-memory Repository for tests.
+// The zero value is not a repository; use NewMemoryRepository.
+type MemoryRepository struct {
+	Root string
+	// CommonDir is the git directory that caches are written to; when it is
+	// empty, as by default, nothing is written to disk
+	CommonDir     string
+	Superproject  string
+	Branches      []string
+	CurrentBranch string
+	// Files holds the content of the tracked files at HEAD
+	Files map[string][]byte
+	// Staged holds the content of the staged files, to be committed by Commit;
+	// nil content is a staged deletion
+	Staged map[string][]byte
+	// Worktree holds the files of the working tree that differ from the
+	// index; nil content is a deleted file
+	Worktree map[string][]byte
+	// Commits holds the commits, newest first
+	Commits []LogEntry
+	// Revisions holds the file contents of named revisions for ShowFile
+	Revisions map[string]map[string][]byte
+	Config    []ConfigEntry
+	// CommitErr, if set, is returned by Commit
+	CommitErr error
+	// CommitOptions records the options of the last commit
+	CommitOptions CommitOptions
+
+	// trees holds the index contents written by WriteIndexTree
+	trees map[string]map[string][]byte
+}
+
+// NewMemoryRepository creates an empty in-memory repository on the main branch
+func NewMemoryRepository(root string) *MemoryRepository {
+	return &MemoryRepository{
+		Root:          root,
+		Branches:      []string{"main"},
+		CurrentBranch: "main",
+		Files:         make(map[string][]byte),
+		Staged:        make(map[string][]byte),
+		Worktree:      make(map[string][]byte),
+		Revisions:     make(map[string]map[string][]byte),
+		trees:         make(map[string]map[string][]byte),
+	}
+}
+
+// IsGitRepository reports whether the repository has a root directory
+func (r *MemoryRepository) IsGitRepository(ctx context.Context) bool {
+	return r.Root != ""
+}
+
+// GetGitRootDir returns the root directory
+func (r *MemoryRepository) GetGitRootDir(ctx context.Context) (string, error) {
+	if r.Root == "" {
+		return "", errors.New("not a git repository")
+	}
+	return r.Root, nil
+}
+
+// GetGitCommonDir returns the git directory
+func (r *MemoryRepository) GetGitCommonDir(ctx context.Context) (string, error) {
+	return r.CommonDir, nil
+}
+
+// GetSuperproject returns the superproject root, if set
+func (r *MemoryRepository) GetSuperproject(ctx context.Context) (string, error) {
+	return r.Superproject, nil
+}
+
+// GetBranches returns the branches
+func (r *MemoryRepository) GetBranches(ctx context.Context) ([]string, error) {
+	return append([]string{}, r.Branches...), nil
+}
+
+// GetCurrentBranch returns the current branch
+func (r *MemoryRepository) GetCurrentBranch(ctx context.Context) (string, error) {
+	return r.CurrentBranch, nil
+}
+
+// GetStagedFiles returns the staged files in sorted order
+func (r *MemoryRepository) GetStagedFiles(ctx context.Context) ([]string, error) {
+	return sortedKeys(r.Staged), nil
+}
+
+// GetStagedChanges returns the staged files as added or modified, with line counts
+func (r *MemoryRepository) GetStagedChanges(ctx context.Context) ([]FileChange, error) {
+	changes := []FileChange{}
+	for _, f := range r.stagedVersions() {
+		changes = append(changes, f.change)
+	}
+	return changes, nil
+}
+
+// GetStagedDiff returns the staged files as a unified diff
+func (r *MemoryRepository) GetStagedDiff(ctx context.Context) (string, error) {
+	return unifiedDiff(r.stagedVersions())
+}
+
+// stagedVersions returns the staged files with their content at HEAD
+func (r *MemoryRepository) stagedVersions() []fileVersions {
+	var files []fileVersions
+	for _, path := range sortedKeys(r.Staged) {
+		before, tracked := r.Files[path]
+		after := r.Staged[path]
+		change := countLines(before, after)
+		change.Path = path
+		change.Status = "M"
+		switch {
+		case after == nil:
+			change.Status = "D"
+		case !tracked:
+			change.Status = "A"
+		}
+		files = append(files, fileVersions{change: change, before: before, after: after})
+	}
+	return files
+}
+
+// GetStatus compares HEAD, the index and the working tree
+func (r *MemoryRepository) GetStatus(ctx context.Context) ([]StatusEntry, error) {
+	index := r.index()
+	paths := make(map[string][]byte)
+	for path := range r.Staged {
+		paths[path] = nil
+	}
+	for path := range r.Worktree {
+		paths[path] = nil
+	}
+
+	entries := []StatusEntry{}
+	for _, path := range sortedKeys(paths) {
+		entry := StatusEntry{Path: path}
+		if content, ok := r.Staged[path]; ok {
+			_, tracked := r.Files[path]
+			switch {
+			case content == nil:
+				entry.Staged = "D"
+			case !tracked:
+				entry.Staged = "A"
+			default:
+				entry.Staged = "M"
+			}
+		}
+		if content, ok := r.Worktree[path]; ok {
+			_, inIndex := index[path]
+			switch {
+			case !inIndex:
+				entry.Unstaged = "?"
+			case content == nil:
+				entry.Unstaged = "D"
+			default:
+				entry.Unstaged = "M"
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Stage moves the working tree version of the files to the index
+func (r *MemoryRepository) Stage(ctx context.Context, paths []string) error {
+	for _, path := range paths {
+		content, ok := r.Worktree[path]
+		if !ok {
+			continue
+		}
+		delete(r.Worktree, path)
+		_, tracked := r.Files[path]
+		switch {
+		case content == nil && !tracked:
+			delete(r.Staged, path)
+		case bytes.Equal(content, r.Files[path]) && content != nil:
+			delete(r.Staged, path)
+		default:
+			r.Staged[path] = content
+		}
+	}
+	return nil
+}
+
+// Unstage moves the staged version of the files back to the working tree,
+// unless it has other changes
+func (r *MemoryRepository) Unstage(ctx context.Context, paths []string) error {
+	for _, path := range paths {
+		content, ok := r.Staged[path]
+		if !ok {
+			continue
+		}
+		delete(r.Staged, path)
+		if _, changed := r.Worktree[path]; !changed {
+			r.Worktree[path] = content
+		}
+	}
+	return nil
+}
+
+// GetUnstagedDiff returns the working tree changes of the files in the index
+func (r *MemoryRepository) GetUnstagedDiff(ctx context.Context) (string, error) {
+	index := r.index()
+	var files []fileVersions
+	for _, path := range sortedKeys(r.Worktree) {
+		before, tracked := index[path]
+		if !tracked {
+			continue
+		}
+		after := r.Worktree[path]
+		change := countLines(before, after)
+		change.Path = path
+		change.Status = "M"
+		if after == nil {
+			change.Status = "D"
+		}
+		files = append(files, fileVersions{change: change, before: before, after: after})
+	}
+	return unifiedDiff(files)
+}
+
+// ApplyToIndex applies the patch to the staged version of the files
+func (r *MemoryRepository) ApplyToIndex(ctx context.Context, patch string) error {
+	files, err := diff.Parse(patch)
+	if err != nil {
+		return err
+	}
+	index := r.index()
+	for _, f := range files {
+		content, ok := index[f.Path()]
+		if !ok || f.OldPath != f.NewPath {
+			return fmt.Errorf("%s: only changes to files in the index can be applied", f.Path())
+		}
+		applied, err := diff.Apply(string(content), f)
+		if err != nil {
+			return err
+		}
+		if head, tracked := r.Files[f.Path()]; tracked && bytes.Equal(head, []byte(applied)) {
+			delete(r.Staged, f.Path())
+		} else {
+			r.Staged[f.Path()] = []byte(applied)
+		}
+		if bytes.Equal(r.Worktree[f.Path()], []byte(applied)) {
+			delete(r.Worktree, f.Path())
+		}
+	}
+	return nil
+}
+
+// WriteIndexTree keeps a copy of the index, with an id derived from its content
+func (r *MemoryRepository) WriteIndexTree(ctx context.Context) (string, error) {
+	files := r.index()
+	hash := sha1.New()
+	for _, path := range sortedKeys(files) {
+		fmt.Fprintf(hash, "%s\x00%d\x00%s", path, len(files[path]), files[path])
+	}
+	id := hex.EncodeToString(hash.Sum(nil))
+	r.trees[id] = files
+	return id, nil
+}
+
+// ReadIndexTree stages the differences between HEAD and a copy of the index
+// written by WriteIndexTree
+func (r *MemoryRepository) ReadIndexTree(ctx context.Context, tree string) error {
+	files, ok := r.trees[tree]
+	if !ok {
+		return fmt.Errorf("unknown tree %q", tree)
+	}
+	r.Staged = make(map[string][]byte)
+	for path, content := range files {
+		if head, tracked := r.Files[path]; !tracked || !bytes.Equal(head, content) {
+			r.Staged[path] = content
+		}
+	}
+	for path := range r.Files {
+		if _, ok := files[path]; !ok {
+			r.Staged[path] = nil
+		}
+	}
+	index := r.index()
+	for path, content := range r.Worktree {
+		indexed, ok := index[path]
+		if ok && content != nil && bytes.Equal(indexed, content) || !ok && content == nil {
+			delete(r.Worktree, path)
+		}
+	}
+	return nil
+}
+
+// index returns the files in the index: HEAD with the staged changes
+func (r *MemoryRepository) index() map[string][]byte {
+	files := make(map[string][]byte, len(r.Files)+len(r.Staged))
+	for path, content := range r.Files {
+		files[path] = content
+	}
+	for path, content := range r.Staged {
+		if content == nil {
+			delete(files, path)
+			continue
+		}
+		files[path] = content
+	}
+	return files
+}
+
+// ListFiles returns the files in the index in sorted order
+func (r *MemoryRepository) ListFiles(ctx context.Context) ([]string, error) {
+	return sortedKeys(r.index()), nil
+}
+
+// ResolveTree returns an id derived from the file names of the revision
+func (r *MemoryRepository) ResolveTree(ctx context.Context, rev string) (string, error) {
+	files, err := r.revisionFiles(ctx, rev)
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum([]byte(strings.Join(sortedKeys(files), "\x00")))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ListDirectories returns the directories of the files in the revision
+func (r *MemoryRepository) ListDirectories(ctx context.Context, rev, prefix string, maxDepth int) ([]string, error) {
+	files, err := r.revisionFiles(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+	dirs := []string{}
+	for _, dir := range fileDirs(sortedKeys(files), maxDepth) {
+		if prefix == "" || strings.HasPrefix(dir, prefix+"/") {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs, nil
+}
+
+// revisionFiles returns the files of a named revision or of HEAD
+func (r *MemoryRepository) revisionFiles(ctx context.Context, rev string) (map[string][]byte, error) {
+	if files, ok := r.Revisions[rev]; ok {
+		return files, nil
+	}
+	if _, err := r.ResolveCommit(ctx, rev); err != nil {
+		return nil, err
+	}
+	return r.Files, nil
+}
+
+// Commit records a commit with the staged files.
+// The message is cleaned up like git does, using core.commentChar from Config.
+func (r *MemoryRepository) Commit(ctx context.Context, message string, opts CommitOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if r.CommitErr != nil {
+		return r.CommitErr
+	}
+	if err := ValidateCommitArgs(opts.Args); err != nil {
+		return err
+	}
+	if len(r.Staged) == 0 && !contains(opts.Args, "--allow-empty") {
+		return errors.New("nothing to commit")
+	}
+
+	settings, err := GetCleanupSettings(ctx, r)
+	if err != nil {
+		return err
+	}
+	if opts.Cleanup != "" {
+		settings.Mode = opts.Cleanup
+	}
+	message = settings.Clean(message)
+	r.CommitOptions = opts
+
+	for path, content := range r.Staged {
+		if content == nil {
+			delete(r.Files, path)
+			continue
+		}
+		r.Files[path] = content
+	}
+	r.Staged = make(map[string][]byte)
+
+	sum := sha1.Sum([]byte(fmt.Sprintf("%d\n%s", len(r.Commits), message)))
+	r.Commits = append([]LogEntry{{Hash: hex.EncodeToString(sum[:]), Message: message}}, r.Commits...)
+	return nil
+}
+
+// GetLog returns up to max commits, newest first
+func (r *MemoryRepository) GetLog(ctx context.Context, max int) ([]LogEntry, error) {
+	if max > len(r.Commits) {
+		max = len(r.Commits)
+	}
+	return append([]LogEntry{}, r.Commits[:max]...), nil
+}
+
+// ResolveCommit resolves HEAD, a commit id or a named revision
+func (r *MemoryRepository) ResolveCommit(ctx context.Context, rev string) (string, error) {
+	if rev == "HEAD" && len(r.Commits) > 0 {
+		return r.Commits[0].Hash, nil
+	}
+	for _, c := range r.Commits {
+		if c.Hash == rev {
+			return c.Hash, nil
+		}
+	}
+	if _, ok := r.Revisions[rev]; ok {
+		return rev, nil
+	}
+	return "", fmt.Errorf("unknown revision %q", rev)
+}
+
+// ShowFile returns a file from a named revision, or from HEAD
+func (r *MemoryRepository) ShowFile(ctx context.Context, rev, path string) ([]byte, error) {
+	files, err := r.revisionFiles(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+	content, ok := files[path]
+	if !ok {
+		return nil, fmt.Errorf("path %q does not exist in %q", path, rev)
+	}
+	return content, nil
+}
+
+// ShowStagedFile returns the content of a file in the index
+func (r *MemoryRepository) ShowStagedFile(ctx context.Context, path string) ([]byte, error) {
+	content, ok := r.index()[path]
+	if !ok {
+		return nil, fmt.Errorf("path %q is not in the index", path)
+	}
+	return content, nil
+}
+
+// GetConfigEntries returns the config entries whose key matches the pattern
+func (r *MemoryRepository) GetConfigEntries(ctx context.Context, pattern string) ([]ConfigEntry, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []ConfigEntry{}
+	for _, entry := range r.Config {
+		if re.MatchString(entry.Key) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// sortedKeys returns the keys of a file map in sorted order
+func sortedKeys(files map[string][]byte) []string {
+	keys := make([]string, 0, len(files))
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
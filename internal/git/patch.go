@@ -0,0 +1,122 @@
+package git
+
+import (
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// patchContextLines is the number of context lines around each hunk, like git diff
+const patchContextLines = 3
+
+// fileVersions holds both versions of a changed file for unifiedDiff.
+// A nil before or after content with an empty path means the file does not exist.
+type fileVersions struct {
+	change FileChange
+	before []byte
+	after  []byte
+	// The modes of both versions; unknown modes are shown as regular files
+	beforeMode filemode.FileMode
+	afterMode  filemode.FileMode
+}
+
+// unifiedDiff renders the changes as a unified diff like `git diff`, for the
+// backends that do not run git
+func unifiedDiff(files []fileVersions) (string, error) {
+	var patch filePatches
+	for _, f := range files {
+		patch = append(patch, newFilePatch(f))
+	}
+
+	var sb strings.Builder
+	err := fdiff.NewUnifiedEncoder(&sb, patchContextLines).Encode(patch)
+	return sb.String(), err
+}
+
+// filePatches implements fdiff.Patch
+type filePatches []fdiff.FilePatch
+
+func (p filePatches) FilePatches() []fdiff.FilePatch { return p }
+func (p filePatches) Message() string                { return "" }
+
+// filePatch implements fdiff.FilePatch
+type filePatch struct {
+	from, to *patchFile
+	binary   bool
+	chunks   []fdiff.Chunk
+}
+
+func newFilePatch(f fileVersions) filePatch {
+	p := filePatch{binary: f.change.Binary}
+
+	oldPath := f.change.Path
+	if f.change.OldPath != "" {
+		oldPath = f.change.OldPath
+	}
+	if f.change.Status != "A" {
+		p.from = &patchFile{path: oldPath, hash: plumbing.ComputeHash(plumbing.BlobObject, f.before), mode: f.beforeMode}
+	}
+	if f.change.Status != "D" {
+		p.to = &patchFile{path: f.change.Path, hash: plumbing.ComputeHash(plumbing.BlobObject, f.after), mode: f.afterMode}
+	}
+
+	if !p.binary {
+		for _, d := range diff.Do(string(f.before), string(f.after)) {
+			op := fdiff.Equal
+			switch d.Type {
+			case diffmatchpatch.DiffInsert:
+				op = fdiff.Add
+			case diffmatchpatch.DiffDelete:
+				op = fdiff.Delete
+			}
+			p.chunks = append(p.chunks, chunk{content: d.Text, op: op})
+		}
+	}
+	return p
+}
+
+func (p filePatch) IsBinary() bool { return p.binary }
+
+// Files returns untyped nils for missing files, as the encoder expects
+func (p filePatch) Files() (fdiff.File, fdiff.File) {
+	var from, to fdiff.File
+	if p.from != nil {
+		from = p.from
+	}
+	if p.to != nil {
+		to = p.to
+	}
+	return from, to
+}
+
+func (p filePatch) Chunks() []fdiff.Chunk { return p.chunks }
+
+// patchFile implements fdiff.File
+type patchFile struct {
+	path string
+	hash plumbing.Hash
+	mode filemode.FileMode
+}
+
+func (f *patchFile) Hash() plumbing.Hash { return f.hash }
+func (f *patchFile) Path() string        { return f.path }
+
+func (f *patchFile) Mode() filemode.FileMode {
+	if f.mode == filemode.Empty {
+		return filemode.Regular
+	}
+	return f.mode
+}
+
+// chunk implements fdiff.Chunk
+type chunk struct {
+	content string
+	op      fdiff.Operation
+}
+
+func (c chunk) Content() string       { return c.content }
+func (c chunk) Type() fdiff.Operation { return c.op }
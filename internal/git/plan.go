@@ -0,0 +1,71 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// PlannedCommit is one of the commits created by CommitPlan
+type PlannedCommit struct {
+	Message string
+	// Paths are the staged files to commit, relative to the root directory.
+	// A rename needs both its old and its new path.
+	Paths []string
+}
+
+// CommitPlan splits the staged changes into the planned commits and creates
+// them in order. Each commit gets the staged version of its files; files that
+// are in no commit stay staged.
+//
+// It returns the number of commits created. The index is always restored to
+// the staged changes that were not committed, also when a commit fails.
+func CommitPlan(ctx context.Context, repo Repository, commits []PlannedCommit, opts CommitOptions) (created int, err error) {
+	tree, err := repo.WriteIndexTree(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		// Restore the index even if the commits were interrupted. After the
+		// commits, the committed files in it are the same as in HEAD.
+		if restoreErr := repo.ReadIndexTree(context.WithoutCancel(ctx), tree); restoreErr != nil {
+			err = errors.Join(err, fmt.Errorf("restoring the index to tree %s: %w", tree, restoreErr))
+		}
+	}()
+
+	for i, commit := range commits {
+		if err := repo.ReadIndexTree(ctx, tree); err != nil {
+			return i, err
+		}
+		if err := unstageOthers(ctx, repo, commit.Paths); err != nil {
+			return i, err
+		}
+		if err := repo.Commit(ctx, commit.Message, opts); err != nil {
+			return i, fmt.Errorf("commit %d of %d: %w", i+1, len(commits), err)
+		}
+	}
+	return len(commits), nil
+}
+
+// unstageOthers unstages the staged files that are not in paths. The files
+// of the earlier commits are in HEAD already and not staged any more.
+func unstageOthers(ctx context.Context, repo Repository, paths []string) error {
+	keep := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		keep[path] = true
+	}
+	changes, err := repo.GetStagedChanges(ctx)
+	if err != nil {
+		return err
+	}
+	var others []string
+	for _, change := range changes {
+		if !keep[change.Path] {
+			others = append(others, change.Path)
+		}
+		if change.Status == "R" && !keep[change.OldPath] {
+			others = append(others, change.OldPath)
+		}
+	}
+	return repo.Unstage(ctx, others)
+}
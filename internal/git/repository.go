@@ -0,0 +1,152 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrTimeout is returned when a git operation takes longer than its timeout
+var ErrTimeout = errors.New("git operation timed out")
+
+// Repository is the set of git operations used by git-cz-go.
+// ExecRepository runs the git binary, GoGitRepository is a pure Go implementation
+// and MemoryRepository is an in-memory fake for tests.
+//
+// Every operation stops when its context is cancelled.
+type Repository interface {
+	// IsGitRepository checks if the repository exists
+	IsGitRepository(ctx context.Context) bool
+	// GetGitRootDir returns the root directory of the working tree
+	GetGitRootDir(ctx context.Context) (string, error)
+	// GetGitCommonDir returns the absolute path of the git directory shared by all worktrees
+	GetGitCommonDir(ctx context.Context) (string, error)
+	// GetSuperproject returns the root of the superproject if the repository
+	// is a submodule, or "" otherwise
+	GetSuperproject(ctx context.Context) (string, error)
+	// GetBranches returns a list of branches
+	GetBranches(ctx context.Context) ([]string, error)
+	// GetCurrentBranch returns the current branch
+	GetCurrentBranch(ctx context.Context) (string, error)
+	// GetStagedFiles returns a list of staged files, relative to the root directory
+	GetStagedFiles(ctx context.Context) ([]string, error)
+	// GetStagedChanges returns the staged changes with their status and line
+	// counts, sorted by path
+	GetStagedChanges(ctx context.Context) ([]FileChange, error)
+	// GetStagedDiff returns the staged changes as a unified diff, like `git diff --cached`
+	GetStagedDiff(ctx context.Context) (string, error)
+	// GetStatus returns the files with staged, unstaged or untracked changes,
+	// sorted by path. Untracked files are listed one by one; ignored files are left out.
+	GetStatus(ctx context.Context) ([]StatusEntry, error)
+	// Stage adds the working tree version of the files, relative to the
+	// root directory, to the index; deleted files are removed from it
+	Stage(ctx context.Context, paths []string) error
+	// Unstage resets the index entries of the files, relative to the root
+	// directory, to HEAD, keeping the working tree
+	Unstage(ctx context.Context, paths []string) error
+	// GetUnstagedDiff returns the changes of tracked files in the working tree
+	// that are not staged, as a unified diff like `git diff`
+	GetUnstagedDiff(ctx context.Context) (string, error)
+	// ApplyToIndex applies a patch in the format of GetUnstagedDiff to the
+	// index, like `git apply --cached`. Backends other than ExecRepository
+	// only apply changes to files that are already in the index.
+	ApplyToIndex(ctx context.Context, patch string) error
+	// WriteIndexTree writes the index as a tree object and returns its id,
+	// like `git write-tree`
+	WriteIndexTree(ctx context.Context) (string, error)
+	// ReadIndexTree replaces the index with a tree written by WriteIndexTree,
+	// keeping the working tree, like `git read-tree`
+	ReadIndexTree(ctx context.Context, tree string) error
+	// ListFiles returns all tracked files, relative to the root directory
+	ListFiles(ctx context.Context) ([]string, error)
+	// ResolveTree returns the id of the tree the given revision points to
+	ResolveTree(ctx context.Context, rev string) (string, error)
+	// ListDirectories returns the directories below prefix in the tree of the
+	// given revision, or all directories if prefix is empty, relative to the root
+	// directory and sorted like git does, up to maxDepth levels deep from the
+	// root; zero means all levels. A missing prefix has no directories.
+	ListDirectories(ctx context.Context, rev, prefix string, maxDepth int) ([]string, error)
+	// Commit commits the staged changes with the given message
+	Commit(ctx context.Context, message string, opts CommitOptions) error
+	// GetLog returns up to max commits reachable from HEAD, newest first
+	GetLog(ctx context.Context, max int) ([]LogEntry, error)
+	// ResolveCommit returns the commit id the given revision points to
+	ResolveCommit(ctx context.Context, rev string) (string, error)
+	// ShowFile returns the content of a file, relative to the root directory, at the given revision
+	ShowFile(ctx context.Context, rev, path string) ([]byte, error)
+	// ShowStagedFile returns the content of a file, relative to the root directory, in the index
+	ShowStagedFile(ctx context.Context, path string) ([]byte, error)
+	// GetConfigEntries returns the config entries whose key matches the given regular expression
+	GetConfigEntries(ctx context.Context, pattern string) ([]ConfigEntry, error)
+}
+
+// Backends that NewRepository can create
+const (
+	// BackendExec runs the git binary
+	BackendExec = "exec"
+	// BackendGoGit uses go-git and needs no git binary
+	BackendGoGit = "go-git"
+)
+
+// ValidateBackend checks that backend names a known backend; "" means BackendExec
+func ValidateBackend(backend string) error {
+	switch backend {
+	case "", BackendExec, BackendGoGit:
+		return nil
+	}
+	return fmt.Errorf("unknown backend %q, expected %q or %q", backend, BackendExec, BackendGoGit)
+}
+
+// NewRepository creates a repository for dir using the named backend
+func NewRepository(backend, dir string) (Repository, error) {
+	if err := ValidateBackend(backend); err != nil {
+		return nil, err
+	}
+	if backend == BackendGoGit {
+		return NewGoGitRepository(dir), nil
+	}
+	return NewExecRepository(dir), nil
+}
+
+// LogEntry is a commit as returned by GetLog
+type LogEntry struct {
+	Hash    string
+	Message string
+}
+
+// Subject returns the first line of the commit message
+func (e LogEntry) Subject() string {
+	subject, _, _ := strings.Cut(e.Message, "\n")
+	return subject
+}
+
+// ConfigEntry is a single key/value pair read from git config
+type ConfigEntry struct {
+	Key   string
+	Value string
+}
+
+// GetRemoteURLs returns the URLs of all configured remotes
+func GetRemoteURLs(ctx context.Context, repo Repository) ([]string, error) {
+	entries, err := repo.GetConfigEntries(ctx, `^remote\..*\.url$`)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		urls = append(urls, entry.Value)
+	}
+	return urls, nil
+}
+
+// Helper function to check if a slice contains a string
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package git
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultScopeDirs are the directory patterns DetectScopes uses when none are given
+var DefaultScopeDirs = []string{"*"}
+
+// commonScopeDirs are listed first by DetectScopes, in this order
+var commonScopeDirs = []string{"cmd", "pkg", "internal", "api", "ui", "docs"}
+
+// scopeCacheDir is the directory below the git directory where detected scopes are cached
+const scopeCacheDir = "git-cz/scopes"
+
+// scopeCacheSize is the number of trees whose scopes are kept in the cache
+const scopeCacheSize = 20
+
+// DetectScopes returns the directories at HEAD that match the given patterns,
+// to be offered as scopes. Patterns are matched with path.Match against paths
+// relative to the root, so "*" selects the top-level directories and
+// "packages/*" the directories in packages.
+//
+// Common source directories come first, followed by the others in sorted order.
+// Only the trees below the literal prefixes of the patterns are read. The result
+// is cached in the git directory by HEAD tree id, so it is only computed again
+// after a commit changes the tree; the cache keeps the last scopeCacheSize trees. Before the first commit the
+// directories of the tracked files are used instead.
+func DetectScopes(ctx context.Context, repo Repository, patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		patterns = DefaultScopeDirs
+	}
+
+	// Only the trees below the literal prefix of each pattern are listed, as
+	// deep as the patterns with that prefix reach
+	depths := make(map[string]int)
+	maxDepth := 0
+	for _, pattern := range patterns {
+		prefix := patternPrefix(pattern)
+		depths[prefix] = max(depths[prefix], pathDepth(pattern))
+		maxDepth = max(maxDepth, pathDepth(pattern))
+	}
+
+	tree, err := repo.ResolveTree(ctx, "HEAD")
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		// No commit yet
+		files, err := repo.ListFiles(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return sortScopes(matchDirs(fileDirs(files, maxDepth), patterns)), nil
+	}
+
+	cachePath := ""
+	if gitDir, err := repo.GetGitCommonDir(ctx); err == nil && gitDir != "" {
+		sum := sha1.Sum([]byte(strings.Join(patterns, "\n")))
+		name := tree + "-" + hex.EncodeToString(sum[:8]) + ".json"
+		cachePath = filepath.Join(gitDir, filepath.FromSlash(scopeCacheDir), name)
+
+		var cached []string
+		if data, err := os.ReadFile(cachePath); err == nil && json.Unmarshal(data, &cached) == nil {
+			return cached, nil
+		}
+	}
+
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, prefix := range listedPrefixes(depths) {
+		listed, err := repo.ListDirectories(ctx, "HEAD", prefix, depths[prefix])
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range listed {
+			if !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	scopes := sortScopes(matchDirs(dirs, patterns))
+
+	if cachePath != "" {
+		if data, err := json.Marshal(scopes); err == nil {
+			WriteCache(cachePath, data, scopeCacheSize)
+		}
+	}
+	return scopes, nil
+}
+
+// patternPrefix returns the leading directories of the pattern that contain no
+// wildcards, like "packages" for "packages/*" and "" for "*"
+func patternPrefix(pattern string) string {
+	parts := strings.Split(pattern, "/")
+	n := 0
+	for n < len(parts)-1 && !strings.ContainsAny(parts[n], `*?[\`) {
+		n++
+	}
+	return strings.Join(parts[:n], "/")
+}
+
+// listedPrefixes returns the prefixes whose directories must be listed, in
+// sorted order, leaving out those whose directories are listed with a shorter
+// prefix already
+func listedPrefixes(depths map[string]int) []string {
+	var prefixes []string
+	for prefix, depth := range depths {
+		covered := false
+		for other, otherDepth := range depths {
+			if other != prefix && otherDepth >= depth && (other == "" || strings.HasPrefix(prefix, other+"/")) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
+
+// matchDirs returns the directories that match any of the patterns
+func matchDirs(dirs, patterns []string) []string {
+	matched := []string{}
+	for _, dir := range dirs {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, dir); ok {
+				matched = append(matched, dir)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// sortScopes sorts the common directories first and the others by path
+func sortScopes(dirs []string) []string {
+	rank := func(dir string) int {
+		for i, common := range commonScopeDirs {
+			if dir == common {
+				return i
+			}
+		}
+		return len(commonScopeDirs)
+	}
+	sort.SliceStable(dirs, func(i, j int) bool {
+		ri, rj := rank(dirs[i]), rank(dirs[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return dirs[i] < dirs[j]
+	})
+	return dirs
+}
+
+// fileDirs returns the distinct directories of the given files, up to maxDepth
+// levels deep (zero means all levels)
+func fileDirs(files []string, maxDepth int) []string {
+	found := make(map[string]bool)
+	for _, file := range files {
+		dir := path.Dir(file)
+		for dir != "." {
+			if maxDepth <= 0 || strings.Count(dir, "/") < maxDepth {
+				found[dir] = true
+			}
+			dir = path.Dir(dir)
+		}
+	}
+
+	dirs := make([]string, 0, len(found))
+	for dir := range found {
+		dirs = append(dirs, dir)
+	}
+	// Sort like git, which compares directory names with a trailing slash
+	sort.Slice(dirs, func(i, j int) bool {
+		return dirs[i]+"/" < dirs[j]+"/"
+	})
+	return dirs
+}
+
+// pathDepth returns the number of elements of a slash-separated path; zero for
+// an empty path
+func pathDepth(p string) int {
+	if p == "" {
+		return 0
+	}
+	return strings.Count(p, "/") + 1
+}
@@ -0,0 +1,188 @@
+// Package history keeps the types, scopes and subjects of the messages
+// committed in a repository, to order the choices by use.
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/a1yama/git-cz-go/internal/git"
+	"github.com/a1yama/git-cz-go/internal/model"
+)
+
+// File is the path of the history below the git directory
+const File = "git-cz/history.json"
+
+// MaxEntries is the number of messages that are kept, and read from the log
+// when there is no history yet
+const MaxEntries = 500
+
+// halfLife is the number of later messages after which a use counts half
+const halfLife = 50
+
+// Entry is the header of a committed message
+type Entry struct {
+	Type    string `json:"type"`
+	Scope   string `json:"scope,omitempty"`
+	Subject string `json:"subject"`
+}
+
+// History is the committed messages of a repository, newest first
+type History struct {
+	Entries []Entry
+	// path is the file the history is saved to, or "" if it is not saved
+	path string
+}
+
+// Load reads the history stored in the git directory. On first use it is
+// seeded with the conventional commits in the last MaxEntries commits of the
+// log and saved. Without a git directory, like for repositories in memory, it
+// is only read from the log.
+func Load(ctx context.Context, repo git.Repository) (*History, error) {
+	h, found, err := open(ctx, repo)
+	if err != nil || found {
+		return h, err
+	}
+	log, err := repo.GetLog(ctx, MaxEntries)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		// No commit yet
+		return h, nil
+	}
+	h.seed(log)
+	return h, nil
+}
+
+// LoadWithLog is like Load, but seeds the history with a log that has been
+// read already, newest first, instead of reading it again
+func LoadWithLog(ctx context.Context, repo git.Repository, log []git.LogEntry) (*History, error) {
+	h, found, err := open(ctx, repo)
+	if err != nil || found {
+		return h, err
+	}
+	h.seed(log)
+	return h, nil
+}
+
+// open reads the history file, if there is one, and reports whether it was found
+func open(ctx context.Context, repo git.Repository) (*History, bool, error) {
+	h := &History{}
+	gitDir, err := repo.GetGitCommonDir(ctx)
+	if err != nil || gitDir == "" {
+		return h, false, nil
+	}
+	h.path = filepath.Join(gitDir, filepath.FromSlash(File))
+	data, err := os.ReadFile(h.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if err := json.Unmarshal(data, &h.Entries); err != nil {
+		return nil, false, fmt.Errorf("%s: %w", h.path, err)
+	}
+	return h, true, nil
+}
+
+// seed fills the history with the conventional commits of the log and saves it
+func (h *History) seed(log []git.LogEntry) {
+	if len(log) > MaxEntries {
+		log = log[:MaxEntries]
+	}
+	for _, commit := range log {
+		if msg, ok := model.ParseHeader(commit.Subject()); ok {
+			h.Entries = append(h.Entries, entry(msg))
+		}
+	}
+
+	// The history is only a convenience, so failing to save it is not an error
+	_ = h.save()
+}
+
+// entry returns the entry for a message
+func entry(msg model.CommitMessage) Entry {
+	return Entry{Type: msg.Type, Scope: msg.Scope, Subject: msg.Subject}
+}
+
+// Add records the committed messages, in the order they were committed, and
+// saves the history
+func (h *History) Add(messages ...model.CommitMessage) error {
+	for _, msg := range messages {
+		h.Entries = append([]Entry{entry(msg)}, h.Entries...)
+	}
+	if len(h.Entries) > MaxEntries {
+		h.Entries = h.Entries[:MaxEntries]
+	}
+	return h.save()
+}
+
+// save writes the history to its file, if it has one
+func (h *History) save() error {
+	if h.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(h.Entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(h.path, data, 0644)
+}
+
+// Types returns the used types, most used first
+func (h *History) Types() []string {
+	return h.rank(func(e Entry) string { return e.Type })
+}
+
+// Scopes returns the used scopes, most used first
+func (h *History) Scopes() []string {
+	return h.rank(func(e Entry) string { return e.Scope })
+}
+
+// rank returns the non-empty values of the entries by their uses, each
+// weighted by its recency so that a value used a lot long ago ranks below
+// one used a few times lately
+func (h *History) rank(value func(Entry) string) []string {
+	scores := make(map[string]float64)
+	var values []string
+	for i, e := range h.Entries {
+		v := value(e)
+		if v == "" {
+			continue
+		}
+		if _, ok := scores[v]; !ok {
+			values = append(values, v)
+		}
+		scores[v] += math.Pow(0.5, float64(i)/halfLife)
+	}
+	// Ties keep the most recent value first
+	sort.SliceStable(values, func(i, j int) bool {
+		return scores[values[i]] > scores[values[j]]
+	})
+	return values
+}
+
+// Subjects returns the distinct subjects, newest first
+func (h *History) Subjects() []string {
+	seen := make(map[string]bool)
+	var subjects []string
+	for _, e := range h.Entries {
+		if e.Subject != "" && !seen[e.Subject] {
+			seen[e.Subject] = true
+			subjects = append(subjects, e.Subject)
+		}
+	}
+	return subjects
+}
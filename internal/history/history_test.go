@@ -0,0 +1,114 @@
+package history
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/a1yama/git-cz-go/internal/git"
+	"github.com/a1yama/git-cz-go/internal/model"
+)
+
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+	repo := git.NewMemoryRepository("/repo")
+	repo.CommonDir = t.TempDir()
+	repo.Commits = []git.LogEntry{
+		{Hash: "c4", Message: "fix(ui): keep the cursor\n\nbody"},
+		{Hash: "c3", Message: "Merge branch 'topic'"},
+		{Hash: "c2", Message: "✨ feat(git): add log"},
+		{Hash: "c1", Message: "init"},
+	}
+
+	h, err := Load(ctx, repo)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []Entry{{Type: "fix", Scope: "ui", Subject: "keep the cursor"}, {Type: "feat", Scope: "git", Subject: "add log"}}
+	if !reflect.DeepEqual(h.Entries, want) {
+		t.Errorf("Entries = %+v, want the conventional commits of the log %+v", h.Entries, want)
+	}
+
+	if err := h.Add(model.CommitMessage{Type: "docs", Subject: "explain history"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	// Once saved, the history is read from its file rather than the log
+	repo.Commits = nil
+	h, err = Load(ctx, repo)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(h.Entries) != 3 || h.Entries[0].Type != "docs" {
+		t.Errorf("Entries = %+v, want the added message first", h.Entries)
+	}
+}
+
+func TestLoadWithoutCommits(t *testing.T) {
+	repo := git.NewMemoryRepository("/repo")
+	repo.CommonDir = t.TempDir()
+	h, err := Load(context.Background(), repo)
+	if err != nil || len(h.Entries) != 0 {
+		t.Errorf("Load() = %+v, %v, want an empty history", h, err)
+	}
+}
+
+func TestLoadWithoutGitDir(t *testing.T) {
+	// Without a git directory the history is read from the log and not saved
+	repo := git.NewMemoryRepository("/repo")
+	repo.Commits = []git.LogEntry{{Hash: "c1", Message: "feat: add log"}}
+	h, err := Load(context.Background(), repo)
+	if err != nil || len(h.Entries) != 1 {
+		t.Fatalf("Load() = %+v, %v, want the commit of the log", h, err)
+	}
+	if err := h.Add(model.CommitMessage{Type: "docs", Subject: "explain history"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if h, _ := Load(context.Background(), repo); len(h.Entries) != 1 {
+		t.Errorf("Entries = %+v, want nothing saved", h.Entries)
+	}
+}
+
+func TestLoadWithLog(t *testing.T) {
+	ctx := context.Background()
+	repo := git.NewMemoryRepository("/repo")
+	repo.CommonDir = t.TempDir()
+	repo.Commits = []git.LogEntry{{Hash: "c2", Message: "docs: not read"}}
+
+	// The given log seeds the history instead of the one of the repository
+	log := []git.LogEntry{{Hash: "c1", Message: "feat(git): add log"}}
+	h, err := LoadWithLog(ctx, repo, log)
+	if want := []Entry{{Type: "feat", Scope: "git", Subject: "add log"}}; err != nil || !reflect.DeepEqual(h.Entries, want) {
+		t.Fatalf("LoadWithLog() = %+v, %v, want %+v", h, err, want)
+	}
+
+	// Once saved, the log is not used
+	if h, err := LoadWithLog(ctx, repo, nil); err != nil || len(h.Entries) != 1 {
+		t.Errorf("LoadWithLog() = %+v, %v, want the saved history", h, err)
+	}
+}
+
+func TestRank(t *testing.T) {
+	h := &History{Entries: []Entry{
+		{Type: "fix", Scope: "ui", Subject: "keep the cursor"},
+		{Type: "feat", Scope: "git", Subject: "add log"},
+		{Type: "fix", Scope: "ui", Subject: "keep the cursor"},
+	}}
+	for i := 0; i < 197; i++ {
+		h.Entries = append(h.Entries, Entry{Type: "chore", Subject: "tidy"})
+	}
+	// Used more often than feat, but long ago
+	for i := 0; i < 4; i++ {
+		h.Entries = append(h.Entries, Entry{Type: "style", Subject: "format"})
+	}
+
+	if got, want := h.Types(), []string{"chore", "fix", "feat", "style"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Types() = %v, want %v", got, want)
+	}
+	if got, want := h.Scopes(), []string{"ui", "git"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Scopes() = %v, want %v", got, want)
+	}
+	if got, want := h.Subjects(), []string{"keep the cursor", "add log", "tidy", "format"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Subjects() = %v, want %v", got, want)
+	}
+}
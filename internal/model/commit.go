@@ -1,10 +1,23 @@
 package model
 
+import "regexp"
+
+// headerPattern matches a conventional commit header, with an optional emoji
+// or :shortcode: before the type
+var headerPattern = regexp.MustCompile(`^(?:(:\w+:|[^\x00-\x7F]+)\s+)?(\w[\w-]*)(?:\(([^()]*)\))?(!)?: +(\S.*)$`)
+
 // CommitMessage represents a conventional commit message structure
 type CommitMessage struct {
 	Type    string
+	Scope   string
 	Subject string
 	Emoji   string
+	// Body is the optional text after the header, separated by a blank line
+	Body string
+	// Breaking marks the header with "!"; BreakingChange describes the
+	// change in a BREAKING CHANGE footer, if set
+	Breaking       bool
+	BreakingChange string
 }
 
 // ValidateSubject checks if the subject is valid
@@ -14,8 +27,8 @@ func (c *CommitMessage) ValidateSubject(maxLength int) bool {
 	}
 
 	// Calculate the length of the complete subject line
-	// type: subject
-	prefix := c.Type + ": "
+	// type(scope): subject
+	prefix := c.prefix() + ": "
 	totalLength := len(prefix) + len(c.Subject)
 	if c.Emoji != "" {
 		totalLength += len(c.Emoji) + 1 // +1 for the space
@@ -34,8 +47,37 @@ func (c *CommitMessage) Format() string {
 		header += c.Emoji + " "
 	}
 
-	// Add type and subject
-	header += c.Type + ": " + c.Subject
+	// Add type, scope and subject
+	header += c.prefix() + ": " + c.Subject
+
+	message := header
+	if c.Body != "" {
+		message += "\n\n" + c.Body
+	}
+	if c.BreakingChange != "" {
+		message += "\n\nBREAKING CHANGE: " + c.BreakingChange
+	}
+	return message
+}
 
-	return header
+// prefix returns the type with the scope, if any, and the breaking marker
+func (c *CommitMessage) prefix() string {
+	prefix := c.Type
+	if c.Scope != "" {
+		prefix += "(" + c.Scope + ")"
+	}
+	if c.Breaking {
+		prefix += "!"
+	}
+	return prefix
+}
+
+// ParseHeader parses the first line of a conventional commit message like
+// "✨ feat(ui)!: add a step", and reports whether it is one
+func ParseHeader(header string) (CommitMessage, bool) {
+	m := headerPattern.FindStringSubmatch(header)
+	if m == nil {
+		return CommitMessage{}, false
+	}
+	return CommitMessage{Emoji: m[1], Type: m[2], Scope: m[3], Breaking: m[4] != "", Subject: m[5]}, true
 }
@@ -27,6 +27,36 @@ func TestCommitMessageFormat(t *testing.T) {
 			},
 			expected: "🐛 fix: resolve issue",
 		},
+		{
+			name: "With scope",
+			message: CommitMessage{
+				Type:    "feat",
+				Scope:   "ui",
+				Subject: "add scope step",
+			},
+			expected: "feat(ui): add scope step",
+		},
+		{
+			name: "With body",
+			message: CommitMessage{
+				Type:    "build",
+				Scope:   "deps",
+				Subject: "bump 2 dependencies",
+				Body:    "- bump a from v1 to v2\n- bump b from v1 to v2",
+			},
+			expected: "build(deps): bump 2 dependencies\n\n- bump a from v1 to v2\n- bump b from v1 to v2",
+		},
+		{
+			name: "Breaking change",
+			message: CommitMessage{
+				Type:           "feat",
+				Scope:          "api",
+				Subject:        "drop the legacy format",
+				Breaking:       true,
+				BreakingChange: "Format was removed, use Message.Format",
+			},
+			expected: "feat(api)!: drop the legacy format\n\nBREAKING CHANGE: Format was removed, use Message.Format",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -84,3 +114,26 @@ func TestValidateSubject(t *testing.T) {
 		})
 	}
 }
+
+func TestParseHeader(t *testing.T) {
+	testCases := []struct {
+		header string
+		want   CommitMessage
+		ok     bool
+	}{
+		{header: "feat: add new feature", want: CommitMessage{Type: "feat", Subject: "add new feature"}, ok: true},
+		{header: "fix(ui,git)!: drop the old flag", want: CommitMessage{Type: "fix", Scope: "ui,git", Breaking: true, Subject: "drop the old flag"}, ok: true},
+		{header: "🐛 fix: resolve issue", want: CommitMessage{Emoji: "🐛", Type: "fix", Subject: "resolve issue"}, ok: true},
+		{header: ":sparkles: feat(cli): add flag", want: CommitMessage{Emoji: ":sparkles:", Type: "feat", Scope: "cli", Subject: "add flag"}, ok: true},
+		{header: "Merge branch 'main'"},
+		{header: "fix typo: in README"},
+		{header: "feat: "},
+	}
+
+	for _, tc := range testCases {
+		got, ok := ParseHeader(tc.header)
+		if ok != tc.ok || got != tc.want {
+			t.Errorf("ParseHeader(%q) = %+v, %v, want %+v, %v", tc.header, got, ok, tc.want, tc.ok)
+		}
+	}
+}
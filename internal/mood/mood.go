@@ -0,0 +1,171 @@
+// Package mood checks that commit subjects start with a verb in the
+// imperative mood, like "add" rather than "added", "adds" or "adding", using
+// a bundled list of verbs.
+package mood
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// verbs is the bundled list of verbs, in the format described at NewLexicon
+//
+//go:embed verbs.txt
+var verbs string
+
+// Issue is a subject that does not start with an imperative verb
+type Issue struct {
+	// Word is the first word of the subject, like "Added"
+	Word string
+	// Imperative is the imperative form of the word, like "add"
+	Imperative string
+}
+
+// Lexicon maps the past tense, third person and gerund forms of verbs to
+// their imperative form
+type Lexicon struct {
+	forms map[string]string
+	bases map[string]bool
+}
+
+// bundled is the lexicon of the bundled verbs, built once
+var bundled = sync.OnceValue(func() *Lexicon {
+	l := &Lexicon{forms: make(map[string]string), bases: make(map[string]bool)}
+	for _, line := range strings.Split(verbs, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			l.add(line)
+		}
+	}
+	return l
+})
+
+// NewLexicon returns the bundled lexicon with the extra verbs, each given
+// like a line of the bundled list: the base form, followed by the past tense
+// and past participle for irregular verbs, like "dockerize" or "undo undid undone"
+func NewLexicon(extra []string) *Lexicon {
+	if len(extra) == 0 {
+		return bundled()
+	}
+	l := &Lexicon{forms: make(map[string]string), bases: make(map[string]bool)}
+	for form, base := range bundled().forms {
+		l.forms[form] = base
+	}
+	for base := range bundled().bases {
+		l.bases[base] = true
+	}
+	for _, verb := range extra {
+		l.add(verb)
+	}
+	return l
+}
+
+// add adds a verb given like a line of the bundled list
+func (l *Lexicon) add(verb string) {
+	fields := strings.Fields(strings.ToLower(verb))
+	if len(fields) == 0 {
+		return
+	}
+	base := fields[0]
+	l.bases[base] = true
+	// A base form is imperative even if it is another verb's form too, like
+	// "read" or "set"
+	delete(l.forms, base)
+
+	forms := append(thirdPerson(base), gerunds(base)...)
+	if len(fields) > 1 {
+		forms = append(forms, fields[1:]...)
+	} else {
+		forms = append(forms, pastTenses(base)...)
+	}
+	for _, form := range forms {
+		if !l.bases[form] {
+			l.forms[form] = base
+		}
+	}
+}
+
+// Check reports whether the subject starts with a known verb in another form
+// than the imperative
+func (l *Lexicon) Check(subject string) (Issue, bool) {
+	word := firstWord(subject)
+	if base, ok := l.forms[strings.ToLower(word)]; ok {
+		return Issue{Word: word, Imperative: base}, true
+	}
+	return Issue{}, false
+}
+
+// Fix replaces the first word of the subject with its imperative form,
+// keeping a capital first letter
+func (i Issue) Fix(subject string) string {
+	imperative := i.Imperative
+	if first := []rune(i.Word); len(first) > 0 && unicode.IsUpper(first[0]) {
+		imperative = strings.ToUpper(imperative[:1]) + imperative[1:]
+	}
+	return imperative + strings.TrimPrefix(subject, i.Word)
+}
+
+// firstWord returns the letters the subject starts with
+func firstWord(subject string) string {
+	end := strings.IndexFunc(subject, func(r rune) bool { return !unicode.IsLetter(r) })
+	if end < 0 {
+		return subject
+	}
+	return subject[:end]
+}
+
+// isVowel reports whether the letter is a vowel
+func isVowel(b byte) bool {
+	return strings.IndexByte("aeiou", b) >= 0
+}
+
+// doubles reports whether the final consonant of the verb may be doubled
+// before a suffix, like in "stopped" or "committed". Both spellings are
+// derived for such verbs, since a wrong one never appears in a subject.
+func doubles(verb string) bool {
+	n := len(verb)
+	return n >= 3 && !isVowel(verb[n-1]) && strings.IndexByte("wxy", verb[n-1]) < 0 &&
+		isVowel(verb[n-2]) && !isVowel(verb[n-3])
+}
+
+// thirdPerson returns the third person singular of the verb, like "fixes"
+func thirdPerson(verb string) []string {
+	n := len(verb)
+	switch {
+	case strings.HasSuffix(verb, "s") || strings.HasSuffix(verb, "x") || strings.HasSuffix(verb, "z") ||
+		strings.HasSuffix(verb, "ch") || strings.HasSuffix(verb, "sh") || strings.HasSuffix(verb, "o"):
+		return []string{verb + "es"}
+	case n >= 2 && verb[n-1] == 'y' && !isVowel(verb[n-2]):
+		return []string{verb[:n-1] + "ies"}
+	}
+	return []string{verb + "s"}
+}
+
+// pastTenses returns the regular past tense of the verb, like "added"
+func pastTenses(verb string) []string {
+	n := len(verb)
+	switch {
+	case strings.HasSuffix(verb, "e"):
+		return []string{verb + "d"}
+	case n >= 2 && verb[n-1] == 'y' && !isVowel(verb[n-2]):
+		return []string{verb[:n-1] + "ied"}
+	case doubles(verb):
+		return []string{verb + "ed", verb + verb[n-1:] + "ed"}
+	}
+	return []string{verb + "ed"}
+}
+
+// gerunds returns the present participle of the verb, like "adding"
+func gerunds(verb string) []string {
+	n := len(verb)
+	switch {
+	case strings.HasSuffix(verb, "ie"):
+		return []string{verb[:n-2] + "ying"}
+	case strings.HasSuffix(verb, "e") && !strings.HasSuffix(verb, "ee") && !strings.HasSuffix(verb, "ye") && !strings.HasSuffix(verb, "oe"):
+		return []string{verb[:n-1] + "ing"}
+	case doubles(verb):
+		return []string{verb + "ing", verb + verb[n-1:] + "ing"}
+	}
+	return []string{verb + "ing"}
+}
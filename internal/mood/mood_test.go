@@ -0,0 +1,52 @@
+package mood
+
+import "testing"
+
+func TestCheck(t *testing.T) {
+	lexicon := NewLexicon([]string{"dockerize", "undo undid undone"})
+
+	testCases := []struct {
+		subject string
+		want    string
+		fixed   string
+	}{
+		{subject: "added DetectScopes caching", want: "add", fixed: "add DetectScopes caching"},
+		{subject: "Fixes the cursor", want: "fix", fixed: "Fix the cursor"},
+		{subject: "updating deps", want: "update", fixed: "update deps"},
+		{subject: "stopped the spinner", want: "stop"},
+		{subject: "committed files are listed", want: "commit"},
+		{subject: "applies the patch", want: "apply"},
+		{subject: "wrote docs", want: "write"},
+		{subject: "built the binary", want: "build"},
+		{subject: "dockerized the build", want: "dockerize"},
+		{subject: "undid the rename", want: "undo"},
+		{subject: "caching: speed up scopes", want: "cache"},
+		// Imperative, unknown or identical forms
+		{subject: "add DetectScopes caching"},
+		{subject: "read the index once"},
+		{subject: "set the timeout"},
+		{subject: "string helpers are faster"},
+		{subject: ""},
+	}
+
+	for _, tc := range testCases {
+		issue, ok := lexicon.Check(tc.subject)
+		if ok != (tc.want != "") || issue.Imperative != tc.want {
+			t.Errorf("Check(%q) = %+v, %v, want %q", tc.subject, issue, ok, tc.want)
+			continue
+		}
+		if tc.fixed != "" {
+			if got := issue.Fix(tc.subject); got != tc.fixed {
+				t.Errorf("Fix(%q) = %q, want %q", tc.subject, got, tc.fixed)
+			}
+		}
+	}
+}
+
+func TestBundledLexicon(t *testing.T) {
+	// Extra verbs do not leak into the bundled lexicon
+	NewLexicon([]string{"dockerize"})
+	if _, ok := NewLexicon(nil).Check("dockerized"); ok {
+		t.Error("Check() knows an extra verb of another lexicon")
+	}
+}
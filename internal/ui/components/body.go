@@ -0,0 +1,77 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/ui/styles"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// BodySubmittedMsg is sent when the body is submitted, empty if it was skipped
+type BodySubmittedMsg struct {
+	Body string
+}
+
+// BodyModel handles the optional commit body input. Enter submits the body,
+// so new lines are inserted with Ctrl+J or Alt+Enter.
+type BodyModel struct {
+	textarea textarea.Model
+	// touched is set once the user typed, so that a prefilled body does not
+	// replace their text
+	touched bool
+}
+
+// NewBodyModel creates a new body model
+func NewBodyModel() BodyModel {
+	ta := textarea.New()
+	ta.Placeholder = "Explain what changed and why (optional)"
+	ta.ShowLineNumbers = false
+	ta.CharLimit = 0
+	ta.KeyMap.InsertNewline = key.NewBinding(key.WithKeys("ctrl+j", "alt+enter"))
+	ta.SetWidth(72)
+	ta.SetHeight(6)
+	ta.Focus()
+
+	return BodyModel{textarea: ta}
+}
+
+// Prefill sets the body proposed for the staged changes, unless the user
+// already typed one
+func (m *BodyModel) Prefill(body string) {
+	if m.touched {
+		return
+	}
+	m.textarea.SetValue(body)
+}
+
+// Init initializes the model
+func (m BodyModel) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+// Update handles updates for the model
+func (m BodyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.textarea.SetWidth(min(max(msg.Width-4, 20), 100))
+
+	case tea.KeyMsg:
+		if msg.String() == "enter" {
+			body := strings.TrimSpace(m.textarea.Value())
+			return m, func() tea.Msg { return BodySubmittedMsg{Body: body} }
+		}
+		m.touched = true
+	}
+
+	var cmd tea.Cmd
+	m.textarea, cmd = m.textarea.Update(msg)
+	return m, cmd
+}
+
+// View renders the model
+func (m BodyModel) View() string {
+	return m.textarea.View() + "\n\n" +
+		styles.HelpStyle.Render("Enter: Continue, leave empty to skip • Ctrl+J/Alt+Enter: New line")
+}
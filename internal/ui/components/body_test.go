@@ -0,0 +1,31 @@
+package components
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestBodyModelSubmit(t *testing.T) {
+	model := NewBodyModel()
+	model.Prefill("- bump a")
+
+	var updated tea.Model = model
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyCtrlJ})
+	for _, r := range "- bump b" {
+		updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+
+	// Typing keeps a later proposal from replacing the text
+	body := updated.(BodyModel)
+	body.Prefill("something else")
+
+	_, cmd := body.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("Enter did not submit the body")
+	}
+	msg, ok := cmd().(BodySubmittedMsg)
+	if !ok || msg.Body != "- bump a\n- bump b" {
+		t.Errorf("Enter sent %#v, want the body with both lines", msg)
+	}
+}
@@ -0,0 +1,94 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/ui/styles"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// BreakingSubmittedMsg is sent when the breaking change step is done. A
+// description makes the change breaking even if it was not marked.
+type BreakingSubmittedMsg struct {
+	Breaking    bool
+	Description string
+}
+
+// BreakingModel marks the change as breaking and describes it for the
+// BREAKING CHANGE footer
+type BreakingModel struct {
+	breaking bool
+	textarea textarea.Model
+	// touched is set once the user toggled the marker or typed, so that a
+	// prefilled description does not replace their choice
+	touched bool
+}
+
+// NewBreakingModel creates a new breaking change model
+func NewBreakingModel() BreakingModel {
+	ta := textarea.New()
+	ta.Placeholder = "Describe what breaks and how to migrate (optional)"
+	ta.ShowLineNumbers = false
+	ta.CharLimit = 0
+	ta.KeyMap.InsertNewline = key.NewBinding(key.WithKeys("ctrl+j", "alt+enter"))
+	ta.SetWidth(72)
+	ta.SetHeight(6)
+	ta.Focus()
+
+	return BreakingModel{textarea: ta}
+}
+
+// Prefill marks the change as breaking with the description generated for
+// the staged changes, or clears a generated description that no longer
+// applies, unless the user already made a choice
+func (m *BreakingModel) Prefill(description string) {
+	if m.touched {
+		return
+	}
+	m.breaking = description != ""
+	m.textarea.SetValue(description)
+}
+
+// Init initializes the model
+func (m BreakingModel) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+// Update handles updates for the model
+func (m BreakingModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.textarea.SetWidth(min(max(msg.Width-4, 20), 100))
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			description := strings.TrimSpace(m.textarea.Value())
+			breaking := m.breaking || description != ""
+			return m, func() tea.Msg {
+				return BreakingSubmittedMsg{Breaking: breaking, Description: description}
+			}
+		case "tab":
+			m.breaking = !m.breaking
+			m.touched = true
+			return m, nil
+		}
+		m.touched = true
+	}
+
+	var cmd tea.Cmd
+	m.textarea, cmd = m.textarea.Update(msg)
+	return m, cmd
+}
+
+// View renders the model
+func (m BreakingModel) View() string {
+	marker := styles.BlurredStyle.Render("[ ] Breaking change")
+	if m.breaking {
+		marker = styles.FocusedStyle.Render("[x] Breaking change, the header is marked with !")
+	}
+	return marker + "\n\n" + m.textarea.View() + "\n\n" +
+		styles.HelpStyle.Render("Tab: Toggle • Enter: Continue • Ctrl+J/Alt+Enter: New line")
+}
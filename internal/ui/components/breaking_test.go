@@ -0,0 +1,35 @@
+package components
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestBreakingModel(t *testing.T) {
+	model := NewBreakingModel()
+	model.Prefill("api: removed function Old")
+
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if msg := cmd().(BreakingSubmittedMsg); !msg.Breaking || msg.Description != "api: removed function Old" {
+		t.Errorf("Enter sent %#v, want the prefilled breaking change", msg)
+	}
+
+	// Tab unmarks the change, the description is then up to the user
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyTab})
+	breaking := updated.(BreakingModel)
+	breaking.Prefill("api: removed function Other")
+	updated, _ = breaking.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+	for _, r := range "only internal" {
+		updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	_, cmd = updated.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if msg := cmd().(BreakingSubmittedMsg); !msg.Breaking {
+		t.Errorf("Enter sent %#v, want a description to mark the change as breaking", msg)
+	}
+
+	_, cmd = NewBreakingModel().Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if msg := cmd().(BreakingSubmittedMsg); msg.Breaking || msg.Description != "" {
+		t.Errorf("Enter sent %#v, want no breaking change by default", msg)
+	}
+}
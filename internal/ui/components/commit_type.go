@@ -18,6 +18,8 @@ type commitTypeItem struct {
 	description string
 	emoji       string
 	useEmoji    bool
+	// reason is set when the type is suggested for the staged files
+	reason string
 }
 
 // FilterValue implements list.Item
@@ -25,10 +27,14 @@ func (i commitTypeItem) FilterValue() string { return i.type_ + " " + i.descript
 
 // Title returns the title for the list item
 func (i commitTypeItem) Title() string {
+	title := i.type_
 	if i.useEmoji && i.emoji != "" {
-		return i.emoji + "  " + i.type_
+		title = i.emoji + "  " + i.type_
 	}
-	return i.type_
+	if i.reason != "" {
+		title += "  ← suggested: " + i.reason
+	}
+	return title
 }
 
 // Description returns the description for the list item
@@ -37,6 +43,9 @@ func (i commitTypeItem) Description() string { return i.description }
 // CommitTypeModel handles the commit type selection
 type CommitTypeModel struct {
 	list list.Model
+	// touched is set once the user has pressed a key, so that a suggestion
+	// arriving later does not move the selection
+	touched bool
 }
 
 // NewCommitTypeModel creates a new commit type model
@@ -68,6 +77,42 @@ func NewCommitTypeModel(types []config.CommitType, useEmoji bool) CommitTypeMode
 	}
 }
 
+// Suggest marks the suggested type with its reason and selects it, unless
+// the user has already moved in the list
+func (m *CommitTypeModel) Suggest(suggestion config.TypeSuggestion) {
+	items := m.list.Items()
+	for i, item := range items {
+		t := item.(commitTypeItem)
+		t.reason = ""
+		if t.type_ == suggestion.Type {
+			t.reason = suggestion.Reason
+			if !m.touched && m.list.FilterState() == list.Unfiltered {
+				m.list.Select(i)
+			}
+		}
+		m.list.SetItem(i, t)
+	}
+}
+
+// Highlighted returns the type under the cursor, or "" if the list is empty
+func (m CommitTypeModel) Highlighted() string {
+	if item, ok := m.list.SelectedItem().(commitTypeItem); ok {
+		return item.type_
+	}
+	return ""
+}
+
+// Touched reports whether the user has pressed a key in the list, moving
+// the cursor or filtering
+func (m CommitTypeModel) Touched() bool {
+	return m.touched
+}
+
+// Filtering reports whether the filter is being typed
+func (m CommitTypeModel) Filtering() bool {
+	return m.list.FilterState() == list.Filtering
+}
+
 // Init initializes the model
 func (m CommitTypeModel) Init() tea.Cmd {
 	return nil
@@ -82,6 +127,7 @@ func (m CommitTypeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		m.touched = true
 		// Check for enter to select an item
 		if msg.String() == "enter" {
 			i, ok := m.list.SelectedItem().(commitTypeItem)
@@ -1,6 +1,7 @@
 package components
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/a1yama/git-cz-go/internal/config"
@@ -59,3 +60,31 @@ func TestCommitTypeModelUpdate(t *testing.T) {
 		t.Error("Update() returned a model that is not a CommitTypeModel")
 	}
 }
+
+func TestCommitTypeModelSuggest(t *testing.T) {
+	types := []config.CommitType{
+		{Type: "feat", Description: "A new feature"},
+		{Type: "docs", Description: "Documentation only changes"},
+	}
+	model := NewCommitTypeModel(types, false)
+	model.Suggest(config.TypeSuggestion{Type: "docs", Reason: "all staged files match *.md"})
+
+	if !strings.Contains(model.View(), "docs  ← suggested: all staged files match *.md") {
+		t.Errorf("View() does not show the suggestion:\n%s", model.View())
+	}
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if msg, ok := cmd().(CommitTypeSelectedMsg); !ok || msg.Type != "docs" {
+		t.Errorf("Enter selected %#v, want docs", cmd())
+	}
+
+	// Once the user has moved, a suggestion does not change the selection
+	model = NewCommitTypeModel(types, false)
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyUp})
+	model = updated.(CommitTypeModel)
+	model.Suggest(config.TypeSuggestion{Type: "docs", Reason: "all staged files match *.md"})
+	_, cmd = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if msg, ok := cmd().(CommitTypeSelectedMsg); !ok || msg.Type != "feat" {
+		t.Errorf("Enter selected %#v after moving, want feat", cmd())
+	}
+}
@@ -0,0 +1,93 @@
+package components
+
+import (
+	"path/filepath"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ConfigChosenMsg is sent when one of several conflicting config files is chosen
+type ConfigChosenMsg struct {
+	Path string
+}
+
+// configItem represents a config file in the list
+type configItem struct {
+	path string
+	rel  string
+}
+
+// FilterValue implements list.Item
+func (i configItem) FilterValue() string { return i.rel }
+
+// Title returns the title for the list item
+func (i configItem) Title() string { return i.rel }
+
+// Description returns the description for the list item
+func (i configItem) Description() string { return "Use the settings from this file" }
+
+// ConfigChoiceModel lets the user pick a config file when the staged files
+// span nested configs that cannot be merged
+type ConfigChoiceModel struct {
+	list list.Model
+}
+
+// NewConfigChoiceModel creates a new config choice model.
+// Paths are shown relative to root.
+func NewConfigChoiceModel(root string, paths []string) ConfigChoiceModel {
+	items := make([]list.Item, len(paths))
+	for i, p := range paths {
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			rel = p
+		}
+		items[i] = configItem{path: p, rel: rel}
+	}
+
+	listModel := list.New(items, list.NewDefaultDelegate(), 80, 15)
+	listModel.Title = "Staged files use conflicting configs"
+	listModel.SetShowHelp(false)
+	listModel.SetFilteringEnabled(false)
+	listModel.Styles.Title = lipgloss.NewStyle().MarginLeft(2).Bold(true)
+	listModel.Styles.PaginationStyle = lipgloss.NewStyle().Padding(0, 2)
+
+	return ConfigChoiceModel{
+		list: listModel,
+	}
+}
+
+// Init initializes the model
+func (m ConfigChoiceModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles updates for the model
+func (m ConfigChoiceModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.list.SetWidth(msg.Width - 4)
+		m.list.SetHeight(msg.Height - 10)
+		return m, nil
+
+	case tea.KeyMsg:
+		if msg.String() == "enter" {
+			i, ok := m.list.SelectedItem().(configItem)
+			if ok {
+				return m, func() tea.Msg {
+					return ConfigChosenMsg{Path: i.path}
+				}
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+// View renders the model
+func (m ConfigChoiceModel) View() string {
+	return m.list.View()
+}
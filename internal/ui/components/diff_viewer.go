@@ -0,0 +1,386 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/diff"
+	"github.com/a1yama/git-cz-go/internal/ui/styles"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// DiffClosedMsg is sent when the user leaves the diff viewer
+type DiffClosedMsg struct{}
+
+// diffLineKind is the kind of a rendered diff line
+type diffLineKind int
+
+const (
+	diffFileLine diffLineKind = iota
+	diffMetaLine
+	diffHunkLine
+	diffContextLine
+	diffAddedLine
+	diffRemovedLine
+)
+
+// diffLine is a line of the rendered diff before styling
+type diffLine struct {
+	kind diffLineKind
+	text string
+	file int
+}
+
+// DiffViewerModel shows a scrollable, searchable diff
+type DiffViewerModel struct {
+	files      []diff.File
+	lines      []diffLine
+	fileStarts []int
+	hunkStarts []int
+	loaded     bool
+	err        error
+
+	viewport viewport.Model
+	width    int
+	height   int
+
+	// search is the input shown after "/"; query is the last submitted search
+	search    textinput.Model
+	searching bool
+	query     string
+	matches   []int
+
+	// jumping is set while the file list is shown
+	jumping    bool
+	jumpCursor int
+}
+
+// NewDiffViewerModel creates a diff viewer that shows a loading message until SetDiff is called
+func NewDiffViewerModel() DiffViewerModel {
+	search := textinput.New()
+	search.Prompt = "/"
+	search.Placeholder = "search"
+
+	return DiffViewerModel{
+		viewport: viewport.New(80, 20),
+		search:   search,
+		width:    80,
+		height:   24,
+	}
+}
+
+// SetDiff sets the files to show
+func (m *DiffViewerModel) SetDiff(files []diff.File) {
+	m.files = files
+	m.loaded = true
+	m.lines, m.fileStarts, m.hunkStarts = diffLines(files)
+	m.render()
+}
+
+// SetError shows an error instead of the diff
+func (m *DiffViewerModel) SetError(err error) {
+	m.err = err
+	m.loaded = true
+}
+
+// diffLines flattens the files into lines, recording where files and hunks start
+func diffLines(files []diff.File) ([]diffLine, []int, []int) {
+	var lines []diffLine
+	var fileStarts, hunkStarts []int
+
+	for i, f := range files {
+		fileStarts = append(fileStarts, len(lines))
+		title := f.Path()
+		switch {
+		case f.OldPath == "":
+			title += " (new)"
+		case f.NewPath == "":
+			title += " (deleted)"
+		case f.OldPath != f.NewPath:
+			title = f.OldPath + " → " + f.NewPath
+		}
+		lines = append(lines, diffLine{kind: diffFileLine, text: title, file: i})
+		if f.Binary {
+			lines = append(lines, diffLine{kind: diffMetaLine, text: "Binary file", file: i})
+		}
+
+		for _, h := range f.Hunks {
+			hunkStarts = append(hunkStarts, len(lines))
+			lines = append(lines, diffLine{kind: diffHunkLine, text: h.Header, file: i})
+			for _, l := range h.Lines {
+				line := diffLine{kind: diffContextLine, text: " " + l.Text, file: i}
+				switch l.Kind {
+				case diff.Added:
+					line.kind, line.text = diffAddedLine, "+"+l.Text
+				case diff.Removed:
+					line.kind, line.text = diffRemovedLine, "-"+l.Text
+				case diff.NoNewline:
+					line.kind, line.text = diffMetaLine, "\\"+l.Text
+				}
+				lines = append(lines, line)
+			}
+		}
+		lines = append(lines, diffLine{kind: diffContextLine, file: i})
+	}
+	return lines, fileStarts, hunkStarts
+}
+
+// SetSize sets the size of the whole viewer
+func (m *DiffViewerModel) SetSize(width, height int) {
+	m.width, m.height = width, height
+	// One line for the title and one for the status bar
+	m.viewport.Width = width
+	m.viewport.Height = max(height-2, 1)
+	m.search.Width = max(width-4, 1)
+	m.render()
+}
+
+// render styles the lines for the current width and search
+func (m *DiffViewerModel) render() {
+	rendered := make([]string, len(m.lines))
+	for i, line := range m.lines {
+		text := runewidth.Truncate(strings.ReplaceAll(line.text, "\t", "    "), m.width, "…")
+		rendered[i] = highlight(text, m.query, diffLineStyle(line.kind))
+	}
+	m.viewport.SetContent(strings.Join(rendered, "\n"))
+}
+
+// diffLineStyle returns the style of a kind of line
+func diffLineStyle(kind diffLineKind) lipgloss.Style {
+	switch kind {
+	case diffFileLine:
+		return styles.PaneTitleStyle
+	case diffMetaLine:
+		return styles.HelpStyle
+	case diffHunkLine:
+		return styles.InfoStyle
+	case diffAddedLine:
+		return styles.AddedStyle
+	case diffRemovedLine:
+		return styles.RemovedStyle
+	}
+	return styles.BaseStyle
+}
+
+// highlight renders text with the style, marking every case-insensitive match of query
+func highlight(text, query string, style lipgloss.Style) string {
+	if query == "" {
+		return style.Render(text)
+	}
+
+	var sb strings.Builder
+	lower, lowerQuery := strings.ToLower(text), strings.ToLower(query)
+	for {
+		i := strings.Index(lower, lowerQuery)
+		// Lowercasing may change byte lengths; only highlight when it did not
+		if i < 0 || len(lower) != len(text) {
+			sb.WriteString(style.Render(text))
+			return sb.String()
+		}
+		end := i + len(lowerQuery)
+		sb.WriteString(style.Render(text[:i]))
+		sb.WriteString(styles.MatchStyle.Render(text[i:end]))
+		text, lower = text[end:], lower[end:]
+	}
+}
+
+// Init initializes the model
+func (m DiffViewerModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles updates for the model
+func (m DiffViewerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+		if m.searching {
+			m.search, cmd = m.search.Update(msg)
+		}
+		return m, cmd
+	}
+
+	if m.searching {
+		return m.updateSearch(keyMsg)
+	}
+	if m.jumping {
+		return m.updateJump(keyMsg), nil
+	}
+
+	switch keyMsg.String() {
+	case "esc", "q":
+		return m, func() tea.Msg { return DiffClosedMsg{} }
+	case "/":
+		m.searching = true
+		m.search.SetValue("")
+		return m, m.search.Focus()
+	case "n":
+		m.jumpTo(m.matches, 1)
+	case "N":
+		m.jumpTo(m.matches, -1)
+	case "]":
+		m.jumpTo(m.hunkStarts, 1)
+	case "[":
+		m.jumpTo(m.hunkStarts, -1)
+	case "}":
+		m.jumpTo(m.fileStarts, 1)
+	case "{":
+		m.jumpTo(m.fileStarts, -1)
+	case "f":
+		if len(m.files) > 0 {
+			m.jumping = true
+			m.jumpCursor = m.currentFile()
+		}
+	case "g", "home":
+		m.viewport.GotoTop()
+	case "G", "end":
+		m.viewport.GotoBottom()
+	default:
+		var cmd tea.Cmd
+		m.viewport, cmd = m.viewport.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// updateSearch handles keys while the search is being typed
+func (m DiffViewerModel) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.searching = false
+		m.search.Blur()
+		return m, nil
+	case "enter":
+		m.searching = false
+		m.search.Blur()
+		m.query = m.search.Value()
+		m.matches = nil
+		if m.query != "" {
+			lowerQuery := strings.ToLower(m.query)
+			for i, line := range m.lines {
+				if strings.Contains(strings.ToLower(line.text), lowerQuery) {
+					m.matches = append(m.matches, i)
+				}
+			}
+		}
+		m.render()
+		// Start searching at the current line, which may itself match
+		m.viewport.YOffset--
+		m.jumpTo(m.matches, 1)
+		if m.viewport.YOffset < 0 {
+			m.viewport.YOffset = 0
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	return m, cmd
+}
+
+// updateJump handles keys while the file list is shown
+func (m DiffViewerModel) updateJump(msg tea.KeyMsg) DiffViewerModel {
+	switch msg.String() {
+	case "esc", "q", "f":
+		m.jumping = false
+	case "up", "k":
+		if m.jumpCursor > 0 {
+			m.jumpCursor--
+		}
+	case "down", "j":
+		if m.jumpCursor < len(m.files)-1 {
+			m.jumpCursor++
+		}
+	case "enter":
+		m.jumping = false
+		m.viewport.SetYOffset(m.fileStarts[m.jumpCursor])
+	}
+	return m
+}
+
+// jumpTo scrolls to the next (dir 1) or previous (dir -1) of the given line
+// positions, relative to the top line. Positions are sorted.
+func (m *DiffViewerModel) jumpTo(positions []int, dir int) {
+	top := m.viewport.YOffset
+	if dir > 0 {
+		for _, p := range positions {
+			if p > top {
+				m.viewport.SetYOffset(p)
+				return
+			}
+		}
+		return
+	}
+	for i := len(positions) - 1; i >= 0; i-- {
+		if positions[i] < top {
+			m.viewport.SetYOffset(positions[i])
+			return
+		}
+	}
+}
+
+// currentFile returns the index of the file shown at the top
+func (m DiffViewerModel) currentFile() int {
+	top := m.viewport.YOffset
+	if top < len(m.lines) {
+		return m.lines[top].file
+	}
+	return 0
+}
+
+// View renders the model
+func (m DiffViewerModel) View() string {
+	title := styles.HeaderStyle.Copy().MarginBottom(0).Render("Staged changes")
+	switch {
+	case !m.loaded:
+		return title + "\n" + styles.InfoStyle.Render("Loading diff...")
+	case m.err != nil:
+		return title + "\n" + styles.ErrorStyle.Render(fmt.Sprintf("Error: %v", m.err)) + "\n" +
+			styles.HelpStyle.Render("Esc: Back")
+	case len(m.files) == 0:
+		return title + "\n" + styles.WarningStyle.Render("Nothing is staged") + "\n" +
+			styles.HelpStyle.Render("Esc: Back")
+	}
+
+	file := m.currentFile()
+	title += styles.HelpStyle.Render(fmt.Sprintf(" %s (%d/%d)", m.files[file].Path(), file+1, len(m.files)))
+
+	body := m.viewport.View()
+	if m.jumping {
+		body = m.jumpView()
+	}
+
+	status := styles.HelpStyle.Render("↑/↓: Scroll • [/]: Hunks • {/}: Files • f: File list • /: Search • Esc: Back")
+	switch {
+	case m.searching:
+		status = m.search.View()
+	case m.query != "":
+		status = styles.HelpStyle.Render(fmt.Sprintf("/%s: %d matches • n/N: Next/previous • ", m.query, len(m.matches))) + status
+	}
+	return title + "\n" + body + "\n" + status
+}
+
+// jumpView renders the file list
+func (m DiffViewerModel) jumpView() string {
+	lines := make([]string, 0, len(m.files))
+	for i, f := range m.files {
+		line := "  " + f.Path()
+		if i == m.jumpCursor {
+			line = styles.FocusedStyle.Render("> " + f.Path())
+		}
+		lines = append(lines, line)
+	}
+
+	// Keep the cursor visible
+	height := m.viewport.Height
+	start := 0
+	if m.jumpCursor >= height {
+		start = m.jumpCursor - height + 1
+	}
+	end := min(start+height, len(lines))
+	return lipgloss.NewStyle().Height(height).Render(strings.Join(lines[start:end], "\n"))
+}
@@ -0,0 +1,111 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/a1yama/git-cz-go/internal/diff"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const viewerDiff = `diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -1,2 +1,2 @@
+ package a
+-var x = 1
++var x = 2
+diff --git a/b.go b/b.go
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/b.go
+@@ -0,0 +1,2 @@
++package b
++var needle = true
+`
+
+// updateViewer sends messages to the viewer and returns the updated model
+func updateViewer(m DiffViewerModel, msgs ...tea.Msg) (DiffViewerModel, tea.Cmd) {
+	var cmd tea.Cmd
+	for _, msg := range msgs {
+		var updated tea.Model
+		updated, cmd = m.Update(msg)
+		m = updated.(DiffViewerModel)
+	}
+	return m, cmd
+}
+
+func keys(s string) []tea.Msg {
+	var msgs []tea.Msg
+	for _, r := range s {
+		msgs = append(msgs, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return msgs
+}
+
+// visible reports whether a line of the diff is on screen
+func visible(m DiffViewerModel, line int) bool {
+	return line >= m.viewport.YOffset && line < m.viewport.YOffset+m.viewport.Height
+}
+
+func TestDiffViewer(t *testing.T) {
+	files, err := diff.Parse(viewerDiff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewDiffViewerModel()
+	if view := m.View(); !strings.Contains(view, "Loading diff") {
+		t.Errorf("Expected a loading message before the diff is set, got:\n%s", view)
+	}
+
+	m.SetSize(80, 12)
+	m.SetDiff(files)
+	view := m.View()
+	for _, want := range []string{"a.go (1/2)", "@@ -1,2 +1,2 @@", "-var x = 1", "+var x = 2"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() does not contain %q:\n%s", want, view)
+		}
+	}
+
+	// Files and hunks can be jumped to
+	m.SetSize(80, 6)
+	m, _ = updateViewer(m, keys("}")...)
+	if !visible(m, m.fileStarts[1]) {
+		t.Errorf("After } the second file is not shown, top line %d", m.viewport.YOffset)
+	}
+	m, _ = updateViewer(m, keys("g]")...)
+	if got, want := m.viewport.YOffset, m.hunkStarts[0]; got != want {
+		t.Errorf("After ] the top line is %d, want %d", got, want)
+	}
+
+	// The file list jumps to the chosen file
+	m, _ = updateViewer(m, keys("g")...)
+	m, _ = updateViewer(m, append(keys("fj"), tea.KeyMsg{Type: tea.KeyEnter})...)
+	if !visible(m, m.fileStarts[1]) {
+		t.Errorf("After choosing b.go it is not shown, top line %d", m.viewport.YOffset)
+	}
+
+	// Searching is case-insensitive and scrolls to the first match
+	m, _ = updateViewer(m, keys("g")...)
+	m, _ = updateViewer(m, append(keys("/NEEDLE"), tea.KeyMsg{Type: tea.KeyEnter})...)
+	if len(m.matches) != 1 {
+		t.Fatalf("Expected 1 match, got %v", m.matches)
+	}
+	if !visible(m, m.matches[0]) {
+		t.Errorf("After searching the match is not shown, top line %d", m.viewport.YOffset)
+	}
+	if view := m.View(); !strings.Contains(view, "/NEEDLE: 1 matches") {
+		t.Errorf("Expected the search to be shown, got:\n%s", view)
+	}
+
+	_, cmd := updateViewer(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if cmd == nil {
+		t.Fatal("Expected Esc to close the viewer")
+	}
+	if _, ok := cmd().(DiffClosedMsg); !ok {
+		t.Errorf("Expected a DiffClosedMsg, got %T", cmd())
+	}
+}
@@ -0,0 +1,94 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/git"
+	"github.com/a1yama/git-cz-go/internal/model"
+	"github.com/a1yama/git-cz-go/internal/ui/styles"
+	"github.com/mattn/go-runewidth"
+)
+
+// MaxExamples is the number of commits the examples pane shows at most
+const MaxExamples = 5
+
+// example is an earlier commit with a conventional header
+type example struct {
+	header  string
+	message model.CommitMessage
+}
+
+// ExamplesPane shows earlier commits of the highlighted type and scope, as
+// examples of the conventions of the repository
+type ExamplesPane struct {
+	examples []example
+}
+
+// NewExamplesPane creates a pane for the commits of the log, newest first.
+// Commits without a conventional header are left out.
+func NewExamplesPane(commits []git.LogEntry) ExamplesPane {
+	var examples []example
+	for _, commit := range commits {
+		header := commit.Subject()
+		if msg, ok := model.ParseHeader(header); ok {
+			examples = append(examples, example{header: header, message: msg})
+		}
+	}
+	return ExamplesPane{examples: examples}
+}
+
+// Examples returns the headers of up to MaxExamples commits of the type
+// and scope, newest first, and the scope they were found for. When there
+// are none with the scope, the commits of the type with any scope are
+// returned and the scope is "".
+func (p ExamplesPane) Examples(typ, scope string) ([]string, string) {
+	if scope != "" {
+		if headers := p.matching(typ, scope, true); len(headers) > 0 {
+			return headers, scope
+		}
+	}
+	return p.matching(typ, "", false), ""
+}
+
+// matching returns the headers of the commits of the type, with the scope if
+// byScope is set
+func (p ExamplesPane) matching(typ, scope string, byScope bool) []string {
+	var headers []string
+	for _, e := range p.examples {
+		if e.message.Type != typ || (byScope && e.message.Scope != scope) {
+			continue
+		}
+		headers = append(headers, e.header)
+		if len(headers) == MaxExamples {
+			break
+		}
+	}
+	return headers
+}
+
+// View renders the examples of the type and scope within the given width;
+// zero means unlimited
+func (p ExamplesPane) View(typ, scope string, width int) string {
+	style := styles.PaneStyle
+	if width > 0 {
+		style = style.Width(width - style.GetHorizontalFrameSize())
+	}
+	inner := width - style.GetHorizontalFrameSize()
+
+	headers, found := p.Examples(typ, scope)
+	title := typ
+	if found != "" {
+		title += "(" + found + ")"
+	}
+	lines := []string{styles.PaneTitleStyle.Render("Recent " + title + " commits")}
+	if len(headers) == 0 {
+		lines = append(lines, styles.HelpStyle.Render("None yet"))
+	}
+	for _, header := range headers {
+		if inner > 0 {
+			header = runewidth.Truncate(header, inner, "…")
+		}
+		lines = append(lines, header)
+	}
+	return style.Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,44 @@
+package components
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/a1yama/git-cz-go/internal/git"
+)
+
+func TestExamplesPane(t *testing.T) {
+	pane := NewExamplesPane([]git.LogEntry{
+		{Hash: "c5", Message: "feat(ui): add the examples pane\n\nbody"},
+		{Hash: "c4", Message: "Merge branch 'topic'"},
+		{Hash: "c3", Message: "fix(git): keep the index"},
+		{Hash: "c2", Message: "✨ feat(git): read the log"},
+		{Hash: "c1", Message: "feat: init"},
+	})
+
+	testCases := []struct {
+		typ, scope string
+		want       []string
+		wantScope  string
+	}{
+		{typ: "feat", want: []string{"feat(ui): add the examples pane", "✨ feat(git): read the log", "feat: init"}},
+		{typ: "feat", scope: "git", want: []string{"✨ feat(git): read the log"}, wantScope: "git"},
+		// Without commits of the scope, those of the type are shown
+		{typ: "fix", scope: "ui", want: []string{"fix(git): keep the index"}},
+		{typ: "docs"},
+	}
+	for _, tc := range testCases {
+		got, scope := pane.Examples(tc.typ, tc.scope)
+		if !reflect.DeepEqual(got, tc.want) || scope != tc.wantScope {
+			t.Errorf("Examples(%q, %q) = %q, %q, want %q, %q", tc.typ, tc.scope, got, scope, tc.want, tc.wantScope)
+		}
+	}
+
+	if view := pane.View("feat", "git", 40); !strings.Contains(view, "Recent feat(git) commits") {
+		t.Errorf("View() = %q, want the type and scope in the title", view)
+	}
+	if view := pane.View("docs", "", 40); !strings.Contains(view, "None yet") {
+		t.Errorf("View() = %q, want a note that there are no examples", view)
+	}
+}
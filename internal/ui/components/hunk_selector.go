@@ -0,0 +1,291 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/diff"
+	"github.com/a1yama/git-cz-go/internal/ui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+)
+
+// HunksSelectedMsg is sent with the patch of the selected hunks, to be
+// applied to the index
+type HunksSelectedMsg struct {
+	Patch string
+}
+
+// HunksClosedMsg is sent when the hunk selector is left without staging anything
+type HunksClosedMsg struct{}
+
+// hunkState is the selection of a hunk, either as a whole or, once it has
+// been split, per change group
+type hunkState struct {
+	file     int
+	hunk     int
+	split    bool
+	selected []bool
+}
+
+// hunkRow is a row of the list: a whole hunk, or a change group of a split hunk
+type hunkRow struct {
+	state int
+	// group is the change group, or -1 for the whole hunk
+	group int
+}
+
+// HunkSelectorModel lets the user pick the hunks of the unstaged changes to
+// stage, splitting hunks into their change groups where needed
+type HunkSelectorModel struct {
+	files   []diff.File
+	hunks   []hunkState
+	rows    []hunkRow
+	cursor  int
+	skipped int
+	width   int
+	height  int
+}
+
+// NewHunkSelectorModel creates a hunk selector for the unstaged changes.
+// New, deleted, renamed and binary files have no hunks to pick from and are
+// left out; they can be staged as a whole.
+func NewHunkSelectorModel(files []diff.File) HunkSelectorModel {
+	m := HunkSelectorModel{width: 80, height: 20}
+	for _, f := range files {
+		if f.Binary || f.OldPath == "" || f.OldPath != f.NewPath || len(f.Hunks) == 0 {
+			m.skipped++
+			continue
+		}
+		for i, h := range f.Hunks {
+			m.hunks = append(m.hunks, hunkState{file: len(m.files), hunk: i, selected: make([]bool, h.Groups())})
+		}
+		m.files = append(m.files, f)
+	}
+	m.updateRows()
+	return m
+}
+
+// updateRows lists a row per hunk, or per change group of a split hunk
+func (m *HunkSelectorModel) updateRows() {
+	m.rows = m.rows[:0]
+	for i, h := range m.hunks {
+		if !h.split {
+			m.rows = append(m.rows, hunkRow{state: i, group: -1})
+			continue
+		}
+		for g := range h.selected {
+			m.rows = append(m.rows, hunkRow{state: i, group: g})
+		}
+	}
+}
+
+// Init initializes the model
+func (m HunkSelectorModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles updates for the model
+func (m HunkSelectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = max(msg.Height-12, 6)
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			return m, func() tea.Msg { return HunksClosedMsg{} }
+		case "enter":
+			patch := m.Patch()
+			if patch == "" {
+				return m, func() tea.Msg { return HunksClosedMsg{} }
+			}
+			return m, func() tea.Msg { return HunksSelectedMsg{Patch: patch} }
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.rows)-1 {
+				m.cursor++
+			}
+		case " ":
+			m.toggle()
+		case "s":
+			m.split()
+		}
+	}
+	return m, nil
+}
+
+// toggle selects or deselects the current row; a whole hunk is selected
+// unless it was fully selected already
+func (m *HunkSelectorModel) toggle() {
+	if m.cursor >= len(m.rows) {
+		return
+	}
+	row := m.rows[m.cursor]
+	selected := m.hunks[row.state].selected
+	if row.group >= 0 {
+		selected[row.group] = !selected[row.group]
+		return
+	}
+	all := allSelected(selected)
+	for g := range selected {
+		selected[g] = !all
+	}
+}
+
+// split shows the change groups of the current hunk as separate rows
+func (m *HunkSelectorModel) split() {
+	if m.cursor >= len(m.rows) {
+		return
+	}
+	row := m.rows[m.cursor]
+	if h := &m.hunks[row.state]; !h.split && len(h.selected) > 1 {
+		h.split = true
+		m.updateRows()
+	}
+}
+
+// allSelected reports whether every change group is selected
+func allSelected(selected []bool) bool {
+	for _, s := range selected {
+		if !s {
+			return false
+		}
+	}
+	return true
+}
+
+// anySelected reports whether a change group is selected
+func anySelected(selected []bool) bool {
+	for _, s := range selected {
+		if s {
+			return true
+		}
+	}
+	return false
+}
+
+// Patch returns the patch of the selected changes, or "" if nothing is selected
+func (m HunkSelectorModel) Patch() string {
+	files := make([]diff.File, len(m.files))
+	for i, f := range m.files {
+		files[i] = f
+		files[i].Hunks = make([]diff.Hunk, len(f.Hunks))
+	}
+	for _, h := range m.hunks {
+		selected := h.selected
+		files[h.file].Hunks[h.hunk] = m.files[h.file].Hunks[h.hunk].Select(func(group int) bool {
+			return selected[group]
+		})
+	}
+	return diff.Format(files)
+}
+
+// View renders the model
+func (m HunkSelectorModel) View() string {
+	if len(m.rows) == 0 {
+		return styles.WarningStyle.Render("There are no hunks to stage") + "\n\n" +
+			styles.HelpStyle.Render("Esc: Back")
+	}
+
+	// The list takes up to a third of the height, the preview the rest
+	listHeight := min(len(m.rows), max(m.height/3, 3))
+	start := 0
+	if m.cursor >= listHeight {
+		start = m.cursor - listHeight + 1
+	}
+	end := min(start+listHeight, len(m.rows))
+
+	var lines []string
+	for i := start; i < end; i++ {
+		lines = append(lines, m.rowLine(i))
+	}
+	if m.skipped > 0 {
+		lines = append(lines, styles.HelpStyle.Render(fmt.Sprintf(
+			"%d new, deleted or binary file(s) can only be staged as a whole", m.skipped)))
+	}
+
+	lines = append(lines, styles.DividerStyle.Render(strings.Repeat("─", m.width)))
+	lines = append(lines, m.preview(max(m.height-listHeight-1, 1))...)
+
+	help := "Space: Select • s: Split hunk • Enter: Stage selected • Esc: Back"
+	return strings.Join(lines, "\n") + "\n\n" + styles.HelpStyle.Render(help)
+}
+
+// rowLine renders a row as "[x] path @@ -1,2 +1,3 @@" or, for a change
+// group, "[x] path change 1/2"
+func (m HunkSelectorModel) rowLine(i int) string {
+	row := m.rows[i]
+	state := m.hunks[row.state]
+	f := m.files[state.file]
+
+	box := "[ ]"
+	label := f.Hunks[state.hunk].Header
+	if row.group >= 0 {
+		label = fmt.Sprintf("  change %d/%d", row.group+1, len(state.selected))
+		if state.selected[row.group] {
+			box = "[x]"
+		}
+	} else {
+		switch {
+		case allSelected(state.selected):
+			box = "[x]"
+		case anySelected(state.selected):
+			box = "[~]"
+		}
+	}
+
+	line := runewidth.Truncate(box+" "+f.Path()+" "+label, max(m.width-2, 10), "…")
+	if i == m.cursor {
+		return styles.FocusedStyle.Render("> " + line)
+	}
+	return "  " + line
+}
+
+// preview renders the hunk of the current row; for a change group, the
+// other changes of the hunk are dimmed
+func (m HunkSelectorModel) preview(height int) []string {
+	row := m.rows[m.cursor]
+	state := m.hunks[row.state]
+	hunk := m.files[state.file].Hunks[state.hunk]
+	groups := hunk.LineGroups()
+
+	lines := []string{styles.InfoStyle.Render(hunk.Header)}
+	for i, l := range hunk.Lines {
+		text := runewidth.Truncate(strings.ReplaceAll(diffLinePrefix(l.Kind)+l.Text, "\t", "    "), m.width, "…")
+		switch {
+		case row.group >= 0 && groups[i] >= 0 && groups[i] != row.group:
+			lines = append(lines, styles.HelpStyle.Render(text))
+		case l.Kind == diff.Added:
+			lines = append(lines, styles.AddedStyle.Render(text))
+		case l.Kind == diff.Removed:
+			lines = append(lines, styles.RemovedStyle.Render(text))
+		case l.Kind == diff.NoNewline:
+			lines = append(lines, styles.HelpStyle.Render(text))
+		default:
+			lines = append(lines, styles.BaseStyle.Render(text))
+		}
+	}
+	if len(lines) > height {
+		more := len(lines) - height + 1
+		lines = append(lines[:height-1], styles.HelpStyle.Render(fmt.Sprintf("… %d more lines", more)))
+	}
+	return lines
+}
+
+// diffLinePrefix returns the prefix character of a diff line
+func diffLinePrefix(kind diff.LineKind) string {
+	switch kind {
+	case diff.Added:
+		return "+"
+	case diff.Removed:
+		return "-"
+	case diff.NoNewline:
+		return "\\"
+	}
+	return " "
+}
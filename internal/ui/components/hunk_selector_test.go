@@ -0,0 +1,90 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/a1yama/git-cz-go/internal/diff"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const unstagedDiff = `diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -1,5 +1,5 @@ package a
+-var x = 1
++var x = 2
+ var y = 1
+ var z = 1
+-var w = 1
++var w = 2
+ var v = 1
+diff --git a/new.go b/new.go
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1 @@
++package b
+`
+
+func TestHunkSelector(t *testing.T) {
+	files, err := diff.Parse(unstagedDiff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewHunkSelectorModel(files)
+
+	view := m.View()
+	for _, want := range []string{"[ ] a.go @@ -1,5 +1,5 @@ package a", "1 new, deleted or binary file(s)", "+var x = 2"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() does not contain %q:\n%s", want, view)
+		}
+	}
+
+	// Nothing selected closes the selector without a patch
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter}); cmd == nil {
+		t.Fatal("Expected Enter to close the selector")
+	} else if _, ok := cmd().(HunksClosedMsg); !ok {
+		t.Errorf("Enter without a selection sent %T, want HunksClosedMsg", cmd())
+	}
+
+	// Split the hunk and select its second change
+	var updated tea.Model = m
+	for _, msg := range []tea.Msg{
+		tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}},
+		tea.KeyMsg{Type: tea.KeyDown},
+		tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}},
+	} {
+		updated, _ = updated.Update(msg)
+	}
+	m = updated.(HunkSelectorModel)
+	view = m.View()
+	for _, want := range []string{"[ ] a.go   change 1/2", "[x] a.go   change 2/2"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() after splitting does not contain %q:\n%s", want, view)
+		}
+	}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	msg, ok := cmd().(HunksSelectedMsg)
+	if !ok {
+		t.Fatalf("Enter sent %T, want HunksSelectedMsg", cmd())
+	}
+	want := `diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -1,5 +1,5 @@ package a
+ var x = 1
+ var y = 1
+ var z = 1
+-var w = 1
++var w = 2
+ var v = 1
+`
+	if msg.Patch != want {
+		t.Errorf("Patch = %q, want %q", msg.Patch, want)
+	}
+}
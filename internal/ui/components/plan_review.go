@@ -0,0 +1,91 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/ui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// PlanConfirmedMsg is sent when the planned commits should be created
+type PlanConfirmedMsg struct{}
+
+// PlanCancelledMsg is sent when the plan is dropped without committing
+type PlanCancelledMsg struct{}
+
+// PlannedCommitView is a planned commit as shown for review
+type PlannedCommitView struct {
+	// Message is the message as git will record it
+	Message string
+	Paths   []string
+}
+
+// PlanReviewModel shows all planned commits before they are created
+type PlanReviewModel struct {
+	commits []PlannedCommitView
+	offset  int
+	height  int
+}
+
+// NewPlanReviewModel creates a review of the planned commits
+func NewPlanReviewModel(commits []PlannedCommitView) PlanReviewModel {
+	return PlanReviewModel{commits: commits, height: 15}
+}
+
+// Init initializes the model
+func (m PlanReviewModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles updates for the model
+func (m PlanReviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = max(msg.Height-10, 5)
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter", "y", "Y":
+			return m, func() tea.Msg { return PlanConfirmedMsg{} }
+		case "esc", "n", "N":
+			return m, func() tea.Msg { return PlanCancelledMsg{} }
+		case "up", "k":
+			m.offset = max(m.offset-1, 0)
+		case "down", "j":
+			m.offset = max(min(m.offset+1, len(m.lines())-m.height), 0)
+		}
+	}
+	return m, nil
+}
+
+// lines renders each commit as its number and message followed by its files
+func (m PlanReviewModel) lines() []string {
+	var lines []string
+	for i, c := range m.commits {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, styles.PaneTitleStyle.Render(fmt.Sprintf("Commit %d of %d", i+1, len(m.commits))))
+		for _, line := range strings.Split(strings.TrimRight(c.Message, "\n"), "\n") {
+			lines = append(lines, styles.BaseStyle.Render("  "+line))
+		}
+		for _, path := range c.Paths {
+			lines = append(lines, styles.HelpStyle.Render("    "+path))
+		}
+	}
+	return lines
+}
+
+// View renders the model
+func (m PlanReviewModel) View() string {
+	lines := m.lines()
+	end := min(m.offset+m.height, len(lines))
+	view := strings.Join(lines[m.offset:end], "\n")
+	if more := len(lines) - end; more > 0 {
+		view += "\n" + styles.HelpStyle.Render(fmt.Sprintf("… %d more lines", more))
+	}
+
+	help := "↑/↓: Scroll • Enter/Y: Create the commits • Esc/N: Cancel without committing"
+	return view + "\n\n" + styles.HelpStyle.Render(help)
+}
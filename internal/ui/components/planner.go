@@ -0,0 +1,193 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/git"
+	"github.com/a1yama/git-cz-go/internal/ui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+)
+
+// PlanMsg is sent with the staged files of each planned commit, in commit order
+type PlanMsg struct {
+	Commits [][]git.FileChange
+}
+
+// PlannerClosedMsg is sent when the planner is left without planning commits
+type PlannerClosedMsg struct{}
+
+// PlannerModel splits the staged files into several commits. Every file
+// starts in the first commit; empty commits are left out.
+type PlannerModel struct {
+	files []git.FileChange
+	// commit is the planned commit of each file, counted from 0
+	commit []int
+	// cursor is the index of the selected file in files
+	cursor int
+	width  int
+	height int
+}
+
+// NewPlannerModel creates a planner for the staged changes
+func NewPlannerModel(files []git.FileChange) PlannerModel {
+	return PlannerModel{files: files, commit: make([]int, len(files)), width: 80, height: 15}
+}
+
+// Init initializes the model
+func (m PlannerModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles updates for the model
+func (m PlannerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = max(msg.Height-12, 5)
+
+	case tea.KeyMsg:
+		if len(m.files) == 0 {
+			if msg.String() == "esc" || msg.String() == "enter" {
+				return m, func() tea.Msg { return PlannerClosedMsg{} }
+			}
+			return m, nil
+		}
+
+		order := m.order()
+		pos := 0
+		for i, f := range order {
+			if f == m.cursor {
+				pos = i
+			}
+		}
+		switch key := msg.String(); key {
+		case "esc":
+			return m, func() tea.Msg { return PlannerClosedMsg{} }
+		case "enter":
+			commits := m.Commits()
+			return m, func() tea.Msg { return PlanMsg{Commits: commits} }
+		case "up", "k":
+			if pos > 0 {
+				m.cursor = order[pos-1]
+			}
+		case "down", "j":
+			if pos < len(order)-1 {
+				m.cursor = order[pos+1]
+			}
+		case "left", "h":
+			m.move(m.commit[m.cursor] - 1)
+		case "right", "l":
+			m.move(m.commit[m.cursor] + 1)
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			m.move(int(key[0] - '1'))
+		}
+	}
+	return m, nil
+}
+
+// move moves the selected file to a commit; a commit after the last one is
+// a new commit
+func (m *PlannerModel) move(commit int) {
+	if commit < 0 {
+		return
+	}
+	m.commit[m.cursor] = min(commit, m.count())
+	m.renumber()
+}
+
+// count returns the number of planned commits
+func (m PlannerModel) count() int {
+	count := 0
+	for _, c := range m.commit {
+		count = max(count, c+1)
+	}
+	return count
+}
+
+// renumber closes the gaps that commits without files leave in the numbering
+func (m *PlannerModel) renumber() {
+	used := make([]bool, len(m.files)+1)
+	for _, c := range m.commit {
+		used[c] = true
+	}
+	number := make([]int, len(used))
+	next := 0
+	for c, u := range used {
+		number[c] = next
+		if u {
+			next++
+		}
+	}
+	for i, c := range m.commit {
+		m.commit[i] = number[c]
+	}
+}
+
+// order returns the indexes of the files in the order they are shown: by
+// commit, and in staged order within a commit
+func (m PlannerModel) order() []int {
+	order := make([]int, 0, len(m.files))
+	for c := 0; c < m.count(); c++ {
+		for i := range m.files {
+			if m.commit[i] == c {
+				order = append(order, i)
+			}
+		}
+	}
+	return order
+}
+
+// Commits returns the files of each planned commit
+func (m PlannerModel) Commits() [][]git.FileChange {
+	commits := make([][]git.FileChange, m.count())
+	for _, i := range m.order() {
+		commits[m.commit[i]] = append(commits[m.commit[i]], m.files[i])
+	}
+	return commits
+}
+
+// View renders the model
+func (m PlannerModel) View() string {
+	if len(m.files) == 0 {
+		return styles.WarningStyle.Render("There are no staged files to plan commits for") + "\n\n" +
+			styles.HelpStyle.Render("Esc: Back")
+	}
+
+	var lines []string
+	selected := 0
+	last := -1
+	for _, i := range m.order() {
+		if c := m.commit[i]; c != last {
+			last = c
+			lines = append(lines, styles.PaneTitleStyle.Render(fmt.Sprintf("Commit %d", c+1)))
+		}
+		f := m.files[i]
+		path := f.Path
+		if f.OldPath != "" {
+			path = f.OldPath + " → " + f.Path
+		}
+		line := runewidth.Truncate(f.Status+" "+path, max(m.width-4, 10), "…")
+		if i == m.cursor {
+			selected = len(lines)
+			lines = append(lines, styles.FocusedStyle.Render("  > "+line))
+		} else {
+			lines = append(lines, "    "+line)
+		}
+	}
+
+	// Keep the selected file visible
+	start := 0
+	if selected >= m.height {
+		start = selected - m.height + 1
+	}
+	end := min(start+m.height, len(lines))
+	view := strings.Join(lines[start:end], "\n")
+	if more := len(lines) - end; more > 0 {
+		view += "\n" + styles.HelpStyle.Render(fmt.Sprintf("… %d more", more))
+	}
+
+	help := "←/→ or 1-9: Move file to commit • Enter: Write the messages • Esc: Back"
+	return view + "\n\n" + styles.HelpStyle.Render(help)
+}
@@ -0,0 +1,64 @@
+package components
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/a1yama/git-cz-go/internal/git"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPlannerModel(t *testing.T) {
+	m := NewPlannerModel([]git.FileChange{
+		{Status: "M", Path: "a.go"},
+		{Status: "A", Path: "b.go"},
+		{Status: "R", Path: "c.go", OldPath: "old.go"},
+	})
+	press := func(keys ...tea.KeyMsg) {
+		for _, k := range keys {
+			updated, _ := m.Update(k)
+			m = updated.(PlannerModel)
+		}
+	}
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	right := tea.KeyMsg{Type: tea.KeyRight}
+	left := tea.KeyMsg{Type: tea.KeyLeft}
+	up := tea.KeyMsg{Type: tea.KeyUp}
+	digit := func(r rune) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}} }
+
+	// b.go to a new second commit, which is then shown last, and c.go to a
+	// third one with 9
+	press(down, right, up, digit('9'))
+	if got, want := m.commit, []int{0, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("commits = %v, want %v", got, want)
+	}
+
+	// Moving a.go out of the first commit renumbers the others
+	m.cursor = 0
+	press(digit('3'))
+	if got, want := m.commit, []int{1, 0, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("commits after emptying the first one = %v, want %v", got, want)
+	}
+	// The cursor stays on a.go, now shown after c.go
+	press(left)
+	if got, want := m.commit, []int{0, 0, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("commits after moving back = %v, want %v", got, want)
+	}
+
+	view := m.View()
+	for _, want := range []string{"Commit 1", "Commit 2", "R old.go → c.go"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() does not contain %q:\n%s", want, view)
+		}
+	}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	msg, ok := cmd().(PlanMsg)
+	if !ok || len(msg.Commits) != 2 {
+		t.Fatalf("Enter sent %#v, want a plan of 2 commits", cmd())
+	}
+	if got := []string{msg.Commits[0][0].Path, msg.Commits[0][1].Path, msg.Commits[1][0].Path}; !reflect.DeepEqual(got, []string{"a.go", "b.go", "c.go"}) {
+		t.Errorf("planned files = %v", got)
+	}
+}
@@ -0,0 +1,201 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/config"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ScopeSelectedMsg is sent when a scope is selected.
+// Scope is empty when the user chose not to use a scope.
+type ScopeSelectedMsg struct {
+	Scope string
+}
+
+// scopeItem represents a scope in the list
+type scopeItem struct {
+	name        string
+	description string
+	// reason is set when the scope is suggested for the staged files
+	reason string
+}
+
+// FilterValue implements list.Item
+func (i scopeItem) FilterValue() string { return i.name + " " + i.description }
+
+// Title returns the title for the list item
+func (i scopeItem) Title() string {
+	if i.name == "" {
+		return "(none)"
+	}
+	if i.reason != "" {
+		return i.name + "  ← suggested: " + i.reason
+	}
+	return i.name
+}
+
+// Description returns the description for the list item
+func (i scopeItem) Description() string { return i.description }
+
+// ScopeModel handles the scope selection
+type ScopeModel struct {
+	list   list.Model
+	scopes []config.Scope
+	// touched is set once the user has pressed a key, so that a suggestion
+	// arriving later does not move the selection
+	touched bool
+}
+
+// NewScopeModel creates a new scope model.
+// The first item always allows committing without a scope.
+func NewScopeModel(scopes []config.Scope) ScopeModel {
+	// デフォルトのサイズ
+	width := 80
+	height := 15
+
+	// Set up list
+	listModel := list.New(scopeItems(scopes), list.NewDefaultDelegate(), width, height)
+	listModel.Title = "Scopes"
+	listModel.SetShowHelp(false)
+	listModel.SetFilteringEnabled(true)
+	listModel.Styles.Title = lipgloss.NewStyle().MarginLeft(2).Bold(true)
+	listModel.Styles.PaginationStyle = lipgloss.NewStyle().Padding(0, 2)
+
+	return ScopeModel{
+		list:   listModel,
+		scopes: scopes,
+	}
+}
+
+// scopeItems returns the list items for the scopes, after "(none)"
+func scopeItems(scopes []config.Scope) []list.Item {
+	items := make([]list.Item, 0, len(scopes)+1)
+	items = append(items, scopeItem{description: "Commit without a scope"})
+	for _, s := range scopes {
+		items = append(items, scopeItem{
+			name:        s.Name,
+			description: s.Description,
+		})
+	}
+	return items
+}
+
+// Suggest marks the suggested scope with its reason and selects it, unless
+// the user has already moved in the list. When the staged files span several
+// scopes, all of them together and their common parent are offered right
+// after "(none)", like a suggested scope that is not in the list.
+func (m *ScopeModel) Suggest(suggestion config.ScopeSuggestion) {
+	items := scopeItems(m.scopes)
+	var extra []list.Item
+	if len(suggestion.Scopes) == 1 && !m.hasScope(suggestion.Scopes[0]) {
+		extra = append(extra, scopeItem{
+			name:        suggestion.Scopes[0],
+			description: "Suggested for the staged changes",
+		})
+	}
+	if len(suggestion.Scopes) > 1 {
+		extra = append(extra, scopeItem{
+			name:        strings.Join(suggestion.Scopes, ","),
+			description: "The staged files span several scopes",
+		})
+		if suggestion.Parent != "" && !m.hasScope(suggestion.Parent) {
+			extra = append(extra, scopeItem{
+				name:        suggestion.Parent,
+				description: "The common parent directory of the staged files",
+			})
+		}
+	}
+	items = append(items[:1], append(extra, items[1:]...)...)
+
+	selected := -1
+	for i, item := range items {
+		scope := item.(scopeItem)
+		if len(suggestion.Scopes) > 0 && scope.name == suggestion.Scopes[0] {
+			scope.reason = suggestion.Reason
+			items[i] = scope
+			selected = i
+		}
+	}
+	previous, hasPrevious := m.list.SelectedItem().(scopeItem)
+	m.list.SetItems(items)
+	if m.list.FilterState() != list.Unfiltered {
+		return
+	}
+	if selected >= 0 && !m.touched {
+		m.list.Select(selected)
+		return
+	}
+	if !hasPrevious {
+		return
+	}
+	// The inserted items move the others down, so the cursor follows the
+	// scope it was on
+	for i, item := range items {
+		if item.(scopeItem).name == previous.name {
+			m.list.Select(i)
+			return
+		}
+	}
+}
+
+// hasScope reports whether a scope with the name is in the list
+func (m ScopeModel) hasScope(name string) bool {
+	for _, s := range m.scopes {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Highlighted returns the scope under the cursor, or "" for "(none)"
+func (m ScopeModel) Highlighted() string {
+	if item, ok := m.list.SelectedItem().(scopeItem); ok {
+		return item.name
+	}
+	return ""
+}
+
+// Filtering reports whether the filter is being typed
+func (m ScopeModel) Filtering() bool {
+	return m.list.FilterState() == list.Filtering
+}
+
+// Init initializes the model
+func (m ScopeModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles updates for the model
+func (m ScopeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.list.SetWidth(msg.Width - 4)
+		m.list.SetHeight(msg.Height - 10)
+		return m, nil
+
+	case tea.KeyMsg:
+		m.touched = true
+		// Let the list handle enter while the filter is being typed
+		if msg.String() == "enter" && m.list.FilterState() != list.Filtering {
+			i, ok := m.list.SelectedItem().(scopeItem)
+			if ok {
+				return m, func() tea.Msg {
+					return ScopeSelectedMsg{Scope: i.name}
+				}
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+// View renders the model
+func (m ScopeModel) View() string {
+	return m.list.View()
+}
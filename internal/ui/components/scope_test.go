@@ -0,0 +1,84 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/a1yama/git-cz-go/internal/config"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestScopeModelSelect(t *testing.T) {
+	scopes := []config.Scope{
+		{Name: "ui", Description: "Terminal UI"},
+		{Name: "git"},
+	}
+
+	model := NewScopeModel(scopes)
+
+	if view := model.View(); view == "" {
+		t.Error("View() returned an empty string")
+	}
+
+	// The first item is "no scope"
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	msg, ok := executeCmd(t, cmd).(ScopeSelectedMsg)
+	if !ok {
+		t.Fatal("Command did not return a ScopeSelectedMsg")
+	}
+	if msg.Scope != "" {
+		t.Errorf("ScopeSelectedMsg.Scope = %q, want empty", msg.Scope)
+	}
+
+	// Move down to the first configured scope
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	_, cmd = updated.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	msg, ok = executeCmd(t, cmd).(ScopeSelectedMsg)
+	if !ok {
+		t.Fatal("Command did not return a ScopeSelectedMsg")
+	}
+	if msg.Scope != "ui" {
+		t.Errorf("ScopeSelectedMsg.Scope = %q, want %q", msg.Scope, "ui")
+	}
+}
+
+func TestScopeModelSuggest(t *testing.T) {
+	updated, _ := NewScopeModel([]config.Scope{{Name: "ui"}, {Name: "git"}, {Name: "internal"}}).
+		Update(tea.WindowSizeMsg{Width: 100, Height: 50})
+	model := updated.(ScopeModel)
+	model.Suggest(config.ScopeSuggestion{Scopes: []string{"git", "ui"}, Reason: "2 of 3 staged files are in git", Parent: "internal"})
+
+	view := model.View()
+	for _, want := range []string{"git,ui", "git  ← suggested: 2 of 3 staged files are in git"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() does not contain %q:\n%s", want, view)
+		}
+	}
+	// The parent is a scope already, so it is not added again
+	if got := strings.Count(view, "internal"); got != 1 {
+		t.Errorf("View() shows internal %d times, want once:\n%s", got, view)
+	}
+
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if msg, ok := executeCmd(t, cmd).(ScopeSelectedMsg); !ok || msg.Scope != "git" {
+		t.Errorf("Enter selected %#v, want git", msg)
+	}
+
+	// The scopes together are offered right after "(none)"
+	updated, _ = NewScopeModel(nil).Update(tea.KeyMsg{Type: tea.KeyDown})
+	model = updated.(ScopeModel)
+	model.Suggest(config.ScopeSuggestion{Scopes: []string{"git", "ui"}, Parent: "internal"})
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	_, cmd = updated.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if msg, ok := executeCmd(t, cmd).(ScopeSelectedMsg); !ok || msg.Scope != "git,ui" {
+		t.Errorf("Enter selected %#v after moving, want git,ui", msg)
+	}
+
+	// A scope the user has moved to stays selected when items are inserted
+	updated, _ = NewScopeModel([]config.Scope{{Name: "ui"}, {Name: "git"}}).Update(tea.KeyMsg{Type: tea.KeyDown})
+	model = updated.(ScopeModel)
+	model.Suggest(config.ScopeSuggestion{Scopes: []string{"api", "web"}, Parent: "services"})
+	if got := model.Highlighted(); got != "ui" {
+		t.Errorf("Highlighted() = %q after the suggestion, want ui", got)
+	}
+}
@@ -0,0 +1,105 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/git"
+	"github.com/a1yama/git-cz-go/internal/ui/styles"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// StagedPane lists the staged changes next to the wizard
+type StagedPane struct {
+	changes []git.FileChange
+}
+
+// NewStagedPane creates a pane for the given staged changes
+func NewStagedPane(changes []git.FileChange) StagedPane {
+	return StagedPane{changes: changes}
+}
+
+// Empty reports whether nothing is staged
+func (p StagedPane) Empty() bool {
+	return len(p.changes) == 0
+}
+
+// Changes returns the staged changes
+func (p StagedPane) Changes() []git.FileChange {
+	return p.changes
+}
+
+// Summary returns the total diffstat, like "3 files changed, +10 -2"
+func (p StagedPane) Summary() string {
+	added, removed := 0, 0
+	for _, c := range p.changes {
+		added += c.Added
+		removed += c.Removed
+	}
+	files := "files"
+	if len(p.changes) == 1 {
+		files = "file"
+	}
+	return fmt.Sprintf("%d %s changed, +%d -%d", len(p.changes), files, added, removed)
+}
+
+// View renders the pane within the given size; zero means unlimited
+func (p StagedPane) View(width, height int) string {
+	style := styles.PaneStyle
+	if width > 0 {
+		style = style.Width(width - style.GetHorizontalFrameSize())
+	}
+	inner := width - style.GetHorizontalFrameSize()
+
+	lines := []string{styles.PaneTitleStyle.Render("Staged changes"), styles.HelpStyle.Render(p.Summary())}
+	if p.Empty() {
+		lines = append(lines, "", styles.ErrorStyle.Render("Nothing is staged"))
+	}
+
+	// Leave room for the title, summary, border and a "more" line
+	maxFiles := len(p.changes)
+	if height > 0 && maxFiles > height-5 {
+		maxFiles = max(height-5, 1)
+	}
+	for _, c := range p.changes[:maxFiles] {
+		lines = append(lines, changeLine(c, inner))
+	}
+	if more := len(p.changes) - maxFiles; more > 0 {
+		lines = append(lines, styles.HelpStyle.Render(fmt.Sprintf("… %d more", more)))
+	}
+
+	return style.Render(strings.Join(lines, "\n"))
+}
+
+// changeLine renders a single change as "M path  +1 -2", truncating the path to width
+func changeLine(c git.FileChange, width int) string {
+	stat := styles.AddedStyle.Render(fmt.Sprintf("+%d", c.Added)) + " " +
+		styles.RemovedStyle.Render(fmt.Sprintf("-%d", c.Removed))
+	if c.Binary {
+		stat = styles.HelpStyle.Render("binary")
+	}
+
+	path := c.Path
+	if c.OldPath != "" {
+		path = c.OldPath + " → " + c.Path
+	}
+	if width > 0 {
+		room := width - 3 - lipgloss.Width(stat)
+		if runes := []rune(path); room > 1 && len(runes) > room {
+			path = "…" + string(runes[len(runes)-room+1:])
+		}
+	}
+
+	return statusStyle(c.Status).Render(c.Status) + " " + path + "  " + stat
+}
+
+// statusStyle returns the color of a status letter
+func statusStyle(status string) lipgloss.Style {
+	switch status {
+	case "A":
+		return styles.AddedStyle
+	case "D":
+		return styles.RemovedStyle
+	}
+	return styles.InfoStyle
+}
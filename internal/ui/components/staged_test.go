@@ -0,0 +1,36 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/a1yama/git-cz-go/internal/git"
+)
+
+func TestStagedPane(t *testing.T) {
+	pane := NewStagedPane([]git.FileChange{
+		{Status: "M", Path: "internal/ui/ui.go", Added: 3, Removed: 1},
+		{Status: "R", Path: "docs/new.md", OldPath: "docs/old.md"},
+		{Status: "A", Path: "logo.png", Binary: true},
+	})
+
+	if got, want := pane.Summary(), "3 files changed, +3 -1"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+
+	view := pane.View(60, 0)
+	for _, want := range []string{"M internal/ui/ui.go  +3 -1", "R docs/old.md → docs/new.md", "A logo.png  binary"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() does not contain %q:\n%s", want, view)
+		}
+	}
+
+	// Files that do not fit are counted
+	if view := pane.View(60, 7); !strings.Contains(view, "… 1 more") {
+		t.Errorf("View() with a small height does not count the hidden files:\n%s", view)
+	}
+
+	if view := NewStagedPane(nil).View(60, 0); !strings.Contains(view, "Nothing is staged") {
+		t.Errorf("View() without changes does not warn:\n%s", view)
+	}
+}
@@ -0,0 +1,195 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/git"
+	"github.com/a1yama/git-cz-go/internal/ui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// StageMsg is sent when files should be staged, or unstaged if Unstage is set.
+// The model waits for SetStatus before accepting further changes.
+type StageMsg struct {
+	Paths   []string
+	Unstage bool
+}
+
+// PartialStageMsg is sent when the user wants to pick hunks of the unstaged changes
+type PartialStageMsg struct{}
+
+// StagingDoneMsg is sent when the user is done staging files
+type StagingDoneMsg struct{}
+
+// StagingModel lists the changed and untracked files with checkboxes to
+// stage and unstage them
+type StagingModel struct {
+	entries []git.StatusEntry
+	cursor  int
+	loaded  bool
+	// busy is set while git is staging or unstaging files
+	busy   bool
+	err    error
+	height int
+}
+
+// NewStagingModel creates a staging model that shows a loading message until SetStatus is called
+func NewStagingModel() StagingModel {
+	return StagingModel{height: 15}
+}
+
+// SetStatus sets the files to show, keeping the cursor on the same file
+func (m *StagingModel) SetStatus(entries []git.StatusEntry, err error) {
+	var current string
+	if m.cursor < len(m.entries) {
+		current = m.entries[m.cursor].Path
+	}
+
+	m.loaded, m.busy, m.err = true, false, err
+	if err != nil {
+		return
+	}
+	m.entries = entries
+	m.cursor = 0
+	for i, e := range entries {
+		if e.Path == current {
+			m.cursor = i
+		}
+	}
+}
+
+// Init initializes the model
+func (m StagingModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles updates for the model
+func (m StagingModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = max(msg.Height-12, 3)
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter", "esc":
+			return m, func() tea.Msg { return StagingDoneMsg{} }
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.entries)-1 {
+				m.cursor++
+			}
+		case " ":
+			if m.busy || m.cursor >= len(m.entries) {
+				return m, nil
+			}
+			return m.stage(toggle(m.entries[m.cursor]))
+		case "a":
+			if m.busy {
+				return m, nil
+			}
+			// Like `git commit -a`: modified and deleted files, but not untracked ones
+			var paths []string
+			for _, e := range m.entries {
+				if e.Unstaged != "" && !e.Untracked() {
+					paths = append(paths, e.Path)
+				}
+			}
+			return m.stage(StageMsg{Paths: paths})
+		case "p":
+			if !m.busy {
+				return m, func() tea.Msg { return PartialStageMsg{} }
+			}
+		}
+	}
+	return m, nil
+}
+
+// toggle returns the change that stages a file, or unstages it if it is fully staged
+func toggle(e git.StatusEntry) StageMsg {
+	if e.Staged != "" && e.Unstaged == "" {
+		paths := []string{e.Path}
+		if e.OldPath != "" {
+			paths = append(paths, e.OldPath)
+		}
+		return StageMsg{Paths: paths, Unstage: true}
+	}
+	return StageMsg{Paths: []string{e.Path}}
+}
+
+// stage sends the change unless there is nothing to change
+func (m StagingModel) stage(msg StageMsg) (tea.Model, tea.Cmd) {
+	if len(msg.Paths) == 0 {
+		return m, nil
+	}
+	m.busy = true
+	return m, func() tea.Msg { return msg }
+}
+
+// View renders the model
+func (m StagingModel) View() string {
+	if !m.loaded {
+		return styles.InfoStyle.Render("Loading git status...")
+	}
+
+	var lines []string
+	if m.err != nil {
+		lines = append(lines, styles.ErrorStyle.Render(fmt.Sprintf("Error: %v", m.err)), "")
+	}
+	if len(m.entries) == 0 {
+		lines = append(lines, styles.WarningStyle.Render("There are no changes to stage"))
+	}
+
+	// Keep the cursor visible
+	start := 0
+	if m.cursor >= m.height {
+		start = m.cursor - m.height + 1
+	}
+	end := min(start+m.height, len(m.entries))
+	for i := start; i < end; i++ {
+		lines = append(lines, m.entryLine(i))
+	}
+	if more := len(m.entries) - end; more > 0 {
+		lines = append(lines, styles.HelpStyle.Render(fmt.Sprintf("… %d more", more)))
+	}
+
+	help := "Space: Stage/unstage • a: Stage all tracked • p: Stage hunks • Enter: Continue"
+	if m.busy {
+		help = "Updating the index..."
+	}
+	return strings.Join(lines, "\n") + "\n\n" + styles.HelpStyle.Render(help)
+}
+
+// entryLine renders a file as "[x] MM path", where the letters are the
+// staged and unstaged status like in `git status --short`
+func (m StagingModel) entryLine(i int) string {
+	e := m.entries[i]
+
+	box := "[ ]"
+	switch {
+	case e.Staged != "" && e.Unstaged != "":
+		box = "[~]"
+	case e.Staged != "":
+		box = "[x]"
+	}
+
+	staged, unstaged := e.Staged, e.Unstaged
+	if e.Untracked() {
+		staged = "?"
+	}
+	status := styles.AddedStyle.Render(fmt.Sprintf("%1s", staged)) +
+		styles.RemovedStyle.Render(fmt.Sprintf("%1s", unstaged))
+
+	path := e.Path
+	if e.OldPath != "" {
+		path = e.OldPath + " → " + e.Path
+	}
+
+	if i == m.cursor {
+		return styles.FocusedStyle.Render("> "+box) + " " + status + " " + styles.FocusedStyle.Render(path)
+	}
+	return "  " + box + " " + status + " " + path
+}
@@ -0,0 +1,70 @@
+package components
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/a1yama/git-cz-go/internal/git"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestStagingModel(t *testing.T) {
+	m := NewStagingModel()
+	m.SetStatus([]git.StatusEntry{
+		{Path: "both.go", Staged: "M", Unstaged: "M"},
+		{Path: "changed.go", Unstaged: "M"},
+		{Path: "deleted.go", Unstaged: "D"},
+		{Path: "new.go", Unstaged: "?"},
+		{Path: "renamed.go", OldPath: "old.go", Staged: "R"},
+	}, nil)
+
+	view := m.View()
+	for _, want := range []string{"[~] MM both.go", "[ ]  M changed.go", "[ ] ?? new.go", "[x] R  old.go → renamed.go"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() does not contain %q:\n%s", want, view)
+		}
+	}
+
+	// Space stages a partially staged file and unstages both paths of a staged rename
+	tests := []struct {
+		cursor int
+		want   StageMsg
+	}{
+		{0, StageMsg{Paths: []string{"both.go"}}},
+		{3, StageMsg{Paths: []string{"new.go"}}},
+		{4, StageMsg{Paths: []string{"renamed.go", "old.go"}, Unstage: true}},
+	}
+	for _, tt := range tests {
+		m.cursor = tt.cursor
+		updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+		if cmd == nil {
+			t.Fatalf("Space on entry %d sent nothing", tt.cursor)
+		}
+		if got := cmd(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Space on entry %d = %+v, want %+v", tt.cursor, got, tt.want)
+		}
+
+		// Further changes wait for the new status
+		if _, cmd := updated.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}); cmd != nil {
+			t.Errorf("Expected no change while the index is being updated, got %+v", cmd())
+		}
+	}
+
+	// Stage all tracked leaves untracked files alone
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	want := StageMsg{Paths: []string{"both.go", "changed.go", "deleted.go"}}
+	if cmd == nil {
+		t.Fatal("Expected a to stage the tracked files")
+	}
+	if got := cmd(); !reflect.DeepEqual(got, want) {
+		t.Errorf("a = %+v, want %+v", got, want)
+	}
+
+	// The cursor stays on the same file after a refresh
+	m.cursor = 1
+	m.SetStatus([]git.StatusEntry{{Path: "a.go", Unstaged: "?"}, {Path: "both.go", Unstaged: "M"}, {Path: "changed.go", Staged: "M"}}, nil)
+	if m.cursor != 2 {
+		t.Errorf("Expected the cursor to stay on changed.go, got %d", m.cursor)
+	}
+}
@@ -2,6 +2,10 @@ package components
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/mood"
+	"github.com/a1yama/git-cz-go/internal/ui/styles"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -17,6 +21,20 @@ type SubjectModel struct {
 	textInput  textinput.Model
 	maxLength  int
 	validInput bool
+	// touched is set once the user typed, so that a prefilled subject does
+	// not replace their text
+	touched bool
+	// suggestions are draft subjects shown under the input; selected is the
+	// one that Tab copies into the input
+	suggestions []string
+	selected    int
+	// history holds earlier subjects, newest first; the rest of the first one
+	// that starts with the typed text is shown after it, like in fish
+	history []string
+	// lexicon checks that the subject starts with an imperative verb, unless
+	// it is nil; refuseMood refuses a subject that does not
+	lexicon    *mood.Lexicon
+	refuseMood bool
 }
 
 // NewSubjectModel creates a new subject model
@@ -34,6 +52,58 @@ func NewSubjectModel(maxLength int) SubjectModel {
 	}
 }
 
+// Prefill sets the subject proposed for the staged changes, unless the
+// user already typed one
+func (m *SubjectModel) Prefill(subject string) {
+	if m.touched {
+		return
+	}
+	m.textInput.SetValue(subject)
+	m.textInput.CursorEnd()
+	m.validInput = subject != ""
+}
+
+// SetSuggestions sets the draft subjects shown under the input
+func (m *SubjectModel) SetSuggestions(suggestions []string) {
+	m.suggestions = suggestions
+	m.selected = 0
+}
+
+// SetHistory sets the earlier subjects that the typed text is completed from
+func (m *SubjectModel) SetHistory(subjects []string) {
+	m.history = subjects
+}
+
+// completion returns the rest of the newest earlier subject that starts with
+// the typed text, while the cursor is at its end
+func (m SubjectModel) completion() string {
+	value := m.textInput.Value()
+	if value == "" || m.textInput.Position() != len([]rune(value)) {
+		return ""
+	}
+	for _, subject := range m.history {
+		if len(subject) > len(value) && strings.HasPrefix(subject, value) {
+			return subject[len(value):]
+		}
+	}
+	return ""
+}
+
+// SetMoodCheck checks that the subject starts with a verb in the imperative
+// mood, warning about it or, with refuse, not accepting the subject until it does
+func (m *SubjectModel) SetMoodCheck(lexicon *mood.Lexicon, refuse bool) {
+	m.lexicon = lexicon
+	m.refuseMood = refuse
+}
+
+// moodIssue returns the verb of the subject that is not in the imperative mood
+func (m SubjectModel) moodIssue() (mood.Issue, bool) {
+	if m.lexicon == nil {
+		return mood.Issue{}, false
+	}
+	return m.lexicon.Check(m.textInput.Value())
+}
+
 // Init initializes the model
 func (m SubjectModel) Init() tea.Cmd {
 	return textinput.Blink
@@ -45,12 +115,44 @@ func (m SubjectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "enter":
+			if _, ok := m.moodIssue(); ok && m.refuseMood {
+				return m, nil
+			}
 			if len(m.textInput.Value()) > 0 {
 				return m, func() tea.Msg {
 					return SubjectSubmittedMsg{Subject: m.textInput.Value()}
 				}
 			}
+		case "up":
+			m.selected = max(m.selected-1, 0)
+			return m, nil
+		case "down":
+			m.selected = max(min(m.selected+1, len(m.suggestions)-1), 0)
+			return m, nil
+		case "ctrl+r":
+			if issue, ok := m.moodIssue(); ok {
+				m.textInput.SetValue(issue.Fix(m.textInput.Value()))
+				m.textInput.CursorEnd()
+				m.touched = true
+			}
+			return m, nil
+		case "right", "ctrl+f":
+			if completion := m.completion(); completion != "" {
+				m.textInput.SetValue(m.textInput.Value() + completion)
+				m.textInput.CursorEnd()
+				m.touched = true
+				return m, nil
+			}
+		case "tab":
+			if len(m.suggestions) > 0 {
+				m.textInput.SetValue(m.suggestions[m.selected])
+				m.textInput.CursorEnd()
+				m.validInput = true
+				m.touched = true
+			}
+			return m, nil
 		}
+		m.touched = true
 	}
 
 	// Update validation status
@@ -73,8 +175,14 @@ func (m SubjectModel) View() string {
 		counterStyle = counterStyle.Foreground(lipgloss.Color("9")) // Red for low remaining
 	}
 
-	// Main text input view
+	// Main text input view, with the completion dimmed after the cursor
 	view := m.textInput.View()
+	if completion := m.completion(); completion != "" {
+		// Without a width the input is not padded up to the completion
+		input := m.textInput
+		input.Width = 0
+		view = input.View() + styles.BlurredStyle.Render(completion)
+	}
 
 	// Add character counter and validation hint
 	view += "\n\n" + counterStyle.Render(fmt.Sprintf("%d/%d characters", currentLength, m.maxLength))
@@ -84,6 +192,31 @@ func (m SubjectModel) View() string {
 		view += "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render("Subject cannot be empty")
 	}
 
+	if issue, ok := m.moodIssue(); ok {
+		if m.refuseMood {
+			view += "\n" + styles.ErrorStyle.Render(fmt.Sprintf(
+				"✗ The subject must use the imperative mood: %q rather than %q (Ctrl+R: Fix)", issue.Imperative, issue.Word))
+		} else {
+			view += "\n" + styles.WarningStyle.Render(fmt.Sprintf(
+				"⚠ Use the imperative mood: %q rather than %q (Ctrl+R: Fix)", issue.Imperative, issue.Word))
+		}
+	}
+
+	if len(m.suggestions) > 0 {
+		view += "\n\n" + styles.HelpStyle.Render("Suggestions from the staged changes (↑/↓: Choose • Tab: Use)")
+		for i, suggestion := range m.suggestions {
+			if i == m.selected {
+				view += "\n" + styles.FocusedStyle.Render("> "+suggestion)
+			} else {
+				view += "\n" + styles.BlurredStyle.Render("  "+suggestion)
+			}
+		}
+	}
+
+	if m.completion() != "" {
+		view += "\n" + styles.HelpStyle.Render("→: Complete from an earlier subject")
+	}
+
 	// Add helper text
 	view += "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("12")).Render("Tips:") +
 		"\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("7")).Render("- Use imperative, present tense: \"add\" not \"added\"") +
@@ -1,8 +1,10 @@
 package components
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/a1yama/git-cz-go/internal/mood"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -47,3 +49,94 @@ func TestSubjectModelUpdate(t *testing.T) {
 		t.Error("New model should have validInput=false")
 	}
 }
+
+func TestSubjectModelPrefill(t *testing.T) {
+	model := NewSubjectModel(100)
+	model.Prefill("bump a from v1 to v2")
+
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("Enter did not submit the prefilled subject")
+	}
+	if msg := cmd().(SubjectSubmittedMsg); msg.Subject != "bump a from v1 to v2" {
+		t.Errorf("Subject = %q, want the prefilled subject", msg.Subject)
+	}
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	typed := updated.(SubjectModel)
+	typed.Prefill("something else")
+	if got := typed.textInput.Value(); got != "bump a from v1 to v" {
+		t.Errorf("Value() = %q, want the edited subject to be kept", got)
+	}
+}
+
+func TestSubjectModelSuggestions(t *testing.T) {
+	model := NewSubjectModel(100)
+	model.SetSuggestions([]string{"add Walk", "remove contains helper"})
+	if view := model.View(); !strings.Contains(view, "> add Walk") || !strings.Contains(view, "remove contains helper") {
+		t.Errorf("View() = %q, want the suggestions with the first one selected", view)
+	}
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyTab})
+	chosen := updated.(SubjectModel)
+	if got := chosen.textInput.Value(); got != "remove contains helper" {
+		t.Errorf("Value() = %q, want the selected suggestion", got)
+	}
+
+	// A chosen suggestion is the user's text, which a prefill keeps
+	chosen.Prefill("bump a from v1 to v2")
+	if got := chosen.textInput.Value(); got != "remove contains helper" {
+		t.Errorf("Value() = %q, want the chosen suggestion to be kept", got)
+	}
+}
+
+func TestSubjectModelCompletion(t *testing.T) {
+	model := NewSubjectModel(100)
+	model.SetHistory([]string{"update the README", "add history", "add help"})
+
+	var updated tea.Model = model
+	for _, r := range "add h" {
+		updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if view := updated.View(); !strings.Contains(view, "istory") {
+		t.Errorf("View() = %q, want the rest of the newest matching subject", view)
+	}
+
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRight})
+	if got := updated.(SubjectModel).textInput.Value(); got != "add history" {
+		t.Errorf("Value() = %q, want the completed subject", got)
+	}
+
+	// Nothing is completed in the middle of the text
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	if got := updated.(SubjectModel).completion(); got != "" {
+		t.Errorf("completion() = %q, want none before the end", got)
+	}
+}
+
+func TestSubjectModelMoodCheck(t *testing.T) {
+	for _, refuse := range []bool{false, true} {
+		model := NewSubjectModel(100)
+		model.SetMoodCheck(mood.NewLexicon(nil), refuse)
+		model.Prefill("Added caching")
+
+		if view := model.View(); !strings.Contains(view, `"add" rather than "Added"`) {
+			t.Errorf("View() = %q, want the imperative form proposed", view)
+		}
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		if submitted := cmd != nil; submitted == refuse {
+			t.Errorf("refuse = %v: Enter submitted = %v", refuse, submitted)
+		}
+
+		updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+		fixed := updated.(SubjectModel)
+		if got := fixed.textInput.Value(); got != "Add caching" {
+			t.Errorf("Value() = %q, want the fixed subject", got)
+		}
+		if _, cmd := fixed.Update(tea.KeyMsg{Type: tea.KeyEnter}); cmd == nil {
+			t.Error("Enter did not submit the fixed subject")
+		}
+	}
+}
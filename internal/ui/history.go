@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"sort"
+
+	"github.com/a1yama/git-cz-go/internal/config"
+	"github.com/a1yama/git-cz-go/internal/history"
+	"github.com/a1yama/git-cz-go/internal/model"
+)
+
+// orderTypes returns the types with the ones used before first, most used
+// first, and the others in the configured order
+func orderTypes(types []config.CommitType, hist *history.History) []config.CommitType {
+	if hist == nil {
+		return types
+	}
+	return byUse(types, func(t config.CommitType) string { return t.Type }, hist.Types())
+}
+
+// orderScopes returns the scopes with the ones used before first, most used
+// first, and the others in the configured or detected order
+func orderScopes(scopes []config.Scope, hist *history.History) []config.Scope {
+	if hist == nil {
+		return scopes
+	}
+	return byUse(scopes, func(s config.Scope) string { return s.Name }, hist.Scopes())
+}
+
+// byUse returns a copy of the items sorted by the rank of their name in used,
+// keeping the order of the unused ones after them
+func byUse[T any](items []T, name func(T) string, used []string) []T {
+	rank := make(map[string]int, len(used))
+	for i, u := range used {
+		rank[u] = i
+	}
+	rankOf := func(item T) int {
+		if r, ok := rank[name(item)]; ok {
+			return r
+		}
+		return len(used)
+	}
+
+	sorted := append([]T(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return rankOf(sorted[i]) < rankOf(sorted[j])
+	})
+	return sorted
+}
+
+// recordHistory adds the committed messages to the history. The history is
+// only a convenience, so failing to save it is not an error.
+func (m Model) recordHistory() {
+	if m.history == nil {
+		return
+	}
+	messages := []model.CommitMessage{m.commitMessage}
+	if m.plan != nil {
+		messages = m.plan.messages
+	}
+	_ = m.history.Add(messages...)
+}
@@ -0,0 +1,526 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/a1yama/git-cz-go/internal/apidiff"
+	"github.com/a1yama/git-cz-go/internal/bump"
+	"github.com/a1yama/git-cz-go/internal/config"
+	"github.com/a1yama/git-cz-go/internal/diff"
+	"github.com/a1yama/git-cz-go/internal/draft"
+	"github.com/a1yama/git-cz-go/internal/git"
+	"github.com/a1yama/git-cz-go/internal/history"
+	"github.com/a1yama/git-cz-go/internal/ui/components"
+	"github.com/a1yama/git-cz-go/internal/ui/styles"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// source is a piece of repository data that is loaded in the background
+// while the wizard is already running
+type source int
+
+const (
+	sourceRepository source = iota
+	sourceSuperproject
+	sourceCleanup
+	sourceStaged
+	sourceScopes
+	sourceHistory
+	sourceLog
+	sourceCount
+)
+
+// String returns the name shown in the loading status
+func (s source) String() string {
+	switch s {
+	case sourceRepository:
+		return "repository"
+	case sourceSuperproject:
+		return "submodule"
+	case sourceCleanup:
+		return "commit.cleanup"
+	case sourceStaged:
+		return "staged files"
+	case sourceScopes:
+		return "scopes"
+	case sourceHistory:
+		return "history"
+	case sourceLog:
+		return "recent commits"
+	}
+	return "unknown"
+}
+
+// degraded describes what still works when the source failed to load
+func (s source) degraded() string {
+	switch s {
+	case sourceSuperproject:
+		return "the superproject is not shown"
+	case sourceCleanup:
+		return "the preview assumes commit.cleanup=whitespace"
+	case sourceStaged:
+		return "the staged files are not shown and nested configs are not applied"
+	case sourceScopes:
+		return "only \"(none)\" can be selected"
+	case sourceHistory:
+		return "types and scopes are not ordered by use"
+	case sourceLog:
+		return "no example commits are shown"
+	}
+	return ""
+}
+
+// sourceStatus is the loading state of a source
+type sourceStatus struct {
+	done bool
+	err  error
+}
+
+// sourceLoadedMsg is sent when a source has been loaded, with the source
+// specific result in value
+type sourceLoadedMsg struct {
+	source source
+	value  interface{}
+	err    error
+}
+
+// stagedResult is the value loaded for sourceStaged. The status is only
+// loaded when nothing is staged, to offer staging files first.
+type stagedResult struct {
+	root    string
+	changes []git.FileChange
+	status  []git.StatusEntry
+}
+
+// errNotRepository is shown when the wizard is not started in a git repository
+var errNotRepository = errors.New("not a git repository (or any of the parent directories)")
+
+// loadCmd runs load in the background and reports its result as a sourceLoadedMsg
+func loadCmd(s source, load func() (interface{}, error)) tea.Cmd {
+	return func() tea.Msg {
+		value, err := load()
+		return sourceLoadedMsg{source: s, value: value, err: err}
+	}
+}
+
+// loadCmds starts loading every source that does not depend on another one.
+// Scopes are loaded once the nested configs are known and the history once
+// the log is, see handleLoaded.
+func loadCmds(ctx context.Context, repo git.Repository) []tea.Cmd {
+	return []tea.Cmd{
+		loadCmd(sourceRepository, func() (interface{}, error) {
+			if !repo.IsGitRepository(ctx) {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				return nil, errNotRepository
+			}
+			return nil, nil
+		}),
+		loadCmd(sourceSuperproject, func() (interface{}, error) {
+			return repo.GetSuperproject(ctx)
+		}),
+		loadCmd(sourceCleanup, func() (interface{}, error) {
+			return git.GetCleanupSettings(ctx, repo)
+		}),
+		loadStagedCmd(ctx, repo),
+		// The log is read once, for the examples and to seed the history
+		loadCmd(sourceLog, func() (interface{}, error) {
+			commits, err := repo.GetLog(ctx, history.MaxEntries)
+			if err != nil && ctx.Err() == nil {
+				// No commit yet
+				return []git.LogEntry(nil), nil
+			}
+			return commits, err
+		}),
+	}
+}
+
+// loadHistoryCmd loads the history in the background, seeding it with the
+// log on first use
+func loadHistoryCmd(ctx context.Context, repo git.Repository, log []git.LogEntry) tea.Cmd {
+	return loadCmd(sourceHistory, func() (interface{}, error) {
+		return history.LoadWithLog(ctx, repo, log)
+	})
+}
+
+// loadStagedCmd loads the staged changes in the background
+func loadStagedCmd(ctx context.Context, repo git.Repository) tea.Cmd {
+	return loadCmd(sourceStaged, func() (interface{}, error) {
+		root, err := repo.GetGitRootDir(ctx)
+		if err != nil {
+			return nil, err
+		}
+		changes, err := repo.GetStagedChanges(ctx)
+		if err != nil {
+			return nil, err
+		}
+		result := stagedResult{root: root, changes: changes}
+		if len(changes) == 0 {
+			// Without a status only the "nothing is staged" warning is shown
+			result.status, _ = repo.GetStatus(ctx)
+		}
+		return result, nil
+	})
+}
+
+// loadScopesCmd detects the scopes for the current config in the background
+func loadScopesCmd(ctx context.Context, cfg *config.Config, repo git.Repository) tea.Cmd {
+	return loadCmd(sourceScopes, func() (interface{}, error) {
+		return scopes(ctx, cfg, repo)
+	})
+}
+
+// loading reports whether any source is still being loaded
+func (m Model) loading() bool {
+	for _, status := range m.sources {
+		if !status.done {
+			return true
+		}
+	}
+	return false
+}
+
+// commitSources are the sources a commit depends on. The others only
+// help writing the message, so a commit never waits for them.
+var commitSources = []source{sourceRepository, sourceSuperproject, sourceCleanup, sourceStaged}
+
+// loadingCommit reports whether a source the commit depends on is still being loaded
+func (m Model) loadingCommit() bool {
+	for _, s := range commitSources {
+		if !m.sources[s].done {
+			return true
+		}
+	}
+	return false
+}
+
+// handleLoaded stores a loaded source and starts what depends on it
+func (m Model) handleLoaded(msg sourceLoadedMsg) (Model, tea.Cmd) {
+	m.sources[msg.source] = sourceStatus{done: true, err: msg.err}
+	var cmds []tea.Cmd
+
+	switch msg.source {
+	case sourceRepository:
+		if msg.err != nil {
+			// Nothing can be committed outside of a repository
+			m.err = msg.err
+			return m, nil
+		}
+
+	case sourceSuperproject:
+		if msg.err == nil {
+			m.superproject = msg.value.(string)
+		}
+
+	case sourceCleanup:
+		if msg.err == nil {
+			m.cleanup = msg.value.(git.CleanupSettings)
+		}
+
+	case sourceStaged:
+		if msg.err == nil {
+			staged := msg.value.(stagedResult)
+			m.staged = components.NewStagedPane(staged.changes)
+			if m.offerStaging(staged.status) {
+				// Nested configs and scopes depend on the staged files, so
+				// they are loaded again once the user is done staging
+				m.sources[sourceStaged] = sourceStatus{}
+				return m, m.sizeCmd()
+			}
+			// Nested configs are applied to the base config, so that those of
+			// files staged earlier do not remain
+			m.config = m.baseConfig.Clone()
+			choices, err := m.config.ApplyNested(staged.root, changedPaths(staged.changes))
+			if err != nil {
+				m.sources[sourceStaged] = sourceStatus{done: true, err: err}
+			} else if len(choices) > 0 {
+				// Scopes are loaded once a config has been chosen
+				choice := components.NewConfigChoiceModel(staged.root, choices)
+				m.configChoice = &choice
+				return m, m.sizeCmd()
+			}
+			m.rebuildSteps()
+			m.suggest()
+			cmds = append(cmds, analyzeCmd(m.ctx, m.repo))
+		}
+		cmds = append(cmds, loadScopesCmd(m.ctx, m.config, m.repo))
+
+	case sourceScopes:
+		var detected []config.Scope
+		if msg.err == nil {
+			detected = msg.value.([]config.Scope)
+		}
+		m.scopes = orderScopes(detected, m.history)
+		m.steps[StepScope] = components.NewScopeModel(m.scopes)
+		m.suggest()
+		cmds = append(cmds, m.sizeCmd())
+
+	case sourceHistory:
+		if msg.err == nil {
+			m.history = msg.value.(*history.History)
+			m.rebuildSteps()
+			if m.sources[sourceScopes].done {
+				m.scopes = orderScopes(m.scopes, m.history)
+				if m.activeStep < int(StepScope) {
+					m.steps[StepScope] = components.NewScopeModel(m.scopes)
+				}
+			}
+			m.suggest()
+			cmds = append(cmds, m.sizeCmd())
+		}
+
+	case sourceLog:
+		if msg.err != nil {
+			// The history cannot be seeded without the log either
+			m.sources[sourceHistory] = sourceStatus{done: true, err: msg.err}
+			break
+		}
+		log := msg.value.([]git.LogEntry)
+		m.examples = components.NewExamplesPane(log)
+		cmds = append(cmds, loadHistoryCmd(m.ctx, m.repo, log))
+	}
+
+	// A confirmed commit waits until everything it depends on is known
+	if m.pendingCommit && !m.loadingCommit() {
+		m.pendingCommit = false
+		cmds = append(cmds, m.commit())
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// rebuildSteps recreates the steps the user has not reached yet, so that
+// they use the config as changed by nested configs and the order of the
+// history. The type list is recreated too while it is shown, unless the user
+// has moved in it or filtered it; then it is recreated on their next visit,
+// see revisitTypes.
+func (m *Model) rebuildSteps() {
+	steps := newSteps(m.config, m.history)
+	for i := range m.steps {
+		switch {
+		case i == int(StepType) && i <= m.activeStep:
+			step, ok := m.steps[i].(components.CommitTypeModel)
+			if i == m.activeStep && !(ok && step.Touched()) {
+				m.steps[i] = steps[i]
+			} else {
+				m.staleTypes = true
+			}
+		case i > m.activeStep:
+			// Scopes are set when they have been loaded
+			if i != int(StepScope) || !m.sources[sourceScopes].done {
+				m.steps[i] = steps[i]
+			}
+		}
+	}
+}
+
+// revisitTypes recreates the type list the user comes back to, when it
+// could not be recreated while they were using it
+func (m *Model) revisitTypes() tea.Cmd {
+	m.staleTypes = false
+	m.steps[StepType] = newSteps(m.config, m.history)[StepType]
+	m.suggestType()
+	return m.sizeCmd()
+}
+
+// changedPaths returns the paths of the changed files, after renames
+func changedPaths(changes []git.FileChange) []string {
+	files := make([]string, len(changes))
+	for i, change := range changes {
+		files[i] = change.Path
+	}
+	return files
+}
+
+// changesToCommit returns the staged changes of the commit whose message is
+// being written: all of them, or those of the current planned commit
+func (m Model) changesToCommit() []git.FileChange {
+	if m.plan != nil && m.plan.current() < len(m.plan.files) {
+		return m.plan.files[m.plan.current()]
+	}
+	return m.staged.Changes()
+}
+
+// suggest suggests the type, scope and message for the changes in the steps
+func (m *Model) suggest() {
+	m.suggestType()
+	m.suggestScope()
+	m.suggestMessage()
+	m.suggestBreaking()
+}
+
+// bumpOnly reports whether the staged changes only bump dependencies, so
+// that the bump message is proposed. It does not apply to planned commits.
+func (m Model) bumpOnly() bool {
+	return len(m.bumps) > 0 && m.plan == nil
+}
+
+// suggestType suggests a type for the changes in the type step, if it is
+// still a type list
+func (m *Model) suggestType() {
+	step, ok := m.steps[StepType].(components.CommitTypeModel)
+	if !ok {
+		return
+	}
+	suggestion, _ := m.config.SuggestType(changedPaths(m.changesToCommit()))
+	if m.bumpOnly() && m.config.HasType(bump.Type) {
+		suggestion = config.TypeSuggestion{Type: bump.Type, Reason: "the staged changes only bump dependencies"}
+	}
+	step.Suggest(suggestion)
+	m.steps[StepType] = step
+}
+
+// suggestScope suggests scopes for the changes in the scope step, once the
+// scopes have been loaded
+func (m *Model) suggestScope() {
+	step, ok := m.steps[StepScope].(components.ScopeModel)
+	if !ok || !m.sources[sourceScopes].done {
+		return
+	}
+	suggestion, _ := config.SuggestScope(m.scopes, changedPaths(m.changesToCommit()))
+	if m.bumpOnly() {
+		suggestion = config.ScopeSuggestion{Scopes: []string{bump.Scope}, Reason: "the staged changes only bump dependencies"}
+	}
+	step.Suggest(suggestion)
+	m.steps[StepScope] = step
+}
+
+// suggestMessage prefills the subject and body proposed for dependency
+// bumps, or clears a proposal that no longer applies, and offers draft
+// subjects from the diff
+func (m *Model) suggestMessage() {
+	var subject, body string
+	if m.bumpOnly() {
+		subject, body = bump.Message(m.bumps)
+	}
+	if step, ok := m.steps[StepSubject].(components.SubjectModel); ok {
+		step.Prefill(subject)
+		step.SetSuggestions(draft.Subjects(m.diffToCommit()))
+		if m.history != nil {
+			step.SetHistory(m.history.Subjects())
+		}
+		m.steps[StepSubject] = step
+	}
+	if step, ok := m.steps[StepBody].(components.BodyModel); ok {
+		step.Prefill(body)
+		m.steps[StepBody] = step
+	}
+}
+
+// diffToCommit returns the staged diff of the files of the commit whose
+// message is being written
+func (m Model) diffToCommit() []diff.File {
+	if m.plan == nil {
+		return m.stagedDiff
+	}
+	files := make(map[string]bool)
+	for _, file := range paths(m.changesToCommit()) {
+		files[file] = true
+	}
+	var diffs []diff.File
+	for _, f := range m.stagedDiff {
+		if files[f.Path()] {
+			diffs = append(diffs, f)
+		}
+	}
+	return diffs
+}
+
+// breakingToCommit returns the incompatible API changes in the packages of
+// the commit whose message is being written
+func (m Model) breakingToCommit() []apidiff.Change {
+	if m.plan == nil {
+		return m.breaking
+	}
+	dirs := make(map[string]bool)
+	for _, file := range paths(m.changesToCommit()) {
+		dirs[path.Dir(file)] = true
+	}
+	var changes []apidiff.Change
+	for _, c := range m.breaking {
+		if dirs[c.Package] {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// suggestBreaking marks the change as breaking with a summary of the
+// incompatible API changes, or clears a summary that no longer applies
+func (m *Model) suggestBreaking() {
+	if step, ok := m.steps[StepBreaking].(components.BreakingModel); ok {
+		step.Prefill(apidiff.Summary(m.breakingToCommit()))
+		m.steps[StepBreaking] = step
+	}
+}
+
+// analysisMsg is sent with what the staged changes tell about the message:
+// their parsed diff, their dependency bumps, none unless they only bump dependencies, and the
+// incompatible changes to the exported Go API
+type analysisMsg struct {
+	files    []diff.File
+	bumps    []bump.Bump
+	breaking []apidiff.Change
+}
+
+// analyzeCmd analyzes the staged changes in the background. Failures only
+// mean that nothing is proposed.
+func analyzeCmd(ctx context.Context, repo git.Repository) tea.Cmd {
+	return func() tea.Msg {
+		var files []diff.File
+		if text, err := repo.GetStagedDiff(ctx); err == nil {
+			files, _ = diff.Parse(text)
+		}
+		bumps, _ := bump.Detect(ctx, repo, bump.Detectors())
+		breaking, _ := apidiff.Detect(ctx, repo)
+		return analysisMsg{files: files, bumps: bumps, breaking: breaking}
+	}
+}
+
+// sizeCmd resends the window size, so that a replaced step can lay itself out
+func (m Model) sizeCmd() tea.Cmd {
+	if !m.ready {
+		return nil
+	}
+	size := tea.WindowSizeMsg{Width: m.width, Height: m.height}
+	return func() tea.Msg { return size }
+}
+
+// statusView renders the sources that are still loading or failed to load
+func (m Model) statusView() string {
+	var loading []string
+	var warnings []string
+	for s := source(0); s < sourceCount; s++ {
+		status := m.sources[s]
+		switch {
+		case !status.done && s == sourceStaged && m.staging != nil:
+			// Loaded again once the user is done staging
+		case !status.done:
+			loading = append(loading, s.String())
+		case status.err != nil && s.degraded() != "":
+			warnings = append(warnings, styles.WarningStyle.Render(fmt.Sprintf(
+				"⚠ Could not load %s, %s: %v", s, s.degraded(), status.err)))
+		}
+	}
+
+	var lines []string
+	if len(loading) > 0 {
+		lines = append(lines, m.spinner.View()+styles.HelpStyle.Render("Loading "+strings.Join(loading, ", ")))
+	}
+	return strings.Join(append(lines, warnings...), "\n")
+}
+
+// loadingStepView is shown instead of a step whose data is still being loaded
+func (m Model) loadingStepView(s source) string {
+	return m.spinner.View() + styles.InfoStyle.Render(fmt.Sprintf("Loading %s...", s))
+}
+
+// newSpinner creates the spinner shown while sources are loading
+func newSpinner() spinner.Model {
+	return spinner.New(spinner.WithSpinner(spinner.Dot), spinner.WithStyle(styles.InfoStyle))
+}
@@ -0,0 +1,165 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/a1yama/git-cz-go/internal/git"
+	"github.com/a1yama/git-cz-go/internal/model"
+	"github.com/a1yama/git-cz-go/internal/ui/components"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// commitPlan holds the staged files of the planned commits and the messages
+// written for them so far, in commit order
+type commitPlan struct {
+	files    [][]git.FileChange
+	messages []model.CommitMessage
+}
+
+// current returns the index of the commit whose message is being written
+func (p *commitPlan) current() int {
+	return len(p.messages)
+}
+
+// paths returns the paths to commit for the files, with both paths of a rename
+func paths(files []git.FileChange) []string {
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+		if f.Status == "R" {
+			paths = append(paths, f.OldPath)
+		}
+	}
+	return paths
+}
+
+// planCmd creates the planned commits in order
+func planCmd(ctx context.Context, repo git.Repository, commits []git.PlannedCommit, opts git.CommitOptions) tea.Cmd {
+	return func() tea.Msg {
+		created, err := git.CommitPlan(ctx, repo, commits, opts)
+		if err != nil {
+			err = fmt.Errorf("%d of %d planned commits were created, the rest of the changes is still staged: %w",
+				created, len(commits), err)
+		}
+		return commitDoneMsg{err: err}
+	}
+}
+
+// openPlanner opens the commit planner over the current step, once the staged
+// files are known
+func (m Model) openPlanner() (Model, tea.Cmd) {
+	if !m.sources[sourceStaged].done || m.plan != nil {
+		return m, nil
+	}
+	planner := components.NewPlannerModel(m.staged.Changes())
+	m.planner = &planner
+	return m, m.sizeCmd()
+}
+
+// updatePlanner handles updates while the staged files are split into commits
+func (m Model) updatePlanner(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.ready = true
+
+	case tea.KeyMsg:
+		if key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+c"))) {
+			return m.quit()
+		}
+
+	case components.PlanMsg:
+		m.planner = nil
+		m.plan = &commitPlan{files: msg.Commits}
+		return m.nextPlannedCommit()
+
+	case components.PlannerClosedMsg:
+		m.planner = nil
+		return m, m.steps[m.activeStep].Init()
+	}
+
+	updated, cmd := m.planner.Update(msg)
+	planner := updated.(components.PlannerModel)
+	m.planner = &planner
+	return m, cmd
+}
+
+// nextPlannedCommit starts the wizard over for the message of the next
+// planned commit, with the type and scope suggested for its files
+func (m Model) nextPlannedCommit() (Model, tea.Cmd) {
+	steps := newSteps(m.config, m.history)
+	if m.sources[sourceScopes].done {
+		steps[StepScope] = components.NewScopeModel(m.scopes)
+	}
+	m.steps = steps
+	m.staleTypes = false
+	m.activeStep = 0
+	m.commitMessage = model.CommitMessage{}
+	m.suggest()
+	return m, tea.Batch(m.steps[0].Init(), m.sizeCmd())
+}
+
+// planConfirmed records the message of the current planned commit and goes
+// on with the next one, or to the review once every commit has a message
+func (m Model) planConfirmed() (Model, tea.Cmd) {
+	m.plan.messages = append(m.plan.messages, m.commitMessage)
+	if m.plan.current() < len(m.plan.files) {
+		return m.nextPlannedCommit()
+	}
+
+	commits := make([]components.PlannedCommitView, len(m.plan.files))
+	for i, files := range m.plan.files {
+		commits[i] = components.PlannedCommitView{
+			Message: m.cleanup.Clean(m.plan.messages[i].Format()),
+			Paths:   paths(files),
+		}
+	}
+	review := components.NewPlanReviewModel(commits)
+	m.planReview = &review
+	return m, m.sizeCmd()
+}
+
+// updatePlanReview handles updates while the planned commits are reviewed
+func (m Model) updatePlanReview(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.ready = true
+
+	case tea.KeyMsg:
+		if key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+c"))) {
+			return m.quit()
+		}
+
+	case components.PlanConfirmedMsg:
+		m.planReview = nil
+		m.committing = true
+		if m.loadingCommit() {
+			// The cleanup mode is not known yet, commit once it is
+			m.pendingCommit = true
+			return m, nil
+		}
+		return m, m.commit()
+
+	case components.PlanCancelledMsg:
+		return m.quit()
+	}
+
+	updated, cmd := m.planReview.Update(msg)
+	review := updated.(components.PlanReviewModel)
+	m.planReview = &review
+	return m, cmd
+}
+
+// planCommits returns the planned commits with their messages
+func (m Model) planCommits() []git.PlannedCommit {
+	commits := make([]git.PlannedCommit, len(m.plan.files))
+	for i, files := range m.plan.files {
+		commits[i] = git.PlannedCommit{Message: m.plan.messages[i].Format(), Paths: paths(files)}
+	}
+	return commits
+}
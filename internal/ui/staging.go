@@ -0,0 +1,194 @@
+package ui
+
+import (
+	"context"
+
+	"github.com/a1yama/git-cz-go/internal/diff"
+	"github.com/a1yama/git-cz-go/internal/git"
+	"github.com/a1yama/git-cz-go/internal/ui/components"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// stagingUpdatedMsg is sent with the git status and the staged changes after
+// the staging step was opened or changed the index
+type stagingUpdatedMsg struct {
+	status  []git.StatusEntry
+	changes []git.FileChange
+	err     error
+}
+
+// unstagedDiffLoadedMsg is sent when the unstaged changes have been loaded
+// for the hunk selector
+type unstagedDiffLoadedMsg struct {
+	files []diff.File
+	err   error
+}
+
+// stagingStatusCmd loads the git status and the staged changes
+func stagingStatusCmd(ctx context.Context, repo git.Repository) tea.Cmd {
+	return func() tea.Msg {
+		return stagingStatus(ctx, repo)
+	}
+}
+
+// stageCmd stages or unstages files and then reloads the status
+func stageCmd(ctx context.Context, repo git.Repository, msg components.StageMsg) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		if msg.Unstage {
+			err = repo.Unstage(ctx, msg.Paths)
+		} else {
+			err = repo.Stage(ctx, msg.Paths)
+		}
+		if err != nil {
+			return stagingUpdatedMsg{err: err}
+		}
+		return stagingStatus(ctx, repo)
+	}
+}
+
+// unstagedDiffCmd loads and parses the unstaged changes
+func unstagedDiffCmd(ctx context.Context, repo git.Repository) tea.Cmd {
+	return func() tea.Msg {
+		text, err := repo.GetUnstagedDiff(ctx)
+		if err != nil {
+			return unstagedDiffLoadedMsg{err: err}
+		}
+		files, err := diff.Parse(text)
+		return unstagedDiffLoadedMsg{files: files, err: err}
+	}
+}
+
+// applyCmd applies the patch of the selected hunks to the index and then
+// reloads the status
+func applyCmd(ctx context.Context, repo git.Repository, patch string) tea.Cmd {
+	return func() tea.Msg {
+		if err := repo.ApplyToIndex(ctx, patch); err != nil {
+			return stagingUpdatedMsg{err: err}
+		}
+		return stagingStatus(ctx, repo)
+	}
+}
+
+// stagingStatus returns the git status and the staged changes
+func stagingStatus(ctx context.Context, repo git.Repository) stagingUpdatedMsg {
+	status, err := repo.GetStatus(ctx)
+	if err != nil {
+		return stagingUpdatedMsg{err: err}
+	}
+	changes, err := repo.GetStagedChanges(ctx)
+	return stagingUpdatedMsg{status: status, changes: changes, err: err}
+}
+
+// offerStaging opens the staging step before the wizard when nothing is
+// staged but there are changes that could be. It is only offered once.
+func (m *Model) offerStaging(status []git.StatusEntry) bool {
+	if m.stagingOffered || len(status) == 0 || !m.staged.Empty() ||
+		containsArg(m.config.CommitArgs, "--allow-empty") {
+		return false
+	}
+	m.stagingOffered = true
+	staging := components.NewStagingModel()
+	staging.SetStatus(status, nil)
+	m.staging = &staging
+	return true
+}
+
+// openStaging opens the staging step over the current step. It is not
+// offered again once the user has staged files this way.
+func (m Model) openStaging() (Model, tea.Cmd) {
+	staging := components.NewStagingModel()
+	m.staging = &staging
+	m.stagingOffered = true
+	return m, tea.Batch(stagingStatusCmd(m.ctx, m.repo), m.sizeCmd())
+}
+
+// updateStaging handles updates while files are being staged
+func (m Model) updateStaging(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.ready = true
+
+	case tea.KeyMsg:
+		if key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+c"))) {
+			return m.quit()
+		}
+
+	case components.StageMsg:
+		return m, stageCmd(m.ctx, m.repo, msg)
+
+	case components.PartialStageMsg:
+		return m, unstagedDiffCmd(m.ctx, m.repo)
+
+	case components.StagingDoneMsg:
+		m.staging = nil
+		// The nested configs, scopes and analysis depend on the staged files,
+		// so they are loaded again, whether the files were staged before the
+		// wizard started or with Ctrl+G; see handleLoaded. The spinner may
+		// have stopped once everything was loaded, so it is started again.
+		m.sources[sourceStaged] = sourceStatus{}
+		return m, tea.Batch(m.steps[m.activeStep].Init(), m.sizeCmd(), loadStagedCmd(m.ctx, m.repo), m.spinner.Tick)
+	}
+
+	updated, cmd := m.staging.Update(msg)
+	staging := updated.(components.StagingModel)
+	m.staging = &staging
+	return m, cmd
+}
+
+// handleUnstagedDiffLoaded opens the hunk selector, or shows why it cannot be opened
+func (m Model) handleUnstagedDiffLoaded(msg unstagedDiffLoadedMsg) (Model, tea.Cmd) {
+	if m.staging == nil {
+		return m, nil
+	}
+	if msg.err != nil {
+		m.staging.SetStatus(nil, msg.err)
+		return m, nil
+	}
+	hunks := components.NewHunkSelectorModel(msg.files)
+	m.hunks = &hunks
+	return m, m.sizeCmd()
+}
+
+// updateHunks handles updates while hunks are being selected
+func (m Model) updateHunks(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.ready = true
+
+	case tea.KeyMsg:
+		if key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+c"))) {
+			return m.quit()
+		}
+
+	case components.HunksSelectedMsg:
+		m.hunks = nil
+		return m, applyCmd(m.ctx, m.repo, msg.Patch)
+
+	case components.HunksClosedMsg:
+		m.hunks = nil
+		return m, nil
+	}
+
+	updated, cmd := m.hunks.Update(msg)
+	hunks := updated.(components.HunkSelectorModel)
+	m.hunks = &hunks
+	return m, cmd
+}
+
+// handleStagingUpdated shows the new status and refreshes the staged changes pane
+func (m Model) handleStagingUpdated(msg stagingUpdatedMsg) Model {
+	if m.staging != nil {
+		m.staging.SetStatus(msg.status, msg.err)
+	}
+	if msg.err == nil {
+		m.staged = components.NewStagedPane(msg.changes)
+		m.suggest()
+	}
+	return m
+}
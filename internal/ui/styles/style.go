@@ -27,6 +27,18 @@ var (
 			MarginBottom(1).
 			Padding(0, 1)
 
+	ProfileStyle = lipgloss.NewStyle().
+			Foreground(accentColor).
+			Border(lipgloss.NormalBorder(), false, false, false, true).
+			BorderForeground(mutedColor).
+			Padding(0, 1)
+
+	SubmoduleStyle = lipgloss.NewStyle().
+			Foreground(infoColor).
+			Border(lipgloss.NormalBorder(), false, false, false, true).
+			BorderForeground(mutedColor).
+			Padding(0, 1)
+
 	ProgressStyle = lipgloss.NewStyle().
 			Background(secondaryColor).
 			Foreground(lipgloss.Color("255")).
@@ -77,4 +89,25 @@ var (
 
 	InfoStyle = lipgloss.NewStyle().
 			Foreground(infoColor)
+
+	// Diff styles
+	AddedStyle = lipgloss.NewStyle().
+			Foreground(successColor)
+
+	RemovedStyle = lipgloss.NewStyle().
+			Foreground(errorColor)
+
+	MatchStyle = lipgloss.NewStyle().
+			Background(warningColor).
+			Foreground(lipgloss.Color("0"))
+
+	// Pane styles
+	PaneStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(mutedColor).
+			Padding(0, 1)
+
+	PaneTitleStyle = lipgloss.NewStyle().
+			Foreground(primaryColor).
+			Bold(true)
 )
@@ -1,21 +1,41 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"path"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/a1yama/git-cz-go/internal/apidiff"
+	"github.com/a1yama/git-cz-go/internal/bump"
 	"github.com/a1yama/git-cz-go/internal/config"
+	"github.com/a1yama/git-cz-go/internal/diff"
 	"github.com/a1yama/git-cz-go/internal/git"
+	"github.com/a1yama/git-cz-go/internal/history"
 	"github.com/a1yama/git-cz-go/internal/model"
+	"github.com/a1yama/git-cz-go/internal/mood"
 	"github.com/a1yama/git-cz-go/internal/ui/components"
 	"github.com/a1yama/git-cz-go/internal/ui/styles"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
 )
 
 // Model is the main UI model
 type Model struct {
 	config        *config.Config
+	repo          git.Repository
+	ctx           context.Context
+	cancel        context.CancelFunc
+	committing    bool
+	pendingCommit bool
+	cleanup       git.CleanupSettings
+	superproject  string
 	commitMessage model.CommitMessage
 	activeStep    int
 	steps         []tea.Model
@@ -23,6 +43,63 @@ type Model struct {
 	height        int
 	ready         bool
 	err           error
+
+	// sources holds the loading state of the repository data, see loadCmds
+	sources [sourceCount]sourceStatus
+	spinner spinner.Model
+
+	// scopes are the configured or detected scopes, once loaded
+	scopes []config.Scope
+	// bumps are the dependency bumps of the staged changes, set when they
+	// only bump dependencies, see suggestMessage
+	bumps []bump.Bump
+	// breaking are the incompatible changes to the exported Go API in the
+	// staged changes, see suggestBreaking
+	breaking []apidiff.Change
+	// stagedDiff is the parsed staged diff that draft subjects are derived from
+	stagedDiff []diff.File
+	// history holds the earlier messages that order the types and scopes and
+	// complete the subject, once loaded
+	history *history.History
+	// staleTypes is set when the type list could not be recreated for a new
+	// order or config because the user was using it; it is recreated when
+	// they come back to it, see rebuildSteps
+	staleTypes bool
+
+	// examples shows recent commits of the highlighted type and scope next
+	// to their steps, see withExamplesPane
+	examples components.ExamplesPane
+
+	// staged lists the staged changes, shown in a pane toggled with Ctrl+T
+	staged     components.StagedPane
+	showStaged bool
+
+	// baseConfig is the config before nested configs are applied, which is
+	// started over from whenever the staged files change
+	baseConfig *config.Config
+	// configChoice is set while the user has to pick one of several
+	// conflicting nested config files, before the wizard starts or after
+	// staging files with Ctrl+G
+	configChoice *components.ConfigChoiceModel
+
+	// staging is set while the user stages files, before the wizard when
+	// nothing is staged or over the current step with Ctrl+G
+	staging        *components.StagingModel
+	stagingOffered bool
+	// hunks is set while hunks of the unstaged changes are picked from the staging step
+	hunks *components.HunkSelectorModel
+
+	// diffViewer is set while the staged diff is shown over the current step,
+	// opened with Ctrl+O
+	diffViewer *components.DiffViewerModel
+
+	// planner is set while the staged files are split into several commits,
+	// opened with Ctrl+P. Then plan holds the commits while their messages
+	// are written with the wizard, and planReview shows them all before they
+	// are created.
+	planner    *components.PlannerModel
+	plan       *commitPlan
+	planReview *components.PlanReviewModel
 }
 
 // Step represents a commit message input step
@@ -30,47 +107,171 @@ type Step int
 
 const (
 	StepType Step = iota
+	StepScope
 	StepSubject
+	StepBody
+	StepBreaking
 	StepConfirm
 )
 
-// New creates a new UI model
-func New(cfg *config.Config) Model {
-	// ステップを初期化
-	steps := []tea.Model{
-		components.NewCommitTypeModel(cfg.Types, cfg.UseEmoji),
-		components.NewSubjectModel(cfg.MaxSubjectLength),
-		components.NewConfirmModel(),
-	}
+// commitDoneMsg is sent when git commit has finished
+type commitDoneMsg struct {
+	err error
+}
+
+// stagedDiffLoadedMsg is sent when the staged diff for the viewer has been loaded
+type stagedDiffLoadedMsg struct {
+	files []diff.File
+	err   error
+}
 
-	return Model{
+// New creates a new UI model for the given repository.
+// Git operations are stopped when ctx is cancelled or the user quits.
+// Repository data is loaded in the background once the program starts, see Init.
+func New(ctx context.Context, cfg *config.Config, repo git.Repository) Model {
+	ctx, cancel := context.WithCancel(ctx)
+	m := Model{
 		config:     cfg,
+		baseConfig: cfg.Clone(),
+		repo:       repo,
+		ctx:        ctx,
+		cancel:     cancel,
 		activeStep: 0,
-		steps:      steps, // 初期化したステップを設定
 		ready:      false,
+		spinner:    newSpinner(),
+	}
+
+	// ステップを初期化
+	m.steps = newSteps(cfg, nil) // 初期化したステップを設定
+
+	return m
+}
+
+// newSteps creates the wizard steps for the given config, with the types
+// ordered by their use in the history, if it has been loaded.
+// The scope step is replaced once the scopes have been loaded.
+func newSteps(cfg *config.Config, hist *history.History) []tea.Model {
+	subject := components.NewSubjectModel(cfg.MaxSubjectLength)
+	if cfg.ImperativeCheck != config.ImperativeOff {
+		subject.SetMoodCheck(mood.NewLexicon(cfg.ImperativeVerbs), cfg.ImperativeCheck == config.ImperativeError)
+	}
+	return []tea.Model{
+		components.NewCommitTypeModel(orderTypes(cfg.Types, hist), cfg.UseEmoji),
+		components.NewScopeModel(nil),
+		subject,
+		components.NewBodyModel(),
+		components.NewBreakingModel(),
+		components.NewConfirmModel(),
 	}
 }
 
+// scopes returns the configured scopes, or the ones detected from the repository
+func scopes(ctx context.Context, cfg *config.Config, repo git.Repository) ([]config.Scope, error) {
+	if len(cfg.Scopes) > 0 {
+		return cfg.Scopes, nil
+	}
+
+	detected, err := git.DetectScopes(ctx, repo, cfg.ScopeDirs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nested directories are named after their last element, with the path as
+	// description, unless directories in different parents share that name;
+	// those are named after their whole path
+	names := make(map[string]int)
+	for _, dir := range detected {
+		names[path.Base(dir)]++
+	}
+	scopes := make([]config.Scope, 0, len(detected))
+	for _, dir := range detected {
+		scope := config.Scope{Name: path.Base(dir), Paths: []string{dir + "/**"}}
+		if names[scope.Name] > 1 {
+			scope.Name = dir
+		}
+		if scope.Name != dir {
+			scope.Description = dir
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
 // Init関数も修正
 func (m Model) Init() tea.Cmd {
 	// ステップがすでに初期化されていることを確認
 	if len(m.steps) == 0 {
 		// 万が一ステップが空の場合は、ここで初期化
-		m.steps = []tea.Model{
-			components.NewCommitTypeModel(m.config.Types, m.config.UseEmoji),
-			components.NewSubjectModel(m.config.MaxSubjectLength),
-			components.NewConfirmModel(),
-		}
+		m.steps = newSteps(m.config, m.history)
 	}
 
-	// 最初のステップの初期化コマンドを返す
-	return m.steps[0].Init()
+	// 最初のステップの初期化コマンドと、リポジトリ情報の読み込みを返す
+	cmds := append(loadCmds(m.ctx, m.repo), m.steps[0].Init(), m.spinner.Tick)
+	return tea.Batch(cmds...)
 }
 
 // Update handles UI updates
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
+	// Loading continues in the background whatever is shown
+	switch msg := msg.(type) {
+	case sourceLoadedMsg:
+		return m.handleLoaded(msg)
+	case spinner.TickMsg:
+		if !m.loading() {
+			// Stop ticking once everything has been loaded
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case stagedDiffLoadedMsg:
+		if m.diffViewer != nil {
+			if msg.err != nil {
+				m.diffViewer.SetError(msg.err)
+			} else {
+				m.diffViewer.SetDiff(msg.files)
+			}
+		}
+		return m, nil
+	case analysisMsg:
+		m.stagedDiff = msg.files
+		m.bumps = msg.bumps
+		m.breaking = msg.breaking
+		m.suggest()
+		return m, nil
+	case stagingUpdatedMsg:
+		return m.handleStagingUpdated(msg), nil
+	case unstagedDiffLoadedMsg:
+		return m.handleUnstagedDiffLoaded(msg)
+	}
+
+	if m.diffViewer != nil {
+		if size, ok := msg.(tea.WindowSizeMsg); ok {
+			// The step is resized too, so it fits once the viewer is closed
+			m.diffViewer.SetSize(size.Width, size.Height)
+		} else {
+			return m.updateDiffViewer(msg)
+		}
+	}
+
+	if m.hunks != nil {
+		return m.updateHunks(msg)
+	}
+	if m.staging != nil {
+		return m.updateStaging(msg)
+	}
+	if m.configChoice != nil {
+		return m.updateConfigChoice(msg)
+	}
+	if m.planner != nil {
+		return m.updatePlanner(msg)
+	}
+	if m.planReview != nil {
+		return m.updatePlanReview(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
@@ -78,35 +279,73 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.ready = true
 
 	case tea.KeyMsg:
+		// Ctrl+C always quits, stopping any running git command
+		if key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+c"))) {
+			return m.quit()
+		}
+
+		// Any key quits once an error is shown; other keys wait for the commit
+		if m.err != nil {
+			return m.quit()
+		}
+		if m.committing {
+			return m, nil
+		}
+
+		// Ctrl+T toggles the staged changes pane, also while typing
+		if key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+t"))) {
+			m.showStaged = !m.showStaged
+			return m, nil
+		}
+
+		// Ctrl+O opens the staged diff over the current step, also while typing
+		if key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+o"))) {
+			viewer := components.NewDiffViewerModel()
+			viewer.SetSize(m.width, m.height)
+			m.diffViewer = &viewer
+			return m, stagedDiffCmd(m.ctx, m.repo)
+		}
+
+		// Ctrl+G stages and unstages files without leaving the wizard; the
+		// planned commits are fixed once the planner is done
+		if key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+g"))) && m.plan == nil {
+			return m.openStaging()
+		}
+
+		// Ctrl+P splits the staged files into several commits
+		if key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+p"))) {
+			return m.openPlanner()
+		}
+
 		// テキスト入力フォーカス中はグローバルショートカットを無効化
-		isInputFocused := m.activeStep == int(StepSubject)
+		// The filter of a list is typed like text too
+		isInputFocused := m.activeStep == int(StepSubject) || m.activeStep == int(StepBody) ||
+			m.activeStep == int(StepBreaking) || m.filtering()
 
 		// Global keybindings（テキスト入力中は無効）
 		if !isInputFocused {
-			switch {
-			case key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+c", "q"))):
-				return m, tea.Quit
-
-			case key.Matches(msg, key.NewBinding(key.WithKeys("esc"))):
-				if m.activeStep > 0 {
-					m.activeStep--
-					return m, m.steps[m.activeStep].Init()
-				}
-				return m, tea.Quit
-			}
-		} else {
-			// テキスト入力中はCtrl+Cのみ終了として扱う
-			if key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+c"))) {
-				return m, tea.Quit
+			if key.Matches(msg, key.NewBinding(key.WithKeys("q"))) {
+				return m.quit()
 			}
-			// Escキーはテキスト入力中でも前のステップに戻る
-			if key.Matches(msg, key.NewBinding(key.WithKeys("esc"))) {
-				if m.activeStep > 0 {
-					m.activeStep--
-					return m, m.steps[m.activeStep].Init()
+		}
+
+		// Escキーはテキスト入力中でも前のステップに戻る
+		// While a list is filtered it cancels the filter instead
+		if key.Matches(msg, key.NewBinding(key.WithKeys("esc"))) && !m.filtering() {
+			if m.activeStep > 0 {
+				m.activeStep--
+				if m.activeStep == int(StepType) && m.staleTypes {
+					cmd := m.revisitTypes()
+					return m, tea.Batch(cmd, m.steps[m.activeStep].Init())
 				}
-				return m, tea.Quit
+				return m, m.steps[m.activeStep].Init()
 			}
+			return m.quit()
+		}
+
+		// The scope list cannot be used before the scopes have been loaded
+		if m.activeStep == int(StepScope) && !m.sources[sourceScopes].done {
+			return m, nil
 		}
 
 	// 他のメッセージハンドリング...
@@ -126,24 +365,59 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, m.steps[m.activeStep].Init()
 
+	case components.ScopeSelectedMsg:
+		m.commitMessage.Scope = msg.Scope
+		m.activeStep++
+		return m, m.steps[m.activeStep].Init()
+
 	case components.SubjectSubmittedMsg:
 		m.commitMessage.Subject = msg.Subject
 		m.activeStep++
 		return m, m.steps[m.activeStep].Init()
 
+	case components.BodySubmittedMsg:
+		m.commitMessage.Body = msg.Body
+		m.activeStep++
+		return m, m.steps[m.activeStep].Init()
+
+	case components.BreakingSubmittedMsg:
+		m.commitMessage.Breaking = msg.Breaking
+		m.commitMessage.BreakingChange = msg.Description
+		m.activeStep++
+		return m, m.steps[m.activeStep].Init()
+
 	case components.ConfirmMsg:
+		if msg.Confirmed && m.plan != nil {
+			return m.planConfirmed()
+		}
 		if msg.Confirmed {
-			commitMsg := m.commitMessage.Format()
-			return m, tea.Sequence(
-				commitCmd(commitMsg),
-				tea.Quit,
-			)
+			m.committing = true
+			if m.loadingCommit() {
+				// The cleanup mode is not known yet, commit once it is
+				m.pendingCommit = true
+				return m, nil
+			}
+			return m, m.commit()
+		}
+		return m.quit()
+
+	case commitDoneMsg:
+		m.committing = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
 		}
-		return m, tea.Quit
+		m.recordHistory()
+		return m.quit()
 	}
 
 	// Pass the message to the current step
 	if m.activeStep < len(m.steps) {
+		if size, ok := msg.(tea.WindowSizeMsg); ok && m.hasExamplesPane() {
+			// Leave room for the examples pane beside the list
+			size.Width -= examplesPaneWidth(size.Width) + 2
+			msg = size
+		}
 		updatedStep, cmd := m.steps[m.activeStep].Update(msg)
 		m.steps[m.activeStep] = updatedStep
 		cmds = append(cmds, cmd)
@@ -152,6 +426,70 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// commit starts committing the message, or the planned commits
+func (m Model) commit() tea.Cmd {
+	opts := git.CommitOptions{Cleanup: m.cleanup.Mode, Args: m.config.CommitArgs}
+	if m.plan != nil {
+		return planCmd(m.ctx, m.repo, m.planCommits(), opts)
+	}
+	return commitCmd(m.ctx, m.repo, m.commitMessage.Format(), opts)
+}
+
+// quit stops any running git command and quits the program
+func (m Model) quit() (tea.Model, tea.Cmd) {
+	m.cancel()
+	return m, tea.Quit
+}
+
+// updateConfigChoice handles updates while a nested config has to be chosen
+func (m Model) updateConfigChoice(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.ready = true
+
+	case tea.KeyMsg:
+		if key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+c", "q", "esc"))) {
+			return m.quit()
+		}
+
+	case components.ConfigChosenMsg:
+		if err := m.config.ApplyFile(msg.Path); err != nil {
+			m.err = err
+		}
+		m.configChoice = nil
+		m.rebuildSteps()
+		m.suggest()
+		return m, tea.Batch(m.steps[m.activeStep].Init(), loadScopesCmd(m.ctx, m.config, m.repo), analyzeCmd(m.ctx, m.repo))
+	}
+
+	updated, cmd := m.configChoice.Update(msg)
+	choice := updated.(components.ConfigChoiceModel)
+	m.configChoice = &choice
+	return m, cmd
+}
+
+// updateDiffViewer handles updates while the staged diff is shown
+func (m Model) updateDiffViewer(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+c"))) {
+			return m.quit()
+		}
+
+	case components.DiffClosedMsg:
+		// The step was left untouched, only its cursor blink has to be restarted
+		m.diffViewer = nil
+		return m, m.steps[m.activeStep].Init()
+	}
+
+	updated, cmd := m.diffViewer.Update(msg)
+	viewer := updated.(components.DiffViewerModel)
+	m.diffViewer = &viewer
+	return m, cmd
+}
+
 // View renders the UI
 func (m Model) View() string {
 	if !m.ready {
@@ -159,62 +497,271 @@ func (m Model) View() string {
 	}
 
 	if m.err != nil {
-		return fmt.Sprintf("Error: %v", m.err)
+		return errorView(m.err, m.config)
+	}
+
+	if m.diffViewer != nil {
+		return m.diffViewer.View()
+	}
+
+	if m.committing {
+		view := styles.InfoStyle.Render("Committing...")
+		if m.pendingCommit {
+			view = m.statusView()
+		}
+		return view + "\n\n" + styles.HelpStyle.Render("Ctrl+C: Abort")
 	}
 
 	var stepTitle string
 	switch m.activeStep {
 	case int(StepType):
 		stepTitle = "Select the type of change that you're committing"
+	case int(StepScope):
+		stepTitle = "Select the scope of this change (optional)"
 	case int(StepSubject):
 		stepTitle = "Write a short, imperative tense description of the change"
+	case int(StepBody):
+		stepTitle = "Write a longer description of the change (optional)"
+	case int(StepBreaking):
+		stepTitle = "Is this a breaking change?"
 	case int(StepConfirm):
 		stepTitle = "Confirm your commit message"
 	}
+	if m.staging != nil {
+		stepTitle = "Stage the changes to commit"
+	}
+	if m.hunks != nil {
+		stepTitle = "Select the hunks to stage"
+	}
+	if m.planner != nil {
+		stepTitle = "Split the staged files into commits"
+	}
+	if m.planReview != nil {
+		stepTitle = "Review the planned commits"
+	}
+	if m.configChoice != nil {
+		stepTitle = "The staged files span nested configs that cannot be merged, choose one"
+	}
 
 	// Display progress
 	progress := fmt.Sprintf(" %d/%d ", m.activeStep+1, len(m.steps))
 
-	header := styles.HeaderStyle.Render("Git Conventional Commit") +
-		styles.ProgressStyle.Render(progress) +
+	header := styles.HeaderStyle.Render("Git Conventional Commit")
+	if m.config.Profile != "" {
+		header += styles.ProfileStyle.Render(m.config.Profile)
+	}
+	if m.superproject != "" {
+		header += styles.SubmoduleStyle.Render("submodule of " + filepath.Base(m.superproject))
+	}
+	header += styles.ProgressStyle.Render(progress) +
 		"\n\n" +
 		styles.StepTitleStyle.Render(stepTitle) +
 		"\n" +
 		styles.DividerStyle.Render(strings.Repeat("─", m.width))
 
-	if m.activeStep == int(StepConfirm) {
-		// For confirmation step, add commit message preview
-		preview := m.commitMessage.Format()
+	if m.plan != nil && m.planReview == nil {
+		header += "\n" + styles.InfoStyle.Render(runewidth.Truncate(fmt.Sprintf("Commit %d of %d: %s",
+			m.plan.current()+1, len(m.plan.files), strings.Join(paths(m.plan.files[m.plan.current()]), ", ")),
+			max(m.width, 20), "…"))
+	}
+
+	if m.activeStep == int(StepConfirm) && m.planner == nil && m.planReview == nil {
+		// For confirmation step, add commit message preview, as git will record it
+		message := m.commitMessage.Format()
+		preview := m.cleanup.Clean(message)
 		header += "\n" + styles.PreviewStyle.Render("Preview:") + "\n\n" +
 			styles.PreviewContentStyle.Render(preview)
+
+		if removed := m.cleanup.RemovedComments(message); len(removed) > 0 {
+			header += "\n" + styles.WarningStyle.Render(fmt.Sprintf(
+				"commit.cleanup=strip removes %d comment line(s), e.g. %q", len(removed), removed[0]))
+		}
+		if len(m.config.CommitArgs) > 0 {
+			header += "\n" + styles.InfoStyle.Render("git commit "+strings.Join(m.config.CommitArgs, " "))
+		}
+	}
+
+	if status := m.statusView(); status != "" {
+		header += "\n" + status
+	}
+	if breaking := m.breakingToCommit(); len(breaking) > 0 && m.planner == nil && m.planReview == nil {
+		header += "\n" + styles.WarningStyle.Render(runewidth.Truncate(fmt.Sprintf(
+			"⚠ The staged changes break the exported Go API (%d): %s", len(breaking), breaking[0]), max(m.width, 20), "…"))
+	}
+	if m.nothingStaged() {
+		header += "\n" + styles.ErrorStyle.Render(
+			"⚠ Nothing is staged: stage your changes with git add first, or the commit will fail")
 	}
 
 	// Render step content
 	content := ""
-	if m.activeStep < len(m.steps) {
+	if m.planner != nil {
+		content = m.planner.View()
+	} else if m.planReview != nil {
+		content = m.planReview.View()
+	} else if m.hunks != nil {
+		content = m.hunks.View()
+	} else if m.staging != nil {
+		content = m.staging.View()
+	} else if m.configChoice != nil {
+		content = m.configChoice.View()
+	} else if m.activeStep == int(StepScope) && !m.sources[sourceScopes].done {
+		content = m.loadingStepView(sourceScopes)
+	} else if m.activeStep < len(m.steps) {
 		content = m.steps[m.activeStep].View()
 	}
 
+	if m.showStaged {
+		content = m.withStagedPane(content)
+	} else if m.planner == nil && m.planReview == nil && m.staging == nil && m.configChoice == nil {
+		content = m.withExamplesPane(content)
+	}
+
 	return fmt.Sprintf("%s\n\n%s\n\n%s",
 		header,
 		content,
-		styles.HelpStyle.Render("↑/↓: Navigate • Enter: Select • Esc: Back • Ctrl+C/Q: Quit"),
+		styles.HelpStyle.Render("↑/↓: Navigate • Enter: Select • Esc: Back • Ctrl+T: Staged files • Ctrl+O: Diff • Ctrl+G: Stage • Ctrl+P: Plan commits • Ctrl+C/Q: Quit"),
 	)
 }
 
-// Run runs the UI
-func Run(cfg *config.Config) error {
-	p := tea.NewProgram(New(cfg), tea.WithAltScreen())
-	_, err := p.Run()
-	return err
+// nothingStaged reports whether the staged files are known and there are none,
+// unless an empty commit was asked for
+func (m Model) nothingStaged() bool {
+	status := m.sources[sourceStaged]
+	return status.done && status.err == nil && m.staged.Empty() &&
+		!containsArg(m.config.CommitArgs, "--allow-empty")
+}
+
+// filtering reports whether the filter of the type or scope list is being typed
+func (m Model) filtering() bool {
+	switch step := m.steps[m.activeStep].(type) {
+	case components.CommitTypeModel:
+		return step.Filtering()
+	case components.ScopeModel:
+		return step.Filtering()
+	}
+	return false
+}
+
+// sidePaneMinWidth is the terminal width from which the staged changes pane
+// is shown next to the step instead of below it
+const sidePaneMinWidth = 100
+
+// withStagedPane adds the staged changes pane to the step content
+func (m Model) withStagedPane(content string) string {
+	if !m.sources[sourceStaged].done {
+		return content + "\n\n" + m.loadingStepView(sourceStaged)
+	}
+	if m.width >= sidePaneMinWidth {
+		width := min(m.width/3, 60)
+		return lipgloss.JoinHorizontal(lipgloss.Top, content, "  ", m.staged.View(width, m.height-8))
+	}
+	return content + "\n\n" + m.staged.View(m.width, m.height/2)
+}
+
+// hasExamplesPane reports whether the step is shown with the examples pane:
+// the type and scope steps are, when the terminal is wide enough
+func (m Model) hasExamplesPane() bool {
+	if m.width < sidePaneMinWidth || m.activeStep >= len(m.steps) {
+		return false
+	}
+	switch m.steps[m.activeStep].(type) {
+	case components.CommitTypeModel, components.ScopeModel:
+		return true
+	}
+	return false
+}
+
+// examplesPaneWidth returns the width of the examples pane in a terminal
+// of the given width
+func examplesPaneWidth(width int) int {
+	return min(width/3, 60)
+}
+
+// withExamplesPane adds the recent commits of the highlighted type, and of
+// the highlighted scope on the scope step, next to the step content
+func (m Model) withExamplesPane(content string) string {
+	if !m.hasExamplesPane() || !m.sources[sourceLog].done {
+		return content
+	}
+	var typ, scope string
+	switch step := m.steps[m.activeStep].(type) {
+	case components.CommitTypeModel:
+		typ = step.Highlighted()
+	case components.ScopeModel:
+		if !m.sources[sourceScopes].done {
+			return content
+		}
+		typ, scope = m.commitMessage.Type, step.Highlighted()
+	}
+	if typ == "" {
+		return content
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, content, "  ", m.examples.View(typ, scope, examplesPaneWidth(m.width)))
+}
+
+// containsArg reports whether a git commit argument is in args
+func containsArg(args []string, arg string) bool {
+	for _, a := range args {
+		if a == arg {
+			return true
+		}
+	}
+	return false
+}
+
+// errorView renders an error, explaining timeouts separately from git failures
+func errorView(err error, cfg *config.Config) string {
+	var view string
+	switch {
+	case errors.Is(err, git.ErrTimeout):
+		view = styles.ErrorStyle.Render("Timeout: git did not finish in time and was stopped") +
+			"\n\n" + fmt.Sprintf("%v", err) + "\n\n" +
+			styles.WarningStyle.Render(fmt.Sprintf(
+				"The limits are gitTimeout (%v) and commitTimeout (%v); a hook or credential prompt may be waiting for input.",
+				time.Duration(cfg.GitTimeout), time.Duration(cfg.CommitTimeout)))
+	case errors.Is(err, context.Canceled):
+		view = styles.WarningStyle.Render("Cancelled")
+	default:
+		view = styles.ErrorStyle.Render(fmt.Sprintf("Error: %v", err))
+	}
+	return view + "\n\n" + styles.HelpStyle.Render("Press any key to quit")
+}
+
+// Run runs the UI and returns the error it ended with, such as a failed
+// commit, so that the caller can exit with an error status
+func Run(ctx context.Context, cfg *config.Config, repo git.Repository) error {
+	p := tea.NewProgram(New(ctx, cfg, repo), tea.WithAltScreen())
+	final, err := p.Run()
+	if err != nil {
+		return err
+	}
+	return exitError(final)
+}
+
+// exitError returns the error shown by the final model of the UI
+func exitError(final tea.Model) error {
+	if m, ok := final.(Model); ok {
+		return m.err
+	}
+	return nil
 }
 
 // commitCmd creates a command for git commit
-func commitCmd(message string) tea.Cmd {
+func commitCmd(ctx context.Context, repo git.Repository, message string, opts git.CommitOptions) tea.Cmd {
+	return func() tea.Msg {
+		return commitDoneMsg{err: repo.Commit(ctx, message, opts)}
+	}
+}
+
+// stagedDiffCmd loads and parses the staged diff for the viewer
+func stagedDiffCmd(ctx context.Context, repo git.Repository) tea.Cmd {
 	return func() tea.Msg {
-		if err := git.Commit(message); err != nil {
-			return err
+		text, err := repo.GetStagedDiff(ctx)
+		if err != nil {
+			return stagedDiffLoadedMsg{err: err}
 		}
-		return nil
+		files, err := diff.Parse(text)
+		return stagedDiffLoadedMsg{files: files, err: err}
 	}
 }
@@ -0,0 +1,783 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a1yama/git-cz-go/internal/config"
+	"github.com/a1yama/git-cz-go/internal/git"
+	"github.com/a1yama/git-cz-go/internal/history"
+	"github.com/a1yama/git-cz-go/internal/ui/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// run executes a command and returns the messages it produces, expanding
+// batches and sequences. Commands that do not finish quickly, like cursor
+// blinking, are ignored.
+func run(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+
+	var msg tea.Msg
+	select {
+	case msg = <-done:
+	case <-time.After(50 * time.Millisecond):
+		return nil
+	}
+
+	// tea.BatchMsg and the internal sequence message are both slices of commands
+	if v := reflect.ValueOf(msg); v.IsValid() && v.Kind() == reflect.Slice && v.Type().Elem() == reflect.TypeOf(tea.Cmd(nil)) {
+		var msgs []tea.Msg
+		for i := 0; i < v.Len(); i++ {
+			msgs = append(msgs, run(v.Index(i).Interface().(tea.Cmd))...)
+		}
+		return msgs
+	}
+	if msg == nil {
+		return nil
+	}
+	return []tea.Msg{msg}
+}
+
+// send updates the model with a message and with all messages produced in response.
+// It reports whether the program quit.
+func send(t *testing.T, m tea.Model, msg tea.Msg) (tea.Model, bool) {
+	t.Helper()
+
+	queue := []tea.Msg{msg}
+	for i := 0; len(queue) > 0; i++ {
+		if i > 100 {
+			t.Fatal("Too many messages, possible update loop")
+		}
+		msg, queue = queue[0], queue[1:]
+		if _, ok := msg.(tea.QuitMsg); ok {
+			return m, true
+		}
+
+		var cmd tea.Cmd
+		m, cmd = m.Update(msg)
+		queue = append(queue, run(cmd)...)
+	}
+	return m, false
+}
+
+// start runs the commands of Init, loading the repository data like when the program starts
+func start(t *testing.T, m tea.Model) tea.Model {
+	t.Helper()
+	for _, msg := range run(m.Init()) {
+		m, _ = send(t, m, msg)
+	}
+	return m
+}
+
+// typeText sends each rune of the text as a key press
+func typeText(t *testing.T, m tea.Model, text string) tea.Model {
+	t.Helper()
+	for _, r := range text {
+		m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return m
+}
+
+// newTestRepository creates a repository with a staged file, whose caches
+// and history are written to a temporary directory
+func newTestRepository(t *testing.T) *git.MemoryRepository {
+	repo := git.NewMemoryRepository("/repo")
+	repo.CommonDir = t.TempDir()
+	repo.Files["internal/ui/ui.go"] = []byte("package ui\n")
+	repo.Staged["internal/ui/ui.go"] = []byte("package ui\n\n// changed\n")
+	return repo
+}
+
+func TestCommitFlow(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.UseEmoji = false
+	repo := newTestRepository(t)
+
+	var m tea.Model = New(context.Background(), cfg, repo)
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = start(t, m)
+
+	if view := m.View(); view == "" {
+		t.Fatal("View() returned an empty string")
+	}
+
+	// Type: the first one is feat
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	// Scope: the detected scope of the staged file is pre-selected
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	// Subject
+	m = typeText(t, m, "add fake repository")
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	// Body and breaking change: skipped
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	if got := m.(Model).activeStep; got != int(StepConfirm) {
+		t.Fatalf("activeStep = %d, want %d", got, StepConfirm)
+	}
+
+	// Confirm
+	_, quit := send(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if !quit {
+		t.Error("Expected the program to quit after confirming")
+	}
+
+	if len(repo.Commits) != 1 {
+		t.Fatalf("Expected 1 commit, got %d", len(repo.Commits))
+	}
+	if want := "feat(internal): add fake repository"; repo.Commits[0].Message != want {
+		t.Errorf("Commit message = %q, want %q", repo.Commits[0].Message, want)
+	}
+}
+
+func TestCancelDoesNotCommit(t *testing.T) {
+	repo := newTestRepository(t)
+
+	var m tea.Model = New(context.Background(), config.DefaultConfig(), repo)
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = start(t, m)
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = typeText(t, m, "do nothing")
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	_, quit := send(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if !quit {
+		t.Error("Expected the program to quit after cancelling")
+	}
+	if len(repo.Commits) != 0 {
+		t.Errorf("Expected no commits, got %d", len(repo.Commits))
+	}
+}
+
+func TestScopeFilterKeepsKeys(t *testing.T) {
+	repo := newTestRepository(t)
+
+	var m tea.Model = New(context.Background(), config.DefaultConfig(), repo)
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = start(t, m)
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	// While the filter is typed, q is part of it and Esc cancels it
+	m = typeText(t, m, "/q")
+	m, quit := send(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if quit || m.(Model).activeStep != int(StepScope) {
+		t.Fatalf("Expected to stay on the scope step while filtering, got step %d", m.(Model).activeStep)
+	}
+	if m.(Model).filtering() {
+		t.Error("Expected Esc to cancel the filter")
+	}
+
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.(Model).activeStep != int(StepType) {
+		t.Errorf("Expected Esc to go back once the filter is cancelled, got step %d", m.(Model).activeStep)
+	}
+}
+
+func TestCommitTimeoutIsReported(t *testing.T) {
+	repo := newTestRepository(t)
+	repo.CommitErr = fmt.Errorf("git commit: %w", git.ErrTimeout)
+
+	var m tea.Model = New(context.Background(), config.DefaultConfig(), repo)
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = start(t, m)
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = typeText(t, m, "wait for a hook")
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	m, quit := send(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if quit {
+		t.Fatal("Expected the program to show the error instead of quitting")
+	}
+	if view := m.View(); !strings.Contains(view, "Timeout") {
+		t.Errorf("Expected the view to report a timeout, got:\n%s", view)
+	}
+
+	// Any key quits after an error
+	if _, quit := send(t, m, tea.KeyMsg{Type: tea.KeyEnter}); !quit {
+		t.Error("Expected the program to quit after the error was shown")
+	}
+}
+
+func TestQuitCancelsContext(t *testing.T) {
+	m := New(context.Background(), config.DefaultConfig(), newTestRepository(t))
+	ctx := m.ctx
+
+	if _, quit := send(t, m, tea.KeyMsg{Type: tea.KeyCtrlC}); !quit {
+		t.Fatal("Expected Ctrl+C to quit")
+	}
+	if ctx.Err() == nil {
+		t.Error("Expected Ctrl+C to cancel running git commands")
+	}
+}
+
+func TestTypeCanBePickedWhileLoading(t *testing.T) {
+	repo := newTestRepository(t)
+	repo.Commits = []git.LogEntry{{Hash: "c1", Message: "init"}}
+
+	var m tea.Model = New(context.Background(), config.DefaultConfig(), repo)
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+
+	// Nothing has been loaded yet, but a type can be picked
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if got := m.(Model).activeStep; got != int(StepScope) {
+		t.Fatalf("activeStep = %d, want %d", got, StepScope)
+	}
+	if view := m.View(); !strings.Contains(view, "Loading scopes") {
+		t.Errorf("Expected the scope step to wait for the scopes, got:\n%s", view)
+	}
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if got := m.(Model).activeStep; got != int(StepScope) {
+		t.Fatalf("activeStep = %d, want the scope step to wait for the scopes", got)
+	}
+
+	m = start(t, m)
+	if view := m.View(); strings.Contains(view, "Loading") || !strings.Contains(view, "internal") {
+		t.Errorf("Expected the loaded scopes, got:\n%s", view)
+	}
+}
+
+func TestCommitWaitsForLoading(t *testing.T) {
+	repo := newTestRepository(t)
+	repo.Config = []git.ConfigEntry{{Key: "commit.cleanup", Value: "strip"}}
+
+	var m tea.Model = New(context.Background(), config.DefaultConfig(), repo)
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = send(t, m, sourceLoadedMsg{source: sourceScopes, value: []config.Scope(nil)})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = typeText(t, m, "wait for the cleanup mode")
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	m, quit := send(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if quit || len(repo.Commits) != 0 {
+		t.Fatal("Expected the commit to wait until the repository data is loaded")
+	}
+
+	// The history and the log are not needed for the commit
+	for _, msg := range run(m.Init()) {
+		if loaded, ok := msg.(sourceLoadedMsg); ok && (loaded.source == sourceScopes ||
+			loaded.source == sourceHistory || loaded.source == sourceLog) {
+			continue
+		}
+		var done bool
+		m, done = send(t, m, msg)
+		quit = quit || done
+	}
+	if !quit || len(repo.Commits) != 1 {
+		t.Fatalf("Expected a commit once loaded, got %d", len(repo.Commits))
+	}
+	if repo.CommitOptions.Cleanup != git.CleanupStrip {
+		t.Errorf("Cleanup = %q, want %q", repo.CommitOptions.Cleanup, git.CleanupStrip)
+	}
+}
+
+// failingRepository fails to list the staged files
+type failingRepository struct {
+	*git.MemoryRepository
+}
+
+func (r failingRepository) GetStagedChanges(ctx context.Context) ([]git.FileChange, error) {
+	return nil, fmt.Errorf("index is locked")
+}
+
+func TestFailedSourceIsDegraded(t *testing.T) {
+	var m tea.Model = New(context.Background(), config.DefaultConfig(), failingRepository{newTestRepository(t)})
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = start(t, m)
+
+	view := m.View()
+	if !strings.Contains(view, "Could not load staged files") || !strings.Contains(view, "index is locked") {
+		t.Errorf("Expected a warning about the staged files, got:\n%s", view)
+	}
+	if got := m.(Model).err; got != nil {
+		t.Errorf("err = %v, want the wizard to keep running", got)
+	}
+}
+
+func TestNotARepository(t *testing.T) {
+	var m tea.Model = New(context.Background(), config.DefaultConfig(), git.NewMemoryRepository(""))
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = start(t, m)
+
+	if view := m.View(); !strings.Contains(view, "not a git repository") {
+		t.Errorf("Expected the view to report the missing repository, got:\n%s", view)
+	}
+
+	// Any key quits, and the error is returned so that the command fails
+	m, quit := send(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	if !quit {
+		t.Fatal("Expected a key to quit")
+	}
+	if err := exitError(m); err == nil || !strings.Contains(err.Error(), "not a git repository") {
+		t.Errorf("exitError() = %v, want the missing repository", err)
+	}
+}
+
+func TestStagedPane(t *testing.T) {
+	var m tea.Model = New(context.Background(), config.DefaultConfig(), newTestRepository(t))
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = start(t, m)
+
+	if view := m.View(); strings.Contains(view, "Staged changes") {
+		t.Errorf("Expected the staged changes pane to be hidden at first, got:\n%s", view)
+	}
+
+	// The pane can be toggled while typing the subject
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyCtrlT})
+	view := m.View()
+	for _, want := range []string{"Staged changes", "1 file changed, +2 -0", "M internal/ui/ui.go"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected the pane to contain %q, got:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "Nothing is staged") {
+		t.Errorf("Expected no warning with staged changes, got:\n%s", view)
+	}
+
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyCtrlT})
+	if view := m.View(); strings.Contains(view, "Staged changes") {
+		t.Errorf("Expected Ctrl+T to hide the pane, got:\n%s", view)
+	}
+}
+
+func TestNothingStagedWarning(t *testing.T) {
+	repo := newTestRepository(t)
+	repo.Staged = map[string][]byte{}
+
+	var m tea.Model = New(context.Background(), config.DefaultConfig(), repo)
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = start(t, m)
+	if view := m.View(); !strings.Contains(view, "Nothing is staged") {
+		t.Errorf("Expected a warning when nothing is staged, got:\n%s", view)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.CommitArgs = []string{"--allow-empty"}
+	m = New(context.Background(), cfg, repo)
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = start(t, m)
+	if view := m.View(); strings.Contains(view, "Nothing is staged") {
+		t.Errorf("Expected no warning with --allow-empty, got:\n%s", view)
+	}
+}
+
+func TestDiffViewerKeepsStep(t *testing.T) {
+	var m tea.Model = New(context.Background(), config.DefaultConfig(), newTestRepository(t))
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = start(t, m)
+
+	// Open the viewer while typing the subject
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = typeText(t, m, "add viewer")
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyCtrlO})
+
+	view := m.View()
+	for _, want := range []string{"internal/ui/ui.go (1/1)", "+// changed"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected the diff viewer to contain %q, got:\n%s", want, view)
+		}
+	}
+
+	// Keys go to the viewer, so "q" closes it instead of being typed
+	m = typeText(t, m, "/changed")
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if view := m.View(); !strings.Contains(view, "/changed: 1 matches") {
+		t.Errorf("Expected the search to be shown, got:\n%s", view)
+	}
+	m = typeText(t, m, "q")
+
+	ui := m.(Model)
+	if ui.diffViewer != nil {
+		t.Fatal("Expected q to close the diff viewer")
+	}
+	if ui.activeStep != int(StepSubject) {
+		t.Errorf("Expected to return to the subject step, got step %d", ui.activeStep)
+	}
+	if view := m.View(); !strings.Contains(view, "add viewer") {
+		t.Errorf("Expected the subject to be kept, got:\n%s", view)
+	}
+}
+
+func TestStagingBeforeWizard(t *testing.T) {
+	repo := newTestRepository(t)
+	repo.Staged = map[string][]byte{}
+	repo.Worktree["internal/ui/ui.go"] = []byte("package ui\n\n// changed\n")
+	repo.Worktree["notes.txt"] = []byte("todo\n")
+
+	var m tea.Model = New(context.Background(), config.DefaultConfig(), repo)
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = start(t, m)
+
+	view := m.View()
+	for _, want := range []string{"Stage the changes to commit", "[ ]  M internal/ui/ui.go", "[ ] ?? notes.txt"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected the staging step to contain %q, got:\n%s", want, view)
+		}
+	}
+
+	// Stage all tracked files, then continue to the wizard
+	m = typeText(t, m, "a")
+	if view := m.View(); !strings.Contains(view, "[x] M  internal/ui/ui.go") {
+		t.Errorf("Expected the tracked file to be staged, got:\n%s", view)
+	}
+	if _, ok := repo.Staged["notes.txt"]; ok {
+		t.Error("Expected untracked files not to be staged")
+	}
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	view = m.View()
+	if !strings.Contains(view, "Select the type of change") {
+		t.Errorf("Expected the type step after staging, got:\n%s", view)
+	}
+	if strings.Contains(view, "Nothing is staged") {
+		t.Errorf("Expected no warning after staging, got:\n%s", view)
+	}
+	if ui := m.(Model); ui.loading() {
+		t.Errorf("Expected the staged files to be loaded again, got %+v", ui.sources)
+	}
+}
+
+func TestStagingRefreshesStagedPane(t *testing.T) {
+	repo := newTestRepository(t)
+	repo.Worktree["notes.txt"] = []byte("todo\n")
+
+	var m tea.Model = New(context.Background(), config.DefaultConfig(), repo)
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = start(t, m)
+	if view := m.View(); strings.Contains(view, "Stage the changes to commit") {
+		t.Fatalf("Expected no staging step when files are staged, got:\n%s", view)
+	}
+
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyCtrlT})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyCtrlG})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+
+	view := m.View()
+	for _, want := range []string{"Select the type of change", "2 files changed", "A notes.txt"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q after staging, got:\n%s", want, view)
+		}
+	}
+}
+
+func TestStagingReloadsNestedConfig(t *testing.T) {
+	repo := newTestRepository(t)
+	repo.Root = t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo.Root, "web"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo.Root, "web", ".git-cz.json"), []byte(`{"scopes": ["frontend"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo.Worktree["web/app.ts"] = []byte("app\n")
+
+	var m tea.Model = New(context.Background(), config.DefaultConfig(), repo)
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = start(t, m)
+
+	// Staging a file below the nested config with Ctrl+G applies it
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyCtrlG})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	if view := m.View(); !strings.Contains(view, "frontend") {
+		t.Errorf("Expected the scopes of the nested config after staging, got:\n%s", view)
+	}
+}
+
+func TestStagingHunks(t *testing.T) {
+	repo := newTestRepository(t)
+	repo.Staged = map[string][]byte{}
+	repo.Files["numbers.txt"] = []byte("1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n")
+	repo.Worktree["numbers.txt"] = []byte("one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n")
+
+	var m tea.Model = New(context.Background(), config.DefaultConfig(), repo)
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = start(t, m)
+
+	// Pick only the first change, splitting its hunk if needed
+	m = typeText(t, m, "p")
+	if view := m.View(); !strings.Contains(view, "Select the hunks to stage") {
+		t.Fatalf("Expected the hunk selector, got:\n%s", view)
+	}
+	m = typeText(t, m, "s ")
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	if got, want := string(repo.Staged["numbers.txt"]), "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"; got != want {
+		t.Errorf("Staged numbers.txt = %q, want %q", got, want)
+	}
+	if view := m.View(); !strings.Contains(view, "[~] MM numbers.txt") {
+		t.Errorf("Expected the staging step with a partially staged file, got:\n%s", view)
+	}
+}
+
+func TestCommitPlan(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.UseEmoji = false
+	repo := newTestRepository(t)
+	repo.Staged["README.md"] = []byte("# readme\n")
+
+	var m tea.Model = New(context.Background(), cfg, repo)
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = start(t, m)
+
+	// Move the second file to its own commit
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyCtrlP})
+	if view := m.View(); !strings.Contains(view, "Split the staged files into commits") {
+		t.Fatalf("Expected the planner, got:\n%s", view)
+	}
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyRight})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	for i, subject := range []string{"add readme", "change ui"} {
+		if view := m.View(); !strings.Contains(view, fmt.Sprintf("Commit %d of 2", i+1)) {
+			t.Fatalf("Expected the wizard for commit %d, got:\n%s", i+1, view)
+		}
+		m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+		m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+		m = typeText(t, m, subject)
+		m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+		m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+		m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+		m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	}
+	if len(repo.Commits) != 0 {
+		t.Fatal("Commits were created before the plan was reviewed")
+	}
+	view := m.View()
+	// docs is suggested for the commit with only README.md
+	for _, want := range []string{"Review the planned commits", "docs: add readme", "feat(internal): change ui", "internal/ui/ui.go"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Review does not contain %q:\n%s", want, view)
+		}
+	}
+
+	_, quit := send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if !quit {
+		t.Error("Expected the program to quit after the commits")
+	}
+	if len(repo.Commits) != 2 || repo.Commits[1].Message != "docs: add readme" || repo.Commits[0].Message != "feat(internal): change ui" {
+		t.Fatalf("Commits = %+v", repo.Commits)
+	}
+	if len(repo.Staged) != 0 {
+		t.Errorf("Staged after the plan = %v, want nothing", repo.Staged)
+	}
+}
+
+func TestDependencyBumpMessage(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.UseEmoji = false
+	repo := git.NewMemoryRepository("/repo")
+	repo.CommonDir = t.TempDir()
+	repo.Commits = []git.LogEntry{{Hash: "c1", Message: "init"}}
+	repo.Files["go.mod"] = []byte("module example.com/app\n\nrequire github.com/charmbracelet/bubbletea v0.24.2\n")
+	repo.Staged["go.mod"] = []byte("module example.com/app\n\nrequire github.com/charmbracelet/bubbletea v0.25.0\n")
+	repo.Files["go.sum"] = []byte("old\n")
+	repo.Staged["go.sum"] = []byte("new\n")
+
+	var m tea.Model = New(context.Background(), cfg, repo)
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = start(t, m)
+	if view := m.View(); !strings.Contains(view, "the staged changes only bump dependencies") {
+		t.Fatalf("Expected the build type to be suggested, got:\n%s", view)
+	}
+
+	// Type, scope, subject and body are all proposed, and nothing breaks
+	for i := 0; i < 5; i++ {
+		m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	}
+	if _, quit := send(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")}); !quit {
+		t.Fatal("Expected the program to quit after confirming")
+	}
+	want := "build(deps): bump github.com/charmbracelet/bubbletea from v0.24.2 to v0.25.0"
+	if len(repo.Commits) != 2 || repo.Commits[0].Message != want {
+		t.Errorf("Commits = %+v, want %q", repo.Commits, want)
+	}
+}
+
+func TestBreakingAPIChange(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.UseEmoji = false
+	repo := git.NewMemoryRepository("/repo")
+	repo.CommonDir = t.TempDir()
+	repo.Commits = []git.LogEntry{{Hash: "c1", Message: "init"}}
+	repo.Files["pkg/api/api.go"] = []byte("package api\n\nfunc Old() {}\n\nfunc Kept() {}\n")
+	repo.Staged["pkg/api/api.go"] = []byte("package api\n\nfunc Kept() {}\n")
+
+	var m tea.Model = New(context.Background(), cfg, repo)
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = start(t, m)
+	if view := m.View(); !strings.Contains(view, "break the exported Go API (1): pkg/api: removed function Old") {
+		t.Fatalf("Expected a warning about the removed function, got:\n%s", view)
+	}
+
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = typeText(t, m, "remove Old")
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if view := m.View(); !strings.Contains(view, "[x] Breaking change") {
+		t.Fatalf("Expected the breaking change to be marked, got:\n%s", view)
+	}
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if _, quit := send(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")}); !quit {
+		t.Fatal("Expected the program to quit after confirming")
+	}
+
+	want := "feat(pkg)!: remove Old\n\nBREAKING CHANGE: pkg/api: removed function Old"
+	if len(repo.Commits) != 2 || repo.Commits[0].Message != want {
+		t.Errorf("Commits = %+v, want %q", repo.Commits, want)
+	}
+}
+
+func TestHistoryOrdersAndCompletes(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.UseEmoji = false
+	repo := newTestRepository(t)
+	repo.Commits = []git.LogEntry{
+		{Hash: "c3", Message: "docs(cli): explain flags"},
+		{Hash: "c2", Message: "docs: fix typo"},
+		{Hash: "c1", Message: "fix(internal): keep the cursor"},
+	}
+
+	var m tea.Model = New(context.Background(), cfg, repo)
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = start(t, m)
+
+	// Type: docs was used the most
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	// Subject: completed from an earlier one
+	m = typeText(t, m, "keep")
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyRight})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	send(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+
+	if want := "docs(internal): keep the cursor"; repo.Commits[0].Message != want {
+		t.Fatalf("Commit message = %q, want %q", repo.Commits[0].Message, want)
+	}
+	hist, err := history.Load(context.Background(), repo)
+	if err != nil || len(hist.Entries) != 4 || hist.Entries[0].Subject != "keep the cursor" {
+		t.Errorf("history = %+v, %v, want the commit recorded first", hist, err)
+	}
+}
+
+func TestLateHistoryKeepsTypeCursor(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.UseEmoji = false
+	repo := newTestRepository(t)
+	repo.Commits = []git.LogEntry{{Hash: "c1", Message: "docs: fix typo"}}
+
+	var m tea.Model = New(context.Background(), cfg, repo)
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	var late []tea.Msg
+	for _, msg := range run(m.Init()) {
+		if loaded, ok := msg.(sourceLoadedMsg); ok && loaded.source == sourceLog {
+			late = append(late, msg)
+			continue
+		}
+		m, _ = send(t, m, msg)
+	}
+
+	// The history arrives after the user has moved in the type list
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	highlighted := m.(Model).steps[StepType].(components.CommitTypeModel).Highlighted()
+	for _, msg := range late {
+		m, _ = send(t, m, msg)
+	}
+	if got := m.(Model).steps[StepType].(components.CommitTypeModel).Highlighted(); got != highlighted {
+		t.Errorf("Highlighted() = %q after the history was loaded, want %q", got, highlighted)
+	}
+
+	// The new order is applied when the user comes back to the list
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if view := m.View(); strings.Index(view, "docs") > strings.Index(view, "feat") {
+		t.Errorf("Expected docs first once the type list is visited again, got:\n%s", view)
+	}
+}
+
+func TestExamplesPane(t *testing.T) {
+	repo := newTestRepository(t)
+	repo.Commits = []git.LogEntry{
+		{Hash: "c3", Message: "fix(internal): keep the cursor"},
+		{Hash: "c2", Message: "feat(docs): explain the flags"},
+		{Hash: "c1", Message: "feat(internal): add the fake repository"},
+	}
+	cfg := config.DefaultConfig()
+	cfg.UseEmoji = false
+
+	var m tea.Model = New(context.Background(), cfg, repo)
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = start(t, m)
+
+	// The type list is ordered by use, so feat comes first
+	if view := m.View(); !strings.Contains(view, "Recent feat commits") || !strings.Contains(view, "feat(docs): explain the flags") {
+		t.Errorf("Expected the feat examples next to the types, got:\n%s", view)
+	}
+
+	// On the scope step, the examples of the highlighted scope are shown
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	view := m.View()
+	if !strings.Contains(view, "Recent feat(internal) commits") || strings.Contains(view, "explain the flags") {
+		t.Errorf("Expected the feat(internal) examples next to the scopes, got:\n%s", view)
+	}
+
+	// Narrow terminals leave the room to the list
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 80, Height: 40})
+	if view := m.View(); strings.Contains(view, "Recent feat") {
+		t.Errorf("Expected no examples pane in a narrow terminal, got:\n%s", view)
+	}
+}
+
+func TestDetectedScopeNames(t *testing.T) {
+	repo := newTestRepository(t)
+	repo.Files["apps/web/api/api.go"] = nil
+	repo.Files["services/api/api.go"] = nil
+	repo.Files["services/auth/auth.go"] = nil
+	cfg := config.DefaultConfig()
+	cfg.ScopeDirs = []string{"apps/*/*", "services/*"}
+
+	got, err := scopes(context.Background(), cfg, repo)
+	if err != nil {
+		t.Fatalf("scopes() error = %v", err)
+	}
+	// Directories sharing their last element are named after their path
+	want := []config.Scope{
+		{Name: "apps/web/api", Paths: []string{"apps/web/api/**"}},
+		{Name: "services/api", Paths: []string{"services/api/**"}},
+		{Name: "auth", Description: "services/auth", Paths: []string{"services/auth/**"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("scopes() = %+v, want %+v", got, want)
+	}
+}